@@ -21,7 +21,12 @@ func main() {
 	ctx := context.Background()
 	ctx = contextutils.WithLogger(ctx, "squash")
 
-	err := plank.Debug(ctx)
+	var err error
+	if plank.IsProbe() {
+		err = plank.Probe(ctx)
+	} else {
+		err = plank.Debug(ctx)
+	}
 	if err != nil {
 		fmt.Println(err)
 		logger.With(zap.Error(err)).Fatal("debug failed!")
@@ -26,11 +26,8 @@ func main() {
 // This function is a way to fail early (from the squash pod) if the running
 // version of Kubernetes does not support the needed API.
 func mustGetContainerProcessLocator() {
-	_, err := kubernetes.NewContainerProcess()
+	_, _, err := kubernetes.NewNodeContainerProcess()
 	if err != nil {
-		_, err := kubernetes.NewCRIContainerProcessAlphaV1()
-		if err != nil {
-			log.WithError(err).Fatal("Cannot get container process locator")
-		}
+		log.WithError(err).Fatal("Cannot get container process locator")
 	}
 }
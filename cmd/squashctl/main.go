@@ -21,7 +21,7 @@ func main() {
 	}
 
 	if err := app.Execute(); err != nil {
-		fmt.Println(err)
+		squashctl.ReportError(app, err)
 		os.Exit(1)
 	}
 }
@@ -2,6 +2,7 @@
 
 import * as kube from './kube-interfaces';
 import * as shelljs from 'shelljs';
+import * as child_process from 'child_process';
 
 
 import * as config from './config';
@@ -16,6 +17,7 @@ import * as vscode from 'vscode';
    Squashctl creates a debug connection, prints out needed information
    Extension parses the squashctl return value
    Extension uses vscode's debug capabilities
+   Squashctl keeps forwarding the debugger's port until the debug session ends
 */
 
 /*
@@ -211,7 +213,7 @@ class SquashExtension {
         }
 
         // choose debugger to use
-        const debuggerList = ["dlv", "java"];
+        const debuggerList = ["dlv", "java", "nodejs", "nodejs8", "python", "debugpy", "lldb"];
         let debuggerItems: DebuggerPickItem[] = debuggerList.map(name => new DebuggerPickItem(name));
         let debuggerOptions: vscode.QuickPickOptions = {
             placeHolder: "Please select a debugger",
@@ -228,25 +230,31 @@ class SquashExtension {
         let processMatch = config.get_conf_or("processMatch", "");
 
         // now invoke squashctl
-        let cmdSpec = `${squashpath} ${extraArgs} --machine`;
-        cmdSpec += ` --pod ${selectedPod.metadata.name}`;
-        cmdSpec += ` --namespace ${selectedPod.metadata.namespace}`;
-        cmdSpec += ` --container ${selectedContainer.container.name}`;
-        cmdSpec += ` --debugger ${debuggerName}`;
+        let args = maybeKubeConfigArgs();
+        if (extraArgs) {
+            args = args.concat(extraArgs.split(/\s+/).filter((arg: string) => arg !== ""));
+        }
+        args.push("--machine");
+        args.push("--pod", selectedPod.metadata.name);
+        args.push("--namespace", selectedPod.metadata.namespace);
+        args.push("--container", selectedContainer.container.name);
+        args.push("--debugger", debuggerName);
         if (processMatch !== "") {
-            cmdSpec += ` --process-match ${processMatch}`;            
+            args.push("--process-match", processMatch);
         }
-        console.log(`executing ${cmdSpec}`);
-        let stdout = await exec(cmdSpec);
-        let responseData = JSON.parse(stdout);
-        if (!responseData) {
-            throw new Error("can't parse output of squashctl: " + stdout);
+        // squashctl forwards the debugger's port for as long as it runs
+        let session = await squashctl_session(squashpath, args);
+        let responseData: EditorData;
+        try {
+            responseData = editorData(session.line);
+        } catch (err) {
+            session.child.kill();
+            throw err;
         }
 
         let remotepath = config.get_conf_or("remotePath", null);
 
-        // port forward
-        let localport = await kubectl_portforward(responseData.PortForwardCmd);
+        let localport = parseInt(responseData.LocalAddress.split(":").pop() || "");
 
         let localpath = workspace.uri.fsPath;
         // start debugging!
@@ -291,7 +299,6 @@ class SquashExtension {
                     };
                     break;
                 case "python":
-                    // TODO - add this to config when python enabled
                     let ptvsdsecret = config.get_conf_or("pythonSecret", "");
                     debuggerconfig = {
                         type: "python",
@@ -304,6 +311,21 @@ class SquashExtension {
                         host: "127.0.0.1"
                     };
                     break;
+                case "debugpy":
+                    debuggerconfig = {
+                        type: "python",
+                        request: "attach",
+                        name: "Python: Attach",
+                        connect: {
+                            host: "127.0.0.1",
+                            port: localport
+                        },
+                        pathMappings: [{
+                            localRoot: localpath,
+                            remoteRoot: remotepath
+                        }]
+                    };
+                    break;
                 case "gdb":
                     let autorun: string[] = [];
                     if (remotepath) {
@@ -319,15 +341,43 @@ class SquashExtension {
                         autorun: autorun
                     };
                     break;
+                case "lldb":
+                    let sourceMap: { [remote: string]: string } = {};
+                    if (remotepath) {
+                        sourceMap[remotepath] = localpath;
+                    }
+                    debuggerconfig = {
+                        type: "lldb",
+                        request: "custom",
+                        name: "Attach to lldb-server",
+                        processCreateCommands: [`gdb-remote 127.0.0.1:${localport}`],
+                        sourceMap: sourceMap
+                    };
+                    break;
                 default:
+                    session.child.kill();
                     throw new Error(`Unknown debugger ${debuggerName}`);
             }
 
-        return vscode.debug.startDebugging(
+        // the debug session is recognized by its name, which is unique to this squashctl process
+        let sessionName = `${debuggerconfig.name} (squash ${session.child.pid})`;
+        debuggerconfig.name = sessionName;
+        let terminated = vscode.debug.onDidTerminateDebugSession(debugSession => {
+            if (debugSession.name === sessionName) {
+                terminated.dispose();
+                session.child.kill();
+            }
+        });
+        this.context.subscriptions.push(terminated);
+        let started = await vscode.debug.startDebugging(
             workspace,
             debuggerconfig
         );
-
+        if (!started) {
+            terminated.dispose();
+            session.child.kill();
+        }
+        return started;
     }
 
     async  getPods(namespace: string): Promise<kube.Pod[]> {
@@ -359,40 +409,90 @@ export class WorkspaceFolderPickItem implements vscode.QuickPickItem {
     }
 }
 
-function kubectl_portforward(cmd: string): Promise<number> {
-    console.log("Executing: " + cmd);
-    let p = new Promise<number>((resolve, reject) => {
-        let resolved = false;
-        let handler = function (code: number, stdout: string, stderr: string) {
-            if (resolved !== true) {
-                if (code !== 0) {
-                    reject(new ExecError(code, stdout, stderr));
-                } else {
-                    reject(new Error("Didn't receive port"));
-                }
-            } else {
-                console.log(`port forward ended unexpectly: ${code} ${stdout} ${stderr} `);
-            }
-        };
+// the json document that squashctl prints with --machine
+interface JsonDocument {
+    version: string;
+    // names the shape of data
+    kind: string;
+    data?: any;
+    // set instead of data when squashctl failed
+    error?: { type: string; info: string };
+}
 
-        let options = {
-            env: maybeKubeEnv(),
-        };
-        let child = shelljs.exec(cmd, options, handler);
+// describes a ready debug session, the data of EditorData documents
+interface EditorData {
+    LocalAddress: string;
+    Processes: { ContainerName: string; Pid: number; LocalAddress: string; DebuggerUrl?: string }[];
+}
+
+// editorData reads the debug session from the json line of squashctl, or throws the error that squashctl reported
+function editorData(line: string): EditorData {
+    let doc: JsonDocument;
+    try {
+        doc = JSON.parse(line);
+    } catch (err) {
+        throw new Error("can't parse output of squashctl: " + line);
+    }
+    if (doc && doc.error) {
+        throw new Error(`squashctl failed: ${doc.error.info}`);
+    }
+    if (!doc || doc.kind !== "EditorData" || !doc.data || !doc.data.LocalAddress) {
+        throw new Error("unexpected output of squashctl: " + line);
+    }
+    return doc.data;
+}
+
+interface SquashctlSession {
+    // squashctl forwards the debugger's port until it is killed
+    child: child_process.ChildProcess;
+    // the json line that describes the debug session
+    line: string;
+}
+
+function squashctl_session(squashpath: string, args: string[]): Promise<SquashctlSession> {
+    console.log("Executing: " + squashpath + " " + args.join(" "));
+    return new Promise<SquashctlSession>((resolve, reject) => {
+        let resolved = false;
         let stdout = "";
+        let stderr = "";
+
+        let child = child_process.spawn(squashpath, args, {
+            stdio: ['ignore', 'pipe', 'pipe'],
+            env: maybeKubeEnv() || process.env,
+        });
         child.stdout.on('data', function (data) {
+            if (resolved) {
+                console.log(`squashctl: ${data}`);
+                return;
+            }
             stdout += data;
-            let portRegexp = /from\s+.+:(\d+)\s+->/g;
-            let match = portRegexp.exec(stdout);
-            if (match !== null) {
+            // progress is reported on stderr, the json line is the first one that stdout completes
+            for (let line of stdout.split("\n").slice(0, -1)) {
+                if (line.trim().startsWith("{")) {
+                    resolved = true;
+                    resolve({ child: child, line: line.trim() });
+                    return;
+                }
+            }
+        });
+        child.stderr.on('data', function (data) {
+            stderr += data;
+        });
+        child.on('error', function (err) {
+            if (!resolved) {
+                resolved = true;
+                reject(err);
+            }
+        });
+        child.on('exit', function (code: number | null) {
+            if (!resolved) {
                 resolved = true;
-                resolve(parseInt(match[1]));
+                reject(new ExecError(code === null ? -1 : code, stdout, stderr));
+            } else {
+                console.log(`squashctl ended: ${code} ${stderr}`);
             }
         });
     });
-
-    console.log(["port forwarding on", JSON.stringify(p)]);
-    return p;
 }
 
 function kubectl_get<T=any>(cmd: string, ...args: string[]): Promise<T> {
@@ -414,6 +514,14 @@ function maybeKubeConfig(): string {
     return maybeKubeConfig;
 }
 
+function maybeKubeConfigArgs(): string[] {
+    let maybeKubeConfig: string = config.get_conf_or("kubeConfig", null);
+    if (!maybeKubeConfig) {
+        return [];
+    }
+    return [`--kubeconfig=${maybeKubeConfig}`];
+}
+
 function maybeKubeEnv(): Object | null {
     let maybeKubeConfig: string = config.get_conf_or("kubeConfig", null);
     if (!maybeKubeConfig) {
@@ -1,37 +1,45 @@
 package actions
 
 import (
+	"time"
+
 	"github.com/solo-io/solo-kit/pkg/api/v1/clients"
 	"github.com/solo-io/solo-kit/pkg/api/v1/resources/core"
 	v1 "github.com/solo-io/squash/pkg/api/v1"
+	sqOpts "github.com/solo-io/squash/pkg/options"
 )
 
-// Attach creates a DebugAttachment with a state of PendingAttachment
-func (uc *UserController) Attach(daName, namespace, image, podName, container, processName, dbgger string) (*v1.DebugAttachment, error) {
-	di := v1.Intent{
-		Debugger: dbgger,
-		Pod: &core.ResourceRef{
-			Name:      podName,
-			Namespace: namespace,
+// Attach creates a DebugAttachment with a state of RequestingAttachment
+func (uc *UserController) Attach(daName string, intent v1.Intent) (*v1.DebugAttachment, error) {
+	da := v1.DebugAttachment{
+		Metadata: core.Metadata{
+			Name:        daName,
+			Namespace:   intent.Pod.Namespace,
+			Labels:      intent.GenerateLabels(),
+			Annotations: creationAnnotations(),
 		},
-		ContainerName: container,
+		Intent: &intent,
+		State:  v1.DebugAttachment_RequestingAttachment,
+	}
+	writeOpts := clients.WriteOpts{
+		Ctx:               uc.ctx,
+		OverwriteExisting: false,
 	}
-	attachlabels := di.GenerateLabels()
+	return uc.daClient.Write(&da, writeOpts)
+}
+
+// MatchRequest creates a DebugAttachment that asks squash to debug each new pod matching intent.PodSelector
+func (uc *UserController) MatchRequest(daName string, intent v1.Intent) (*v1.DebugAttachment, error) {
 	da := v1.DebugAttachment{
 		Metadata: core.Metadata{
-			Name:      daName,
-			Namespace: namespace,
-			Labels:    attachlabels,
+			Name:        daName,
+			Namespace:   intent.Pod.Namespace,
+			Labels:      intent.GenerateLabels(),
+			Annotations: creationAnnotations(),
 		},
-		Debugger:       dbgger,
-		Image:          image,
-		Pod:            podName,
-		Container:      container,
-		DebugNamespace: namespace,
-		State:          v1.DebugAttachment_RequestingAttachment,
-	}
-	if processName != "" {
-		da.ProcessName = processName
+		Intent:       &intent,
+		State:        v1.DebugAttachment_RequestingAttachment,
+		MatchRequest: true,
 	}
 	writeOpts := clients.WriteOpts{
 		Ctx:               uc.ctx,
@@ -40,7 +48,14 @@ func (uc *UserController) Attach(daName, namespace, image, podName, container, p
 	return uc.daClient.Write(&da, writeOpts)
 }
 
-// Remove sets the DebugAttachment state to PendingDelete
+// creationAnnotations records when a debug attachment was created, so that its age can be shown
+func creationAnnotations() map[string]string {
+	return map[string]string{
+		sqOpts.CreatedAtAnnotation: time.Now().UTC().Format(time.RFC3339),
+	}
+}
+
+// RequestDelete sets the DebugAttachment state to RequestingDelete
 func (uc *UserController) RequestDelete(namespace, name string) (*v1.DebugAttachment, error) {
 
 	da, err := uc.daClient.Read(namespace, name, clients.ReadOpts{Ctx: uc.ctx})
@@ -5,6 +5,7 @@ import (
 
 	v1 "github.com/solo-io/squash/pkg/api/v1"
 	"github.com/solo-io/squash/pkg/utils"
+	"github.com/solo-io/squash/pkg/utils/kubeutils"
 )
 
 type UserController struct {
@@ -12,9 +13,10 @@ type UserController struct {
 	daClient v1.DebugAttachmentClient
 }
 
-func NewUserController() (UserController, error) {
+// NewUserController manages the debug attachments of the cluster that kc selects
+func NewUserController(kc kubeutils.KubeConfig) (UserController, error) {
 	ctx := context.Background()
-	daClient, err := utils.GetBasicDebugAttachmentClient(ctx)
+	daClient, err := utils.GetBasicDebugAttachmentClientFor(ctx, kc)
 	if err != nil {
 		return UserController{}, err
 	}
@@ -69,22 +69,36 @@ func (DebugAttachment_State) EnumDescriptor() ([]byte, []int) {
 //
 //Attachments store the information needed for squash to coordinate a debugging session
 type DebugAttachment struct {
-	Metadata             core.Metadata         `protobuf:"bytes,1,opt,name=metadata,proto3" json:"metadata"`
-	Status               core.Status           `protobuf:"bytes,2,opt,name=status,proto3" json:"status" testdiff:"ignore"`
-	PlankName            string                `protobuf:"bytes,3,opt,name=plank_name,json=plankName,proto3" json:"plank_name,omitempty"`
-	Debugger             string                `protobuf:"bytes,4,opt,name=debugger,proto3" json:"debugger,omitempty"`
-	Image                string                `protobuf:"bytes,5,opt,name=image,proto3" json:"image,omitempty"`
-	ProcessName          string                `protobuf:"bytes,6,opt,name=process_name,json=processName,proto3" json:"process_name,omitempty"`
-	Node                 string                `protobuf:"bytes,7,opt,name=node,proto3" json:"node,omitempty"`
-	MatchRequest         bool                  `protobuf:"varint,8,opt,name=match_request,json=matchRequest,proto3" json:"match_request,omitempty"`
-	DebugServerAddress   string                `protobuf:"bytes,9,opt,name=debug_server_address,json=debugServerAddress,proto3" json:"debug_server_address,omitempty"`
-	Pod                  string                `protobuf:"bytes,11,opt,name=pod,proto3" json:"pod,omitempty"`
-	Container            string                `protobuf:"bytes,12,opt,name=container,proto3" json:"container,omitempty"`
-	DebugNamespace       string                `protobuf:"bytes,13,opt,name=debug_namespace,json=debugNamespace,proto3" json:"debug_namespace,omitempty"`
-	State                DebugAttachment_State `protobuf:"varint,20,opt,name=state,proto3,enum=squash.solo.io.DebugAttachment_State" json:"state,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}              `json:"-"`
-	XXX_unrecognized     []byte                `json:"-"`
-	XXX_sizecache        int32                 `json:"-"`
+	Metadata    core.Metadata `protobuf:"bytes,1,opt,name=metadata,proto3" json:"metadata"`
+	Status      core.Status   `protobuf:"bytes,2,opt,name=status,proto3" json:"status" testdiff:"ignore"`
+	PlankName   string        `protobuf:"bytes,3,opt,name=plank_name,json=plankName,proto3" json:"plank_name,omitempty"`
+	Debugger    string        `protobuf:"bytes,4,opt,name=debugger,proto3" json:"debugger,omitempty"`
+	Image       string        `protobuf:"bytes,5,opt,name=image,proto3" json:"image,omitempty"`
+	ProcessName string        `protobuf:"bytes,6,opt,name=process_name,json=processName,proto3" json:"process_name,omitempty"`
+	Node        string        `protobuf:"bytes,7,opt,name=node,proto3" json:"node,omitempty"`
+	// when set, this attachment does not debug a pod itself: squash debugs every pod that matches intent.pod_selector
+	// and starts after this attachment was created, each in a debug attachment of its own
+	MatchRequest       bool                  `protobuf:"varint,8,opt,name=match_request,json=matchRequest,proto3" json:"match_request,omitempty"`
+	DebugServerAddress string                `protobuf:"bytes,9,opt,name=debug_server_address,json=debugServerAddress,proto3" json:"debug_server_address,omitempty"`
+	Pod                string                `protobuf:"bytes,11,opt,name=pod,proto3" json:"pod,omitempty"`
+	Container          string                `protobuf:"bytes,12,opt,name=container,proto3" json:"container,omitempty"`
+	DebugNamespace     string                `protobuf:"bytes,13,opt,name=debug_namespace,json=debugNamespace,proto3" json:"debug_namespace,omitempty"`
+	State              DebugAttachment_State `protobuf:"varint,20,opt,name=state,proto3,enum=squash.solo.io.DebugAttachment_State" json:"state,omitempty"`
+	// describes what the user wants to debug
+	Intent *Intent `protobuf:"bytes,21,opt,name=intent,proto3" json:"intent,omitempty"`
+	// describes the plank pod that serves this debug session
+	Plank *Plank `protobuf:"bytes,22,opt,name=plank,proto3" json:"plank,omitempty"`
+	// describes where the debugger can be reached
+	PortSpec *PortSpec `protobuf:"bytes,23,opt,name=port_spec,json=portSpec,proto3" json:"port_spec,omitempty"`
+	// every process attached in this debug session, the first one is also described by port_spec
+	AttachedProcesses []*AttachedProcess `protobuf:"bytes,24,rep,name=attached_processes,json=attachedProcesses,proto3" json:"attached_processes,omitempty"`
+	// records the debugger being re-attached after target containers restarted
+	Reattachments *Reattachments `protobuf:"bytes,25,opt,name=reattachments,proto3" json:"reattachments,omitempty"`
+	// container runtime that plank found on the target pod's node
+	ContainerRuntime     *ContainerRuntime `protobuf:"bytes,26,opt,name=container_runtime,json=containerRuntime,proto3" json:"container_runtime,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}          `json:"-"`
+	XXX_unrecognized     []byte            `json:"-"`
+	XXX_sizecache        int32             `json:"-"`
 }
 
 func (m *DebugAttachment) Reset()         { *m = DebugAttachment{} }
@@ -202,6 +216,48 @@ func (m *DebugAttachment) GetState() DebugAttachment_State {
 	return DebugAttachment_RequestingAttachment
 }
 
+func (m *DebugAttachment) GetIntent() *Intent {
+	if m != nil {
+		return m.Intent
+	}
+	return nil
+}
+
+func (m *DebugAttachment) GetPlank() *Plank {
+	if m != nil {
+		return m.Plank
+	}
+	return nil
+}
+
+func (m *DebugAttachment) GetPortSpec() *PortSpec {
+	if m != nil {
+		return m.PortSpec
+	}
+	return nil
+}
+
+func (m *DebugAttachment) GetAttachedProcesses() []*AttachedProcess {
+	if m != nil {
+		return m.AttachedProcesses
+	}
+	return nil
+}
+
+func (m *DebugAttachment) GetReattachments() *Reattachments {
+	if m != nil {
+		return m.Reattachments
+	}
+	return nil
+}
+
+func (m *DebugAttachment) GetContainerRuntime() *ContainerRuntime {
+	if m != nil {
+		return m.ContainerRuntime
+	}
+	return nil
+}
+
 // Describes the user's debug intentions
 type Intent struct {
 	// type of debugger to use
@@ -210,12 +266,26 @@ type Intent struct {
 	Pod *core.ResourceRef `protobuf:"bytes,2,opt,name=pod,proto3" json:"pod,omitempty"`
 	// name of container to debug
 	ContainerName string `protobuf:"bytes,3,opt,name=container_name,json=containerName,proto3" json:"container_name,omitempty"`
-	// NOT YET IMPLEMENTED
 	// if a container has multiple processes and you do not want to debug the first process, this string is used to select a specific process
-	ProcessMatcher       string   `protobuf:"bytes,4,opt,name=process_matcher,json=processMatcher,proto3" json:"process_matcher,omitempty"`
-	XXX_NoUnkeyedLiteral struct{} `json:"-"`
-	XXX_unrecognized     []byte   `json:"-"`
-	XXX_sizecache        int32    `json:"-"`
+	ProcessMatcher string `protobuf:"bytes,4,opt,name=process_matcher,json=processMatcher,proto3" json:"process_matcher,omitempty"`
+	// attach to every process selected by process_matcher, rather than only the first
+	MatchAllProcesses bool `protobuf:"varint,5,opt,name=match_all_processes,json=matchAllProcesses,proto3" json:"match_all_processes,omitempty"`
+	// other containers of the same pod to debug in this session
+	AdditionalContainerNames []string `protobuf:"bytes,6,rep,name=additional_container_names,json=additionalContainerNames,proto3" json:"additional_container_names,omitempty"`
+	// keep watching the target containers and attach to matching processes that start after the session began
+	FollowProcesses bool `protobuf:"varint,7,opt,name=follow_processes,json=followProcesses,proto3" json:"follow_processes,omitempty"`
+	// keep the session across restarts of the target containers, by re-attaching the debugger to the restarted processes
+	ReattachOnRestart bool `protobuf:"varint,8,opt,name=reattach_on_restart,json=reattachOnRestart,proto3" json:"reattach_on_restart,omitempty"`
+	// label selector for the pods to debug, used by debug attachments that set match_request, instead of pod.name
+	PodSelector string `protobuf:"bytes,9,opt,name=pod_selector,json=podSelector,proto3" json:"pod_selector,omitempty"`
+	// how plank is run: "pod" runs it in a privileged pod of its own on the target's node, "ephemeral" injects it into
+	// the target pod as an ephemeral container that shares the target container's process namespace. Defaults to "pod"
+	PlankBackend string `protobuf:"bytes,10,opt,name=plank_backend,json=plankBackend,proto3" json:"plank_backend,omitempty"`
+	// the workload that the pod to debug was resolved from, when it was selected by a workload, a service or a label selector
+	Workload             *Workload `protobuf:"bytes,11,opt,name=workload,proto3" json:"workload,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}  `json:"-"`
+	XXX_unrecognized     []byte    `json:"-"`
+	XXX_sizecache        int32     `json:"-"`
 }
 
 func (m *Intent) Reset()         { *m = Intent{} }
@@ -270,12 +340,63 @@ func (m *Intent) GetProcessMatcher() string {
 	return ""
 }
 
+func (m *Intent) GetMatchAllProcesses() bool {
+	if m != nil {
+		return m.MatchAllProcesses
+	}
+	return false
+}
+
+func (m *Intent) GetAdditionalContainerNames() []string {
+	if m != nil {
+		return m.AdditionalContainerNames
+	}
+	return nil
+}
+
+func (m *Intent) GetFollowProcesses() bool {
+	if m != nil {
+		return m.FollowProcesses
+	}
+	return false
+}
+
+func (m *Intent) GetReattachOnRestart() bool {
+	if m != nil {
+		return m.ReattachOnRestart
+	}
+	return false
+}
+
+func (m *Intent) GetPodSelector() string {
+	if m != nil {
+		return m.PodSelector
+	}
+	return ""
+}
+
+func (m *Intent) GetPlankBackend() string {
+	if m != nil {
+		return m.PlankBackend
+	}
+	return ""
+}
+
+func (m *Intent) GetWorkload() *Workload {
+	if m != nil {
+		return m.Workload
+	}
+	return nil
+}
+
 // Describes the pod squash spawns for managing a particular debug session
 type Plank struct {
 	// plank pod reference
 	Pod *core.ResourceRef `protobuf:"bytes,1,opt,name=pod,proto3" json:"pod,omitempty"`
 	// indicates when plank has completed the debugger-specify preparation
-	ReadyForConnect      bool     `protobuf:"varint,2,opt,name=ready_for_connect,json=readyForConnect,proto3" json:"ready_for_connect,omitempty"`
+	ReadyForConnect bool `protobuf:"varint,2,opt,name=ready_for_connect,json=readyForConnect,proto3" json:"ready_for_connect,omitempty"`
+	// set when plank runs as an ephemeral container of the target pod, rather than in a pod of its own. pod then refers to the target pod
+	EphemeralContainer   string   `protobuf:"bytes,3,opt,name=ephemeral_container,json=ephemeralContainer,proto3" json:"ephemeral_container,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -319,6 +440,13 @@ func (m *Plank) GetReadyForConnect() bool {
 	return false
 }
 
+func (m *Plank) GetEphemeralContainer() string {
+	if m != nil {
+		return m.EphemeralContainer
+	}
+	return ""
+}
+
 // Contains port information needed to connect or find a debugger
 type PortSpec struct {
 	// Types that are valid to be assigned to PortLocation:
@@ -398,12 +526,249 @@ func (*PortSpec) XXX_OneofWrappers() []interface{} {
 	}
 }
 
+// Describes a process that a debugger was attached to
+type AttachedProcess struct {
+	// container that runs the process
+	ContainerName string `protobuf:"bytes,1,opt,name=container_name,json=containerName,proto3" json:"container_name,omitempty"`
+	// process id, in the node's pid namespace
+	Pid int64 `protobuf:"varint,2,opt,name=pid,proto3" json:"pid,omitempty"`
+	// where this process's debugger can be reached
+	PortSpec *PortSpec `protobuf:"bytes,3,opt,name=port_spec,json=portSpec,proto3" json:"port_spec,omitempty"`
+	// websocket url of debuggers that are reached over a websocket, such as the node inspector.
+	// The host is the one that the debugger listens on, clients replace it with the forwarded address.
+	DebuggerUrl          string   `protobuf:"bytes,4,opt,name=debugger_url,json=debuggerUrl,proto3" json:"debugger_url,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *AttachedProcess) Reset()         { *m = AttachedProcess{} }
+func (m *AttachedProcess) String() string { return proto.CompactTextString(m) }
+func (*AttachedProcess) ProtoMessage()    {}
+func (*AttachedProcess) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1f76a2adbe78506d, []int{4}
+}
+func (m *AttachedProcess) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AttachedProcess.Unmarshal(m, b)
+}
+func (m *AttachedProcess) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AttachedProcess.Marshal(b, m, deterministic)
+}
+func (m *AttachedProcess) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AttachedProcess.Merge(m, src)
+}
+func (m *AttachedProcess) XXX_Size() int {
+	return xxx_messageInfo_AttachedProcess.Size(m)
+}
+func (m *AttachedProcess) XXX_DiscardUnknown() {
+	xxx_messageInfo_AttachedProcess.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AttachedProcess proto.InternalMessageInfo
+
+func (m *AttachedProcess) GetContainerName() string {
+	if m != nil {
+		return m.ContainerName
+	}
+	return ""
+}
+
+func (m *AttachedProcess) GetPid() int64 {
+	if m != nil {
+		return m.Pid
+	}
+	return 0
+}
+
+func (m *AttachedProcess) GetPortSpec() *PortSpec {
+	if m != nil {
+		return m.PortSpec
+	}
+	return nil
+}
+
+func (m *AttachedProcess) GetDebuggerUrl() string {
+	if m != nil {
+		return m.DebuggerUrl
+	}
+	return ""
+}
+
+// Describes how often a debug session was re-attached after its target containers restarted
+type Reattachments struct {
+	// number of times the debugger was re-attached
+	Count uint32 `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
+	// when the debugger was last re-attached, in RFC 3339 format
+	LastTime             string   `protobuf:"bytes,2,opt,name=last_time,json=lastTime,proto3" json:"last_time,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Reattachments) Reset()         { *m = Reattachments{} }
+func (m *Reattachments) String() string { return proto.CompactTextString(m) }
+func (*Reattachments) ProtoMessage()    {}
+func (*Reattachments) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1f76a2adbe78506d, []int{5}
+}
+func (m *Reattachments) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Reattachments.Unmarshal(m, b)
+}
+func (m *Reattachments) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Reattachments.Marshal(b, m, deterministic)
+}
+func (m *Reattachments) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Reattachments.Merge(m, src)
+}
+func (m *Reattachments) XXX_Size() int {
+	return xxx_messageInfo_Reattachments.Size(m)
+}
+func (m *Reattachments) XXX_DiscardUnknown() {
+	xxx_messageInfo_Reattachments.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Reattachments proto.InternalMessageInfo
+
+func (m *Reattachments) GetCount() uint32 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+func (m *Reattachments) GetLastTime() string {
+	if m != nil {
+		return m.LastTime
+	}
+	return ""
+}
+
+// Describes the container runtime that plank uses to find the target containers
+type ContainerRuntime struct {
+	// name of the runtime, as reported by its CRI socket, for example containerd, cri-o or docker
+	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// version of the runtime
+	Version string `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"`
+	// path of the CRI socket on the node
+	Socket               string   `protobuf:"bytes,3,opt,name=socket,proto3" json:"socket,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ContainerRuntime) Reset()         { *m = ContainerRuntime{} }
+func (m *ContainerRuntime) String() string { return proto.CompactTextString(m) }
+func (*ContainerRuntime) ProtoMessage()    {}
+func (*ContainerRuntime) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1f76a2adbe78506d, []int{6}
+}
+func (m *ContainerRuntime) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ContainerRuntime.Unmarshal(m, b)
+}
+func (m *ContainerRuntime) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ContainerRuntime.Marshal(b, m, deterministic)
+}
+func (m *ContainerRuntime) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ContainerRuntime.Merge(m, src)
+}
+func (m *ContainerRuntime) XXX_Size() int {
+	return xxx_messageInfo_ContainerRuntime.Size(m)
+}
+func (m *ContainerRuntime) XXX_DiscardUnknown() {
+	xxx_messageInfo_ContainerRuntime.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ContainerRuntime proto.InternalMessageInfo
+
+func (m *ContainerRuntime) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *ContainerRuntime) GetVersion() string {
+	if m != nil {
+		return m.Version
+	}
+	return ""
+}
+
+func (m *ContainerRuntime) GetSocket() string {
+	if m != nil {
+		return m.Socket
+	}
+	return ""
+}
+
+// Describes the workload that the pod to debug was chosen from
+type Workload struct {
+	// kind of the workload: Deployment, StatefulSet, Service, or Selector when the pod was chosen by a bare label selector
+	Kind string `protobuf:"bytes,1,opt,name=kind,proto3" json:"kind,omitempty"`
+	// name of the workload, empty for a bare label selector
+	Name string `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// label selector that the workload's pods were listed with
+	Selector             string   `protobuf:"bytes,3,opt,name=selector,proto3" json:"selector,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Workload) Reset()         { *m = Workload{} }
+func (m *Workload) String() string { return proto.CompactTextString(m) }
+func (*Workload) ProtoMessage()    {}
+func (*Workload) Descriptor() ([]byte, []int) {
+	return fileDescriptor_1f76a2adbe78506d, []int{7}
+}
+func (m *Workload) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Workload.Unmarshal(m, b)
+}
+func (m *Workload) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Workload.Marshal(b, m, deterministic)
+}
+func (m *Workload) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Workload.Merge(m, src)
+}
+func (m *Workload) XXX_Size() int {
+	return xxx_messageInfo_Workload.Size(m)
+}
+func (m *Workload) XXX_DiscardUnknown() {
+	xxx_messageInfo_Workload.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Workload proto.InternalMessageInfo
+
+func (m *Workload) GetKind() string {
+	if m != nil {
+		return m.Kind
+	}
+	return ""
+}
+
+func (m *Workload) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *Workload) GetSelector() string {
+	if m != nil {
+		return m.Selector
+	}
+	return ""
+}
+
 func init() {
 	proto.RegisterEnum("squash.solo.io.DebugAttachment_State", DebugAttachment_State_name, DebugAttachment_State_value)
 	proto.RegisterType((*DebugAttachment)(nil), "squash.solo.io.DebugAttachment")
 	proto.RegisterType((*Intent)(nil), "squash.solo.io.Intent")
 	proto.RegisterType((*Plank)(nil), "squash.solo.io.Plank")
 	proto.RegisterType((*PortSpec)(nil), "squash.solo.io.PortSpec")
+	proto.RegisterType((*AttachedProcess)(nil), "squash.solo.io.AttachedProcess")
+	proto.RegisterType((*Reattachments)(nil), "squash.solo.io.Reattachments")
+	proto.RegisterType((*ContainerRuntime)(nil), "squash.solo.io.ContainerRuntime")
+	proto.RegisterType((*Workload)(nil), "squash.solo.io.Workload")
 }
 
 func init() {
@@ -411,50 +776,77 @@ func init() {
 }
 
 var fileDescriptor_1f76a2adbe78506d = []byte{
-	// 685 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x54, 0xcd, 0x4e, 0xdb, 0x4a,
-	0x14, 0xc6, 0x90, 0xe4, 0x3a, 0x87, 0xfc, 0x8e, 0x72, 0xd1, 0x80, 0xee, 0x2d, 0xa9, 0x5b, 0x44,
-	0x44, 0x5b, 0xa7, 0xd0, 0x45, 0x2b, 0xba, 0x22, 0x45, 0xfd, 0x59, 0x80, 0x90, 0xd9, 0x75, 0x93,
-	0x4e, 0xec, 0x13, 0xc7, 0x22, 0xf1, 0x98, 0x99, 0x09, 0x52, 0xb7, 0x3c, 0x48, 0xa5, 0xee, 0xfa,
-	0x28, 0x7d, 0x0a, 0x16, 0x7d, 0x03, 0xfa, 0x04, 0x95, 0x67, 0x6c, 0x43, 0x50, 0x2b, 0xd1, 0x55,
-	0xe6, 0x7c, 0xdf, 0xf9, 0x4e, 0xce, 0x9c, 0xf3, 0x79, 0xe0, 0x65, 0x18, 0xa9, 0xc9, 0x7c, 0xe4,
-	0xfa, 0x7c, 0xd6, 0x97, 0x7c, 0xca, 0x9f, 0x45, 0xbc, 0x2f, 0xcf, 0xe7, 0x4c, 0x4e, 0xfa, 0x2c,
-	0x89, 0xfa, 0x17, 0xbb, 0xfd, 0x00, 0x47, 0xf3, 0x70, 0xc8, 0x94, 0x62, 0xfe, 0x64, 0x86, 0xb1,
-	0x72, 0x13, 0xc1, 0x15, 0x27, 0x0d, 0x93, 0xe5, 0xa6, 0x22, 0x37, 0xe2, 0x1b, 0x9d, 0x90, 0x87,
-	0x5c, 0x53, 0xfd, 0xf4, 0x64, 0xb2, 0x36, 0x76, 0x7f, 0x57, 0x3e, 0xfd, 0x3d, 0x8b, 0x54, 0xfe,
-	0x07, 0x33, 0x54, 0x2c, 0x60, 0x8a, 0x65, 0x92, 0xfe, 0x3d, 0x24, 0x52, 0x31, 0x35, 0x97, 0x99,
-	0xe0, 0xe9, 0x3d, 0x04, 0x02, 0xc7, 0x7f, 0xd1, 0x51, 0x1e, 0x1b, 0x89, 0xf3, 0xa5, 0x0c, 0xcd,
-	0xc3, 0x74, 0x0a, 0x07, 0xc5, 0x10, 0xc8, 0x2b, 0xb0, 0xf3, 0xbe, 0xa9, 0xd5, 0xb5, 0x7a, 0xab,
-	0x7b, 0x6b, 0xae, 0xcf, 0x05, 0xe6, 0xf3, 0x70, 0x8f, 0x32, 0x76, 0x50, 0xfa, 0x7e, 0xb5, 0xb9,
-	0xe4, 0x15, 0xd9, 0xe4, 0x1d, 0x54, 0x4c, 0xfb, 0x74, 0x59, 0xeb, 0x3a, 0x8b, 0xba, 0x53, 0xcd,
-	0x0d, 0xd6, 0x53, 0xd5, 0xcf, 0xab, 0xcd, 0xb6, 0x42, 0xa9, 0x82, 0x68, 0x3c, 0xde, 0x77, 0xa2,
-	0x30, 0xe6, 0x02, 0x1d, 0x2f, 0x93, 0x93, 0xff, 0x01, 0x92, 0x29, 0x8b, 0xcf, 0x86, 0x31, 0x9b,
-	0x21, 0x5d, 0xe9, 0x5a, 0xbd, 0xaa, 0x57, 0xd5, 0xc8, 0x31, 0x9b, 0x21, 0xd9, 0x00, 0x5b, 0xaf,
-	0x2e, 0x44, 0x41, 0x4b, 0x9a, 0x2c, 0x62, 0xd2, 0x81, 0x72, 0x34, 0x63, 0x21, 0xd2, 0xb2, 0x26,
-	0x4c, 0x40, 0x1e, 0x42, 0x2d, 0x11, 0xdc, 0x47, 0x29, 0x4d, 0xc9, 0x8a, 0x26, 0x57, 0x33, 0x4c,
-	0x17, 0x25, 0x50, 0x8a, 0x79, 0x80, 0xf4, 0x1f, 0x4d, 0xe9, 0x33, 0x79, 0x04, 0xf5, 0x19, 0x53,
-	0xfe, 0x64, 0x28, 0xf0, 0x7c, 0x8e, 0x52, 0x51, 0xbb, 0x6b, 0xf5, 0x6c, 0xaf, 0xa6, 0x41, 0xcf,
-	0x60, 0xe4, 0x39, 0x74, 0x8c, 0x91, 0x24, 0x8a, 0x0b, 0x14, 0x43, 0x16, 0x04, 0x02, 0xa5, 0xa4,
-	0x55, 0x5d, 0x88, 0x68, 0xee, 0x54, 0x53, 0x07, 0x86, 0x21, 0x2d, 0x58, 0x49, 0x78, 0x40, 0x57,
-	0x75, 0x42, 0x7a, 0x24, 0xff, 0x41, 0xd5, 0xe7, 0xb1, 0x62, 0x51, 0x8c, 0x82, 0xd6, 0xcc, 0x7d,
-	0x0b, 0x80, 0x6c, 0x43, 0xd3, 0xfc, 0x43, 0xda, 0xbb, 0x4c, 0x98, 0x8f, 0xb4, 0xae, 0x73, 0x1a,
-	0x1a, 0x3e, 0xce, 0x51, 0xf2, 0x1a, 0xca, 0xe9, 0x04, 0x91, 0x76, 0xba, 0x56, 0xaf, 0xb1, 0xb7,
-	0xe5, 0x2e, 0x3a, 0xd9, 0xbd, 0xb3, 0x6a, 0xbd, 0x11, 0xf4, 0x8c, 0xc6, 0xe1, 0x50, 0xd6, 0x31,
-	0xa1, 0xd0, 0xc9, 0xee, 0x16, 0xc5, 0xb7, 0xb2, 0x5b, 0x4b, 0xe4, 0x5f, 0x68, 0x9f, 0x60, 0x1c,
-	0x2c, 0xc2, 0x16, 0xa9, 0x81, 0x6d, 0x62, 0x0c, 0x5a, 0xcb, 0xa4, 0x03, 0xad, 0x1b, 0xf9, 0x21,
-	0x4e, 0x51, 0x61, 0x6b, 0x85, 0xb4, 0xa1, 0x9e, 0x49, 0x33, 0xa8, 0xb4, 0xef, 0x5c, 0x5e, 0x97,
-	0x6c, 0xa8, 0x04, 0x38, 0x62, 0x4a, 0x5d, 0x5e, 0x97, 0x08, 0x69, 0xe9, 0xfb, 0xdc, 0x7c, 0x90,
-	0xd2, 0xf9, 0x6a, 0x41, 0xe5, 0x43, 0xac, 0x52, 0x5f, 0xde, 0xde, 0xba, 0x75, 0x67, 0xeb, 0x4f,
-	0xcc, 0x44, 0x8d, 0xed, 0xd6, 0x17, 0x6d, 0xe7, 0xa1, 0xe4, 0x73, 0xe1, 0xa3, 0x87, 0x63, 0x33,
-	0xec, 0x2d, 0x68, 0x14, 0xb3, 0xbd, 0xed, 0xb0, 0x7a, 0x81, 0x6a, 0x43, 0x6c, 0x43, 0x33, 0xf7,
-	0x8c, 0xde, 0x77, 0x61, 0xb6, 0x46, 0x06, 0x1f, 0x19, 0xd4, 0xf9, 0x04, 0xe5, 0x93, 0xd4, 0x9b,
-	0x79, 0x17, 0xd6, 0xbd, 0xba, 0xd8, 0x81, 0xb6, 0x40, 0x16, 0x7c, 0x1e, 0x8e, 0xb9, 0x18, 0xfa,
-	0x3c, 0x8e, 0xd1, 0x57, 0xfa, 0x02, 0xb6, 0xd7, 0xd4, 0xc4, 0x5b, 0x2e, 0xde, 0x18, 0xd8, 0x39,
-	0x02, 0xfb, 0x84, 0x0b, 0x75, 0x9a, 0xa0, 0x4f, 0xd6, 0xa0, 0xac, 0xbf, 0x04, 0x33, 0x83, 0xf7,
-	0x4b, 0x9e, 0x09, 0x09, 0x85, 0x8a, 0x62, 0x22, 0x44, 0x53, 0x24, 0x25, 0xb2, 0x78, 0xd0, 0x84,
-	0x7a, 0xc2, 0x85, 0x1a, 0x4e, 0xb9, 0xcf, 0x54, 0xc4, 0xe3, 0xc1, 0xce, 0xb7, 0x1f, 0x0f, 0xac,
-	0x8f, 0x8f, 0xff, 0xfc, 0x3e, 0x26, 0x67, 0x61, 0xf6, 0x60, 0x8c, 0x2a, 0xfa, 0xa1, 0x78, 0xf1,
-	0x2b, 0x00, 0x00, 0xff, 0xff, 0xbb, 0x4a, 0x1e, 0xa4, 0x4e, 0x05, 0x00, 0x00,
+	// 1108 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x56, 0xcb, 0x72, 0x1b, 0x45,
+	0x14, 0xcd, 0x58, 0x8f, 0x8c, 0xae, 0x24, 0x4b, 0xea, 0x28, 0xa6, 0x2d, 0x08, 0x56, 0x04, 0xa9,
+	0x88, 0x04, 0x24, 0x62, 0xa0, 0xa0, 0x02, 0x1b, 0xcb, 0x29, 0x1e, 0x0b, 0x1b, 0xd7, 0x18, 0x0a,
+	0x8a, 0xcd, 0x54, 0x7b, 0xe6, 0x4a, 0x9e, 0xd2, 0x68, 0x7a, 0xd2, 0xdd, 0x72, 0x8a, 0x6d, 0xfe,
+	0x80, 0x0d, 0x5f, 0xc0, 0x82, 0x4f, 0xe1, 0x2b, 0xb2, 0x60, 0xc5, 0x36, 0x7c, 0x01, 0x35, 0xdd,
+	0x3d, 0xa3, 0x07, 0xa6, 0xca, 0xac, 0xd4, 0x7d, 0xce, 0xbd, 0xb7, 0xbb, 0xef, 0xe3, 0x68, 0xe0,
+	0xd3, 0x59, 0xa4, 0x2e, 0x97, 0x17, 0xa3, 0x80, 0x2f, 0xc6, 0x92, 0xc7, 0xfc, 0x83, 0x88, 0x8f,
+	0xe5, 0xf3, 0x25, 0x93, 0x97, 0x63, 0x96, 0x46, 0xe3, 0xab, 0x27, 0xe3, 0x10, 0x2f, 0x96, 0x33,
+	0x9f, 0x29, 0xc5, 0x82, 0xcb, 0x05, 0x26, 0x6a, 0x94, 0x0a, 0xae, 0x38, 0xd9, 0x35, 0x56, 0xa3,
+	0xcc, 0x69, 0x14, 0xf1, 0x5e, 0x77, 0xc6, 0x67, 0x5c, 0x53, 0xe3, 0x6c, 0x65, 0xac, 0x7a, 0x4f,
+	0xae, 0x0b, 0x9f, 0xfd, 0xce, 0x23, 0x95, 0x1f, 0xb0, 0x40, 0xc5, 0x42, 0xa6, 0x98, 0x75, 0x19,
+	0xdf, 0xc0, 0x45, 0x2a, 0xa6, 0x96, 0xd2, 0x3a, 0xbc, 0x7f, 0x03, 0x07, 0x81, 0xd3, 0xff, 0x71,
+	0xa3, 0x7c, 0x6f, 0x5c, 0x06, 0xbf, 0xba, 0xd0, 0x7a, 0x96, 0x65, 0xe1, 0xa8, 0x48, 0x02, 0xf9,
+	0x0c, 0xdc, 0xfc, 0xde, 0xd4, 0xe9, 0x3b, 0xc3, 0xfa, 0xe1, 0xde, 0x28, 0xe0, 0x02, 0xf3, 0x7c,
+	0x8c, 0x4e, 0x2c, 0x3b, 0x29, 0xff, 0xf1, 0xea, 0xe0, 0x96, 0x57, 0x58, 0x93, 0xaf, 0xa0, 0x6a,
+	0xae, 0x4f, 0x77, 0xb4, 0x5f, 0x77, 0xd3, 0xef, 0x5c, 0x73, 0x93, 0xfd, 0xcc, 0xeb, 0xef, 0x57,
+	0x07, 0x1d, 0x85, 0x52, 0x85, 0xd1, 0x74, 0xfa, 0x74, 0x10, 0xcd, 0x12, 0x2e, 0x70, 0xe0, 0x59,
+	0x77, 0x72, 0x0f, 0x20, 0x8d, 0x59, 0x32, 0xf7, 0x13, 0xb6, 0x40, 0x5a, 0xea, 0x3b, 0xc3, 0x9a,
+	0x57, 0xd3, 0xc8, 0x29, 0x5b, 0x20, 0xe9, 0x81, 0xab, 0x4b, 0x37, 0x43, 0x41, 0xcb, 0x9a, 0x2c,
+	0xf6, 0xa4, 0x0b, 0x95, 0x68, 0xc1, 0x66, 0x48, 0x2b, 0x9a, 0x30, 0x1b, 0x72, 0x1f, 0x1a, 0xa9,
+	0xe0, 0x01, 0x4a, 0x69, 0x42, 0x56, 0x35, 0x59, 0xb7, 0x98, 0x0e, 0x4a, 0xa0, 0x9c, 0xf0, 0x10,
+	0xe9, 0x6d, 0x4d, 0xe9, 0x35, 0x79, 0x07, 0x9a, 0x0b, 0xa6, 0x82, 0x4b, 0x5f, 0xe0, 0xf3, 0x25,
+	0x4a, 0x45, 0xdd, 0xbe, 0x33, 0x74, 0xbd, 0x86, 0x06, 0x3d, 0x83, 0x91, 0x0f, 0xa1, 0x6b, 0x1a,
+	0x49, 0xa2, 0xb8, 0x42, 0xe1, 0xb3, 0x30, 0x14, 0x28, 0x25, 0xad, 0xe9, 0x40, 0x44, 0x73, 0xe7,
+	0x9a, 0x3a, 0x32, 0x0c, 0x69, 0x43, 0x29, 0xe5, 0x21, 0xad, 0x6b, 0x83, 0x6c, 0x49, 0xde, 0x82,
+	0x5a, 0xc0, 0x13, 0xc5, 0xa2, 0x04, 0x05, 0x6d, 0x98, 0xf7, 0x16, 0x00, 0x79, 0x08, 0x2d, 0x73,
+	0x42, 0x76, 0x77, 0x99, 0xb2, 0x00, 0x69, 0x53, 0xdb, 0xec, 0x6a, 0xf8, 0x34, 0x47, 0xc9, 0xe7,
+	0x50, 0xc9, 0x32, 0x88, 0xb4, 0xdb, 0x77, 0x86, 0xbb, 0x87, 0x0f, 0x46, 0x9b, 0x9d, 0x3c, 0xda,
+	0x2a, 0xb5, 0xae, 0x08, 0x7a, 0xc6, 0x87, 0x8c, 0xa0, 0x1a, 0x25, 0x0a, 0x13, 0x45, 0xef, 0xda,
+	0xaa, 0x6f, 0x79, 0x7f, 0xa3, 0x59, 0xcf, 0x5a, 0x91, 0xc7, 0x50, 0xd1, 0x25, 0xa1, 0x7b, 0xda,
+	0xfc, 0xee, 0xb6, 0xf9, 0x59, 0x46, 0x7a, 0xc6, 0x86, 0x7c, 0x02, 0xb5, 0x94, 0x0b, 0xe5, 0xcb,
+	0x14, 0x03, 0xfa, 0x86, 0x76, 0xa0, 0xff, 0x72, 0xe0, 0x42, 0x9d, 0xa7, 0x18, 0x78, 0x6e, 0x6a,
+	0x57, 0xe4, 0x14, 0x88, 0x19, 0x4f, 0x0c, 0x7d, 0x5b, 0x2c, 0x94, 0x94, 0xf6, 0x4b, 0xc3, 0xfa,
+	0xe1, 0xc1, 0xb6, 0xff, 0x91, 0xb5, 0x3c, 0x33, 0x86, 0x5e, 0x87, 0x6d, 0x02, 0x28, 0xc9, 0x31,
+	0x34, 0x05, 0xae, 0x06, 0x5e, 0xd2, 0x7d, 0x7d, 0x95, 0x7b, 0xdb, 0xa1, 0xbc, 0x75, 0x23, 0x6f,
+	0xd3, 0x87, 0x9c, 0x40, 0xa7, 0xa8, 0x8d, 0x2f, 0x96, 0x89, 0x8a, 0x16, 0x48, 0x7b, 0x3a, 0x50,
+	0x7f, 0x3b, 0xd0, 0x71, 0x6e, 0xe8, 0x19, 0x3b, 0xaf, 0x1d, 0x6c, 0x21, 0x03, 0x0e, 0x15, 0x5d,
+	0x07, 0x42, 0xa1, 0x6b, 0x7b, 0x2a, 0x4a, 0xd6, 0xaa, 0xd4, 0xbe, 0x45, 0xee, 0x42, 0xe7, 0x0c,
+	0x93, 0x70, 0x13, 0x76, 0x48, 0x03, 0xdc, 0xfc, 0xcd, 0xed, 0x1d, 0xd2, 0x85, 0xf6, 0xca, 0xfd,
+	0x19, 0xc6, 0xa8, 0xb0, 0x5d, 0x22, 0x1d, 0x68, 0x5a, 0x57, 0x0b, 0x95, 0x9f, 0x0e, 0x5e, 0xbe,
+	0x2e, 0xbb, 0x50, 0x0d, 0xf1, 0x82, 0x29, 0xf5, 0xf2, 0x75, 0x99, 0x90, 0xb6, 0xee, 0xa3, 0xb5,
+	0x37, 0x0e, 0xfe, 0x2a, 0x41, 0xd5, 0xd4, 0x7b, 0x63, 0xda, 0x9c, 0xad, 0x69, 0x7b, 0x6c, 0x3a,
+	0xd9, 0x8c, 0xfb, 0xfe, 0xe6, 0xb8, 0x7b, 0x28, 0xf9, 0x52, 0x04, 0xe8, 0xe1, 0xd4, 0x34, 0xf9,
+	0x03, 0xd8, 0x5d, 0xe5, 0x6d, 0x6d, 0xb2, 0x9b, 0x05, 0xaa, 0x07, 0xf1, 0x21, 0xb4, 0xf2, 0x59,
+	0xd5, 0x73, 0x56, 0x0c, 0xf9, 0xae, 0x85, 0x4f, 0x0c, 0x4a, 0x46, 0x70, 0xc7, 0x4c, 0x27, 0x8b,
+	0xe3, 0xb5, 0xee, 0xa8, 0xe8, 0x19, 0xed, 0x68, 0xea, 0x28, 0x8e, 0x57, 0xc5, 0xff, 0x02, 0x7a,
+	0x2c, 0x0c, 0x23, 0x15, 0xf1, 0x84, 0xc5, 0xfe, 0xe6, 0x55, 0x24, 0xad, 0xf6, 0x4b, 0xc3, 0x9a,
+	0x47, 0x57, 0x16, 0xc7, 0xeb, 0xb7, 0x92, 0xe4, 0x3d, 0x68, 0x4f, 0x79, 0x1c, 0xf3, 0x17, 0x6b,
+	0x47, 0xdd, 0xd6, 0x47, 0xb5, 0x0c, 0xbe, 0x3a, 0x68, 0x04, 0x77, 0xf2, 0x8e, 0xf1, 0x79, 0xe2,
+	0x0b, 0x94, 0x8a, 0x89, 0x5c, 0x3c, 0x3a, 0x39, 0xf5, 0x6d, 0xe2, 0x19, 0x42, 0xab, 0x13, 0x0f,
+	0x7d, 0x89, 0x31, 0x06, 0x8a, 0x0b, 0xab, 0x1c, 0xf5, 0x94, 0x87, 0xe7, 0x16, 0xca, 0x94, 0xc8,
+	0x28, 0xe2, 0x05, 0x0b, 0xe6, 0x98, 0x84, 0x14, 0xb4, 0x4d, 0x43, 0x83, 0x13, 0x83, 0x91, 0x8f,
+	0xc1, 0x7d, 0xc1, 0xc5, 0x3c, 0xe6, 0xcc, 0x88, 0xcb, 0x35, 0x33, 0xf6, 0x83, 0xe5, 0xbd, 0xc2,
+	0x72, 0xf0, 0x8b, 0x03, 0x15, 0x3d, 0xab, 0x79, 0x35, 0x9d, 0x1b, 0x55, 0xf3, 0x11, 0x64, 0x2f,
+	0x09, 0x7f, 0xf6, 0xa7, 0x5c, 0x64, 0xc9, 0x4c, 0x30, 0x50, 0xba, 0x11, 0x5c, 0xaf, 0xa5, 0x89,
+	0x2f, 0xb9, 0x38, 0x36, 0x30, 0x19, 0xc3, 0x1d, 0x4c, 0x2f, 0x71, 0x81, 0x62, 0x3d, 0xf1, 0xb6,
+	0xfc, 0xa4, 0xa0, 0x8a, 0x8c, 0x0f, 0x4e, 0xc0, 0xcd, 0xd5, 0x80, 0xec, 0xe5, 0x3a, 0xa3, 0x9b,
+	0xef, 0xeb, 0x5b, 0xb9, 0xa4, 0x50, 0xa8, 0x2a, 0x26, 0x66, 0x68, 0x4e, 0xcd, 0x08, 0xbb, 0x9f,
+	0xb4, 0xa0, 0xa9, 0xc5, 0x26, 0xe6, 0x01, 0xcb, 0x6a, 0x39, 0xf8, 0xcd, 0x81, 0xd6, 0x96, 0x3a,
+	0x5c, 0xd3, 0x8d, 0xce, 0x75, 0xdd, 0x98, 0x69, 0x75, 0x64, 0x3a, 0xbc, 0xe4, 0x65, 0xcb, 0x4d,
+	0x29, 0x2b, 0xdd, 0x58, 0xca, 0xee, 0x43, 0x23, 0x1f, 0x1b, 0x7f, 0x29, 0x62, 0xdb, 0xd3, 0xf5,
+	0x1c, 0xfb, 0x5e, 0xc4, 0x83, 0x09, 0x34, 0x37, 0x84, 0x27, 0xfb, 0x33, 0x0b, 0xf8, 0x32, 0x51,
+	0xfa, 0x6a, 0x4d, 0xcf, 0x6c, 0xc8, 0x9b, 0x50, 0x8b, 0x99, 0x54, 0xbe, 0xd6, 0x9d, 0x1d, 0x33,
+	0x91, 0x19, 0xf0, 0x5d, 0xa6, 0x26, 0x3f, 0x42, 0x7b, 0x5b, 0x73, 0xf4, 0x5f, 0xdb, 0xea, 0x81,
+	0x7a, 0x4d, 0x28, 0xdc, 0xbe, 0x42, 0x21, 0x23, 0x9e, 0xd8, 0x10, 0xf9, 0x96, 0xec, 0x41, 0x55,
+	0xf2, 0x60, 0x8e, 0xca, 0xd6, 0xc7, 0xee, 0x06, 0xa7, 0xe0, 0xe6, 0xdd, 0x93, 0x45, 0x9c, 0x47,
+	0x49, 0x98, 0x47, 0xcc, 0xd6, 0xc5, 0x29, 0x3b, 0x6b, 0xa7, 0xf4, 0xc0, 0x2d, 0xba, 0xda, 0x44,
+	0x2b, 0xf6, 0x93, 0x47, 0x3f, 0xbd, 0xfb, 0xdf, 0x5f, 0x68, 0xe9, 0x7c, 0x66, 0x3f, 0x59, 0x7e,
+	0xff, 0xf3, 0x6d, 0xe7, 0xa2, 0xaa, 0x3f, 0x57, 0x3e, 0xfa, 0x67, 0x00, 0x97, 0x96, 0x62, 0xa4,
+	0xd4, 0x09, 0x00, 0x00,
 }
 
 func (this *DebugAttachment) Equal(that interface{}) bool {
@@ -515,6 +907,29 @@ func (this *DebugAttachment) Equal(that interface{}) bool {
 	if this.State != that1.State {
 		return false
 	}
+	if !this.Intent.Equal(that1.Intent) {
+		return false
+	}
+	if !this.Plank.Equal(that1.Plank) {
+		return false
+	}
+	if !this.PortSpec.Equal(that1.PortSpec) {
+		return false
+	}
+	if len(this.AttachedProcesses) != len(that1.AttachedProcesses) {
+		return false
+	}
+	for i := range this.AttachedProcesses {
+		if !this.AttachedProcesses[i].Equal(that1.AttachedProcesses[i]) {
+			return false
+		}
+	}
+	if !this.Reattachments.Equal(that1.Reattachments) {
+		return false
+	}
+	if !this.ContainerRuntime.Equal(that1.ContainerRuntime) {
+		return false
+	}
 	if !bytes.Equal(this.XXX_unrecognized, that1.XXX_unrecognized) {
 		return false
 	}
@@ -551,6 +966,32 @@ func (this *Intent) Equal(that interface{}) bool {
 	if this.ProcessMatcher != that1.ProcessMatcher {
 		return false
 	}
+	if this.MatchAllProcesses != that1.MatchAllProcesses {
+		return false
+	}
+	if len(this.AdditionalContainerNames) != len(that1.AdditionalContainerNames) {
+		return false
+	}
+	for i := range this.AdditionalContainerNames {
+		if this.AdditionalContainerNames[i] != that1.AdditionalContainerNames[i] {
+			return false
+		}
+	}
+	if this.FollowProcesses != that1.FollowProcesses {
+		return false
+	}
+	if this.ReattachOnRestart != that1.ReattachOnRestart {
+		return false
+	}
+	if this.PodSelector != that1.PodSelector {
+		return false
+	}
+	if this.PlankBackend != that1.PlankBackend {
+		return false
+	}
+	if !this.Workload.Equal(that1.Workload) {
+		return false
+	}
 	if !bytes.Equal(this.XXX_unrecognized, that1.XXX_unrecognized) {
 		return false
 	}
@@ -581,6 +1022,9 @@ func (this *Plank) Equal(that interface{}) bool {
 	if this.ReadyForConnect != that1.ReadyForConnect {
 		return false
 	}
+	if this.EphemeralContainer != that1.EphemeralContainer {
+		return false
+	}
 	if !bytes.Equal(this.XXX_unrecognized, that1.XXX_unrecognized) {
 		return false
 	}
@@ -667,3 +1111,135 @@ func (this *PortSpec_Target) Equal(that interface{}) bool {
 	}
 	return true
 }
+func (this *AttachedProcess) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*AttachedProcess)
+	if !ok {
+		that2, ok := that.(AttachedProcess)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.ContainerName != that1.ContainerName {
+		return false
+	}
+	if this.Pid != that1.Pid {
+		return false
+	}
+	if !this.PortSpec.Equal(that1.PortSpec) {
+		return false
+	}
+	if this.DebuggerUrl != that1.DebuggerUrl {
+		return false
+	}
+	if !bytes.Equal(this.XXX_unrecognized, that1.XXX_unrecognized) {
+		return false
+	}
+	return true
+}
+func (this *Reattachments) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*Reattachments)
+	if !ok {
+		that2, ok := that.(Reattachments)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.Count != that1.Count {
+		return false
+	}
+	if this.LastTime != that1.LastTime {
+		return false
+	}
+	if !bytes.Equal(this.XXX_unrecognized, that1.XXX_unrecognized) {
+		return false
+	}
+	return true
+}
+func (this *ContainerRuntime) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*ContainerRuntime)
+	if !ok {
+		that2, ok := that.(ContainerRuntime)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.Name != that1.Name {
+		return false
+	}
+	if this.Version != that1.Version {
+		return false
+	}
+	if this.Socket != that1.Socket {
+		return false
+	}
+	if !bytes.Equal(this.XXX_unrecognized, that1.XXX_unrecognized) {
+		return false
+	}
+	return true
+}
+func (this *Workload) Equal(that interface{}) bool {
+	if that == nil {
+		return this == nil
+	}
+
+	that1, ok := that.(*Workload)
+	if !ok {
+		that2, ok := that.(Workload)
+		if ok {
+			that1 = &that2
+		} else {
+			return false
+		}
+	}
+	if that1 == nil {
+		return this == nil
+	} else if this == nil {
+		return false
+	}
+	if this.Kind != that1.Kind {
+		return false
+	}
+	if this.Name != that1.Name {
+		return false
+	}
+	if this.Selector != that1.Selector {
+		return false
+	}
+	if !bytes.Equal(this.XXX_unrecognized, that1.XXX_unrecognized) {
+		return false
+	}
+	return true
+}
@@ -48,6 +48,12 @@ func (r *DebugAttachment) Hash() uint64 {
 		r.Container,
 		r.DebugNamespace,
 		r.State,
+		r.Intent,
+		r.Plank,
+		r.PortSpec,
+		r.AttachedProcesses,
+		r.Reattachments,
+		r.ContainerRuntime,
 	)
 }
 
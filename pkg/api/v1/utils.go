@@ -6,8 +6,16 @@ import (
 	"strings"
 
 	"github.com/solo-io/solo-kit/pkg/api/v1/clients"
+	"github.com/solo-io/solo-kit/pkg/api/v1/resources/core"
 )
 
+// Debug attachments that were created with the deprecated flat fields do not record which pod their
+// debug port belongs to. These debuggers were always proxied through plank, all others were reached on the target pod.
+var legacyPlankProxiedDebuggers = map[string]bool{
+	"dlv": true,
+	"gdb": true,
+}
+
 // TODO(mitchdraft) - this should error check for length
 // TODO(mitchdraft) - this should include process id so that we can have multiple debuggers on the same container
 // TODO(mitchdraft) - generated identifier should be applied as a label - the point is to make the resource queriable
@@ -15,6 +23,7 @@ func GenDebugAttachmentName(pod, container string) string {
 	return fmt.Sprintf("%v-%v", pod, container)
 }
 
+// Deprecated: debug attachments store their port in the PortSpec. Use GetDebugPort instead.
 func (m *DebugAttachment) GetPortFromDebugServerAddress() (int, error) {
 	if m.DebugServerAddress == "" {
 		return 0, fmt.Errorf("No debug server address specified on debug attachment %v in namespace %v", m.Metadata.Name, m.Metadata.Namespace)
@@ -26,6 +35,130 @@ func (m *DebugAttachment) GetPortFromDebugServerAddress() (int, error) {
 	return strconv.Atoi(parts[1])
 }
 
+// GetDebugPort returns the port that the debugger listens on, regardless of whether it is on the plank or the target pod
+func (m *DebugAttachment) GetDebugPort() (int, error) {
+	return m.debugPortFor(m.PortSpec)
+}
+
+// GetDebugPod returns the pod that serves the debug port: the plank pod for debuggers that plank proxies,
+// otherwise the target pod
+func (m *DebugAttachment) GetDebugPod() (*core.ResourceRef, error) {
+	return m.debugPodFor(m.PortSpec)
+}
+
+// ListAttachedProcesses returns every process attached in this debug session.
+// Debug attachments written before multiple processes were supported only describe their port spec.
+func (m *DebugAttachment) ListAttachedProcesses() []*AttachedProcess {
+	if len(m.AttachedProcesses) > 0 {
+		return m.AttachedProcesses
+	}
+	if m.PortSpec == nil {
+		return nil
+	}
+	return []*AttachedProcess{{
+		ContainerName: m.GetIntent().GetContainerName(),
+		PortSpec:      m.PortSpec,
+	}}
+}
+
+// GetProcessDebugPort returns the port that the debugger of an attached process listens on
+func (m *DebugAttachment) GetProcessDebugPort(p *AttachedProcess) (int, error) {
+	return m.debugPortFor(p.GetPortSpec())
+}
+
+// GetProcessDebugPod returns the pod that serves the debug port of an attached process
+func (m *DebugAttachment) GetProcessDebugPod(p *AttachedProcess) (*core.ResourceRef, error) {
+	return m.debugPodFor(p.GetPortSpec())
+}
+
+func (m *DebugAttachment) debugPortFor(spec *PortSpec) (int, error) {
+	if spec == nil || spec.PortLocation == nil {
+		return 0, fmt.Errorf("No port spec specified on debug attachment %v in namespace %v", m.Metadata.Name, m.Metadata.Namespace)
+	}
+	portStr := spec.GetPlank()
+	if portStr == "" {
+		portStr = spec.GetTarget()
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid port (%v) specified on debug attachment %v in namespace %v", portStr, m.Metadata.Name, m.Metadata.Namespace)
+	}
+	return port, nil
+}
+
+func (m *DebugAttachment) debugPodFor(spec *PortSpec) (*core.ResourceRef, error) {
+	switch spec.GetPortLocation().(type) {
+	case *PortSpec_Plank:
+		if m.GetPlank().GetPod() == nil {
+			return nil, fmt.Errorf("No plank pod specified on debug attachment %v in namespace %v", m.Metadata.Name, m.Metadata.Namespace)
+		}
+		return m.Plank.Pod, nil
+	case *PortSpec_Target:
+		if m.GetIntent().GetPod() == nil {
+			return nil, fmt.Errorf("No target pod specified on debug attachment %v in namespace %v", m.Metadata.Name, m.Metadata.Namespace)
+		}
+		return m.Intent.Pod, nil
+	}
+	return nil, fmt.Errorf("No port spec specified on debug attachment %v in namespace %v", m.Metadata.Name, m.Metadata.Namespace)
+}
+
+// NewPlankPortSpec describes a debug port that is reached through the plank pod
+func NewPlankPortSpec(port int) *PortSpec {
+	return &PortSpec{PortLocation: &PortSpec_Plank{Plank: strconv.Itoa(port)}}
+}
+
+// NewTargetPortSpec describes a debug port that is reached directly on the target pod
+func NewTargetPortSpec(port int) *PortSpec {
+	return &PortSpec{PortLocation: &PortSpec_Target{Target: strconv.Itoa(port)}}
+}
+
+// ConvertDeprecatedFields populates the Intent, Plank, and PortSpec of debug attachments that were
+// written with the deprecated flat fields. Structured fields that are already set are left untouched.
+func (m *DebugAttachment) ConvertDeprecatedFields() {
+	if m.Intent == nil && m.Pod != "" {
+		namespace := m.DebugNamespace
+		if namespace == "" {
+			namespace = m.Metadata.Namespace
+		}
+		m.Intent = &Intent{
+			Debugger: m.Debugger,
+			Pod: &core.ResourceRef{
+				Name:      m.Pod,
+				Namespace: namespace,
+			},
+			ContainerName:  m.Container,
+			ProcessMatcher: m.ProcessName,
+		}
+	}
+	if m.Plank == nil && m.PlankName != "" {
+		m.Plank = &Plank{
+			Pod: &core.ResourceRef{
+				Name: m.PlankName,
+			},
+			ReadyForConnect: m.DebugServerAddress != "",
+		}
+	}
+	if m.PortSpec == nil && m.DebugServerAddress != "" {
+		port, err := m.GetPortFromDebugServerAddress()
+		if err != nil {
+			// leave the port spec empty, readers will report that no port is available
+			return
+		}
+		if legacyPlankProxiedDebuggers[m.Debugger] {
+			m.PortSpec = NewPlankPortSpec(port)
+		} else {
+			m.PortSpec = NewTargetPortSpec(port)
+		}
+	}
+}
+
+// ConvertDeprecatedFields applies DebugAttachment.ConvertDeprecatedFields to each debug attachment in the list
+func (list DebugAttachmentList) ConvertDeprecatedFields() {
+	for _, da := range list {
+		da.ConvertDeprecatedFields()
+	}
+}
+
 // For a given debug Intent, finds the corresponding DebugAttachment, errors if there is not exactly one match
 func (di *Intent) GetDebugAttachment(daClient DebugAttachmentClient) (*DebugAttachment, error) {
 	das, err := di.GetDebugAttachments(daClient)
@@ -47,6 +180,7 @@ func (di *Intent) GetDebugAttachments(daClient DebugAttachmentClient) (DebugAtta
 	if err != nil {
 		return DebugAttachmentList{}, err
 	}
+	das.ConvertDeprecatedFields()
 	return das, nil
 }
 
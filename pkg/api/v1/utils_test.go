@@ -0,0 +1,136 @@
+package v1
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/solo-io/solo-kit/pkg/api/v1/resources/core"
+)
+
+var _ = Describe("deprecated field conversion", func() {
+	It("should convert the flat fields of a legacy debug attachment", func() {
+		da := &DebugAttachment{
+			Metadata:           core.Metadata{Name: "legacy", Namespace: "ns1"},
+			Debugger:           "dlv",
+			Pod:                "target-pod",
+			Container:          "target-container",
+			ProcessName:        "worker",
+			DebugNamespace:     "ns1",
+			PlankName:          "plank-abc",
+			DebugServerAddress: "inferfrompod:4321",
+		}
+		da.ConvertDeprecatedFields()
+		Expect(da.Intent).To(Equal(&Intent{
+			Debugger:       "dlv",
+			Pod:            &core.ResourceRef{Name: "target-pod", Namespace: "ns1"},
+			ContainerName:  "target-container",
+			ProcessMatcher: "worker",
+		}))
+		Expect(da.Plank.Pod.Name).To(Equal("plank-abc"))
+		Expect(da.Plank.ReadyForConnect).To(BeTrue())
+		Expect(da.PortSpec.GetPlank()).To(Equal("4321"))
+		port, err := da.GetDebugPort()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(port).To(Equal(4321))
+	})
+
+	It("should place the port of target-hosted legacy debuggers on the target", func() {
+		da := &DebugAttachment{
+			Debugger:           "java",
+			Pod:                "target-pod",
+			DebugServerAddress: "inferfrompod:8000",
+		}
+		da.ConvertDeprecatedFields()
+		Expect(da.PortSpec.GetTarget()).To(Equal("8000"))
+	})
+
+	It("should not overwrite structured fields", func() {
+		intent := &Intent{Debugger: "gdb", Pod: &core.ResourceRef{Name: "new-pod", Namespace: "ns2"}}
+		da := &DebugAttachment{
+			Pod:      "old-pod",
+			Intent:   intent,
+			PortSpec: NewTargetPortSpec(1234),
+		}
+		da.ConvertDeprecatedFields()
+		Expect(da.Intent).To(BeIdenticalTo(intent))
+		Expect(da.Plank).To(BeNil())
+		Expect(da.PortSpec.GetTarget()).To(Equal("1234"))
+	})
+
+	It("should error when no port has been published", func() {
+		da := &DebugAttachment{}
+		_, err := da.GetDebugPort()
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("debug pod", func() {
+	target := &core.ResourceRef{Name: "target-pod", Namespace: "ns1"}
+	plank := &core.ResourceRef{Name: "plank-abc", Namespace: "squash-debugger"}
+
+	It("should connect through the plank when plank serves the port", func() {
+		da := &DebugAttachment{
+			Intent:   &Intent{Pod: target},
+			Plank:    &Plank{Pod: plank},
+			PortSpec: NewPlankPortSpec(1234),
+		}
+		pod, err := da.GetDebugPod()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pod).To(Equal(plank))
+	})
+
+	It("should connect to the target when the target serves the port", func() {
+		da := &DebugAttachment{
+			Intent:   &Intent{Pod: target},
+			Plank:    &Plank{Pod: plank},
+			PortSpec: NewTargetPortSpec(1234),
+		}
+		pod, err := da.GetDebugPod()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pod).To(Equal(target))
+	})
+
+	It("should error when no port has been published", func() {
+		da := &DebugAttachment{Intent: &Intent{Pod: target}}
+		_, err := da.GetDebugPod()
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+var _ = Describe("attached processes", func() {
+	It("should describe legacy single process attachments from the port spec", func() {
+		da := &DebugAttachment{
+			Intent:   &Intent{ContainerName: "app"},
+			PortSpec: NewPlankPortSpec(1234),
+		}
+		processes := da.ListAttachedProcesses()
+		Expect(processes).To(HaveLen(1))
+		Expect(processes[0].ContainerName).To(Equal("app"))
+		port, err := da.GetProcessDebugPort(processes[0])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(port).To(Equal(1234))
+	})
+
+	It("should resolve the debug pod of each attached process", func() {
+		target := &core.ResourceRef{Name: "target-pod", Namespace: "ns1"}
+		plank := &core.ResourceRef{Name: "plank-abc", Namespace: "squash-debugger"}
+		da := &DebugAttachment{
+			Intent: &Intent{Pod: target},
+			Plank:  &Plank{Pod: plank},
+			AttachedProcesses: []*AttachedProcess{
+				{ContainerName: "app", Pid: 10, PortSpec: NewPlankPortSpec(1234)},
+				{ContainerName: "sidecar", Pid: 20, PortSpec: NewTargetPortSpec(5005)},
+			},
+		}
+		processes := da.ListAttachedProcesses()
+		Expect(processes).To(HaveLen(2))
+		pod, err := da.GetProcessDebugPod(processes[0])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pod).To(Equal(plank))
+		pod, err = da.GetProcessDebugPod(processes[1])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pod).To(Equal(target))
+		port, err := da.GetProcessDebugPort(processes[1])
+		Expect(err).NotTo(HaveOccurred())
+		Expect(port).To(Equal(5005))
+	})
+})
@@ -0,0 +1,330 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	squashv1 "github.com/solo-io/squash/pkg/api/v1"
+	"github.com/solo-io/squash/pkg/debuggers/local"
+	sqOpts "github.com/solo-io/squash/pkg/options"
+	v1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+// kubernetes limits container names to a DNS label
+const maxContainerNameLength = 63
+
+// where containers mount their service account token, along with the cluster's CA
+const serviceAccountMountPath = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+// ephemeralContainer is the subset of the kubernetes EphemeralContainer that plank needs.
+// It is declared here so that the ephemeral backend does not depend on a newer client-go.
+type ephemeralContainer struct {
+	v1.Container
+	// the container whose process namespace plank shares
+	TargetContainerName string `json:"targetContainerName,omitempty"`
+}
+
+// ephemeralContainerStatuses is the part of a pod's status that describes its ephemeral containers
+type ephemeralContainerStatuses struct {
+	Status struct {
+		EphemeralContainerStatuses []v1.ContainerStatus `json:"ephemeralContainerStatuses,omitempty"`
+	} `json:"status"`
+}
+
+// startEphemeralPlank injects plank into the target pod as an ephemeral container, then waits for it to run.
+// Unlike a plank pod, it needs no host access: it sees the target's processes through their shared process namespace.
+func (s *Squash) startEphemeralPlank() error {
+	if len(s.AdditionalContainers) > 0 {
+		return fmt.Errorf("the %v plank backend only shares the process namespace of one container, it cannot debug additional containers", sqOpts.PlankBackendEphemeral)
+	}
+	cs, err := s.getClientSet()
+	if err != nil {
+		return err
+	}
+	targetPod, err := cs.CoreV1().Pods(s.Namespace).Get(s.Pod, meta_v1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	da, err := s.intendedDebugAttachment()
+	if err != nil {
+		return err
+	}
+	container, err := s.ephemeralPlankContainer(targetPod, da)
+	if err != nil {
+		return err
+	}
+	if err := grantEphemeralPlankAccess(cs, da); err != nil {
+		return err
+	}
+
+	patch, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{
+			"ephemeralContainers": []ephemeralContainer{container},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	err = cs.CoreV1().RESTClient().
+		Patch(types.StrategicMergePatchType).
+		Namespace(s.Namespace).
+		Resource("pods").
+		Name(s.Pod).
+		SubResource("ephemeralcontainers").
+		Body(patch).
+		Do().
+		Error()
+	if err != nil {
+		return ephemeralContainerError(err, container.Name, s.Pod)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.waitTimeout())
+	defer cancel()
+	if err := s.waitForEphemeralContainer(ctx, container.Name); err != nil {
+		return errors.Wrapf(err, "waiting for ephemeral container %v", container.Name)
+	}
+	return nil
+}
+
+// ephemeralPlankContainer describes plank as an ephemeral container of the target pod.
+// Ephemeral containers get no service account token of their own: plank reaches the cluster through the target
+// container's service account mount, and authenticates with the token of its own service account.
+func (s *Squash) ephemeralPlankContainer(targetPod *v1.Pod, da *squashv1.DebugAttachment) (ephemeralContainer, error) {
+	var serviceAccountMount *v1.VolumeMount
+	found := false
+	for _, c := range targetPod.Spec.Containers {
+		if c.Name != s.Container {
+			continue
+		}
+		found = true
+		for i, mount := range c.VolumeMounts {
+			if mount.MountPath == serviceAccountMountPath {
+				serviceAccountMount = &c.VolumeMounts[i]
+			}
+		}
+	}
+	if !found {
+		return ephemeralContainer{}, fmt.Errorf("pod %v has no container %v", targetPod.Name, s.Container)
+	}
+	if serviceAccountMount == nil {
+		return ephemeralContainer{}, fmt.Errorf("container %v does not mount a service account token, which the %v plank backend needs to reach the cluster. Use the %v plank backend instead",
+			s.Container, sqOpts.PlankBackendEphemeral, sqOpts.PlankBackendPod)
+	}
+
+	name := ephemeralPlankName(da)
+	env := append(s.plankEnv(da), v1.EnvVar{
+		Name:  sqOpts.PlankEnvEphemeralContainer,
+		Value: name,
+	}, v1.EnvVar{
+		Name: sqOpts.PlankEnvToken,
+		ValueFrom: &v1.EnvVarSource{
+			SecretKeyRef: &v1.SecretKeySelector{
+				LocalObjectReference: v1.LocalObjectReference{Name: ephemeralPlankAccessName(da)},
+				Key:                  v1.ServiceAccountTokenKey,
+			},
+		},
+	})
+	container := ephemeralContainer{
+		Container:           s.plankContainerFor(name, containerNameFromSpec(s.Debugger), env),
+		TargetContainerName: s.Container,
+	}
+	mount := *serviceAccountMount
+	mount.ReadOnly = true
+	container.VolumeMounts = []v1.VolumeMount{mount}
+	return container, nil
+}
+
+// ephemeralContainerError explains the errors that the cluster's admission is known to return for ephemeral planks
+func ephemeralContainerError(err error, name, pod string) error {
+	if kerrors.IsForbidden(err) && strings.Contains(err.Error(), "PodSecurity") {
+		return errors.Wrapf(err, "the namespace of pod %v enforces a Pod Security Standard that rejects the capabilities plank needs to debug (SYS_PTRACE, and SYS_ADMIN for nodejs). "+
+			"Label the namespace with pod-security.kubernetes.io/enforce=privileged, or use the %v plank backend", pod, sqOpts.PlankBackendPod)
+	}
+	return errors.Wrapf(err, "adding ephemeral container %v to pod %v", name, pod)
+}
+
+// ephemeralPlankAccessName names the service account, token secret, role and role binding of the ephemeral plank
+// of a debug attachment
+func ephemeralPlankAccessName(da *squashv1.DebugAttachment) string {
+	return fmt.Sprintf("%v-%v", sqOpts.EphemeralPlankRoleName, da.Metadata.Name)
+}
+
+// ephemeralPlankAccess returns what lets an ephemeral plank manage its own debug attachment, and nothing else:
+// a service account with a token secret, and a role that is restricted to the one debug attachment.
+// The target pod's service account is left as it is.
+func ephemeralPlankAccess(da *squashv1.DebugAttachment) (*v1.ServiceAccount, *v1.Secret, *rbacv1.Role, *rbacv1.RoleBinding) {
+	name := ephemeralPlankAccessName(da)
+	// the plank runs in the target pod, so its service account and token live in the target's namespace
+	podNamespace := da.Intent.GetPod().GetNamespace()
+	serviceAccount := &v1.ServiceAccount{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      name,
+			Namespace: podNamespace,
+		},
+	}
+	secret := &v1.Secret{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      name,
+			Namespace: podNamespace,
+			Annotations: map[string]string{
+				v1.ServiceAccountNameKey: name,
+			},
+		},
+		Type: v1.SecretTypeServiceAccountToken,
+	}
+	role := &rbacv1.Role{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      name,
+			Namespace: da.Metadata.Namespace,
+		},
+		Rules: []rbacv1.PolicyRule{{
+			Verbs:         []string{"get", "update"},
+			Resources:     []string{"debugattachments"},
+			APIGroups:     []string{"squash.solo.io"},
+			ResourceNames: []string{da.Metadata.Name},
+		}},
+	}
+	binding := &rbacv1.RoleBinding{
+		ObjectMeta: meta_v1.ObjectMeta{
+			Name:      name,
+			Namespace: da.Metadata.Namespace,
+		},
+		Subjects: []rbacv1.Subject{{
+			Kind:      rbacv1.ServiceAccountKind,
+			Name:      name,
+			Namespace: podNamespace,
+		}},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Name:     name,
+			Kind:     "Role",
+		},
+	}
+	return serviceAccount, secret, role, binding
+}
+
+// grantEphemeralPlankAccess creates the service account that the ephemeral plank of a debug attachment authenticates as
+func grantEphemeralPlankAccess(cs kubernetes.Interface, da *squashv1.DebugAttachment) error {
+	serviceAccount, secret, role, binding := ephemeralPlankAccess(da)
+	if _, err := cs.CoreV1().ServiceAccounts(serviceAccount.Namespace).Create(serviceAccount); err != nil && !kerrors.IsAlreadyExists(err) {
+		return errors.Wrapf(err, "creating service account %v", serviceAccount.Name)
+	}
+	if _, err := cs.CoreV1().Secrets(secret.Namespace).Create(secret); err != nil && !kerrors.IsAlreadyExists(err) {
+		return errors.Wrapf(err, "creating token secret %v", secret.Name)
+	}
+	if _, err := cs.RbacV1().Roles(role.Namespace).Create(role); err != nil && !kerrors.IsAlreadyExists(err) {
+		return errors.Wrapf(err, "creating role %v", role.Name)
+	}
+	if _, err := cs.RbacV1().RoleBindings(binding.Namespace).Create(binding); err != nil && !kerrors.IsAlreadyExists(err) {
+		return errors.Wrapf(err, "creating role binding %v", binding.Name)
+	}
+	return nil
+}
+
+// RevokeEphemeralPlankAccess deletes the service account of the ephemeral plank of a debug attachment, along with
+// its token and role. It is called once the debug attachment is deleted, and does nothing for other plank backends.
+func RevokeEphemeralPlankAccess(cs kubernetes.Interface, da *squashv1.DebugAttachment) error {
+	if da.Intent.GetPlankBackend() != sqOpts.PlankBackendEphemeral {
+		return nil
+	}
+	serviceAccount, secret, role, binding := ephemeralPlankAccess(da)
+	var errs []string
+	collect := func(kind, name string, err error) {
+		if err != nil && !kerrors.IsNotFound(err) {
+			errs = append(errs, fmt.Sprintf("deleting %v %v: %v", kind, name, err))
+		}
+	}
+	collect("role binding", binding.Name, cs.RbacV1().RoleBindings(binding.Namespace).Delete(binding.Name, &meta_v1.DeleteOptions{}))
+	collect("role", role.Name, cs.RbacV1().Roles(role.Namespace).Delete(role.Name, &meta_v1.DeleteOptions{}))
+	collect("token secret", secret.Name, cs.CoreV1().Secrets(secret.Namespace).Delete(secret.Name, &meta_v1.DeleteOptions{}))
+	collect("service account", serviceAccount.Name, cs.CoreV1().ServiceAccounts(serviceAccount.Namespace).Delete(serviceAccount.Name, &meta_v1.DeleteOptions{}))
+	if len(errs) > 0 {
+		return errors.New(strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// ephemeralPlankName names plank after the debug attachment it serves, ephemeral containers can never be removed
+// from a pod, so every debug session needs a name of its own
+func ephemeralPlankName(da *squashv1.DebugAttachment) string {
+	name := fmt.Sprintf("%v-%v", sqOpts.PlankContainerName, da.Metadata.Name)
+	// likewise the plank that re-attaches after a restart
+	var suffix string
+	if count := da.GetReattachments().GetCount(); count > 0 {
+		suffix = fmt.Sprintf("-%v", count)
+	}
+	if len(name)+len(suffix) > maxContainerNameLength {
+		name = name[:maxContainerNameLength-len(suffix)]
+	}
+	return name + suffix
+}
+
+// waitForEphemeralContainer waits until the ephemeral container runs, or has run for debuggers that do not keep plank running
+func (s *Squash) waitForEphemeralContainer(ctx context.Context, name string) error {
+	cs, err := s.getClientSet()
+	if err != nil {
+		return err
+	}
+	expectRunning := local.GetParticularDebugger(s.Debugger).ExpectRunningPlank()
+	for {
+		status, err := ephemeralContainerStatus(cs, s.Namespace, s.Pod, name)
+		if err != nil {
+			return err
+		}
+		if status != nil {
+			switch {
+			case status.State.Running != nil:
+				return nil
+			case status.State.Terminated != nil:
+				if !expectRunning {
+					return nil
+				}
+				return fmt.Errorf("container terminated: %v", status.State.Terminated.Reason)
+			}
+			if !s.Machine {
+				fmt.Println("Ephemeral container creating")
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// EphemeralContainerRunning reports whether the named ephemeral container of a pod is running
+func EphemeralContainerRunning(cs kubernetes.Interface, namespace, pod, name string) (bool, error) {
+	status, err := ephemeralContainerStatus(cs, namespace, pod, name)
+	if err != nil {
+		return false, err
+	}
+	return status != nil && status.State.Running != nil, nil
+}
+
+// ephemeralContainerStatus reads the status of the named ephemeral container, it is nil until the container is created
+func ephemeralContainerStatus(cs kubernetes.Interface, namespace, pod, name string) (*v1.ContainerStatus, error) {
+	raw, err := cs.CoreV1().RESTClient().Get().Namespace(namespace).Resource("pods").Name(pod).Do().Raw()
+	if err != nil {
+		return nil, err
+	}
+	var statuses ephemeralContainerStatuses
+	if err := json.Unmarshal(raw, &statuses); err != nil {
+		return nil, err
+	}
+	for _, status := range statuses.Status.EphemeralContainerStatuses {
+		if status.Name == name {
+			return &status, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,189 @@
+package config
+
+import (
+	"fmt"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/solo-io/solo-kit/pkg/api/v1/resources/core"
+	squashv1 "github.com/solo-io/squash/pkg/api/v1"
+	sqOpts "github.com/solo-io/squash/pkg/options"
+	v1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("ephemeral plank", func() {
+	const namespace = "target"
+	var da *squashv1.DebugAttachment
+
+	BeforeEach(func() {
+		da = &squashv1.DebugAttachment{
+			Metadata: core.Metadata{Name: "session", Namespace: namespace},
+			Intent: &squashv1.Intent{
+				Pod:           &core.ResourceRef{Name: "app-1234", Namespace: namespace},
+				ContainerName: "app",
+				Debugger:      "dlv",
+				PlankBackend:  sqOpts.PlankBackendEphemeral,
+			},
+		}
+	})
+
+	Describe("access", func() {
+		It("should only reach the plank's own debug attachment", func() {
+			serviceAccount, secret, role, binding := ephemeralPlankAccess(da)
+
+			Expect(serviceAccount.Name).To(Equal("squash-ephemeral-plank-session"))
+			Expect(serviceAccount.Namespace).To(Equal(namespace))
+
+			Expect(secret.Type).To(Equal(v1.SecretTypeServiceAccountToken))
+			Expect(secret.Annotations[v1.ServiceAccountNameKey]).To(Equal(serviceAccount.Name))
+
+			Expect(role.Rules).To(Equal([]rbacv1.PolicyRule{{
+				Verbs:         []string{"get", "update"},
+				Resources:     []string{"debugattachments"},
+				APIGroups:     []string{"squash.solo.io"},
+				ResourceNames: []string{"session"},
+			}}))
+
+			Expect(binding.RoleRef).To(Equal(rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "Role", Name: role.Name}))
+			Expect(binding.Subjects).To(Equal([]rbacv1.Subject{{
+				Kind:      rbacv1.ServiceAccountKind,
+				Name:      serviceAccount.Name,
+				Namespace: namespace,
+			}}))
+		})
+
+		It("should grant access without touching the target's service account", func() {
+			cs := fake.NewSimpleClientset()
+			Expect(grantEphemeralPlankAccess(cs, da)).To(Succeed())
+			// granted again when the plank is replaced
+			Expect(grantEphemeralPlankAccess(cs, da)).To(Succeed())
+
+			_, err := cs.CoreV1().ServiceAccounts(namespace).Get("squash-ephemeral-plank-session", meta_v1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			_, err = cs.CoreV1().Secrets(namespace).Get("squash-ephemeral-plank-session", meta_v1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			bindings, err := cs.RbacV1().RoleBindings(namespace).List(meta_v1.ListOptions{})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(bindings.Items).To(HaveLen(1))
+			Expect(bindings.Items[0].Subjects[0].Name).To(Equal("squash-ephemeral-plank-session"))
+		})
+
+		It("should revoke access once the debug attachment is deleted", func() {
+			cs := fake.NewSimpleClientset()
+			Expect(grantEphemeralPlankAccess(cs, da)).To(Succeed())
+			Expect(RevokeEphemeralPlankAccess(cs, da)).To(Succeed())
+			// already revoked
+			Expect(RevokeEphemeralPlankAccess(cs, da)).To(Succeed())
+
+			_, err := cs.CoreV1().ServiceAccounts(namespace).Get("squash-ephemeral-plank-session", meta_v1.GetOptions{})
+			Expect(kerrors.IsNotFound(err)).To(BeTrue())
+			_, err = cs.CoreV1().Secrets(namespace).Get("squash-ephemeral-plank-session", meta_v1.GetOptions{})
+			Expect(kerrors.IsNotFound(err)).To(BeTrue())
+			_, err = cs.RbacV1().Roles(namespace).Get("squash-ephemeral-plank-session", meta_v1.GetOptions{})
+			Expect(kerrors.IsNotFound(err)).To(BeTrue())
+			_, err = cs.RbacV1().RoleBindings(namespace).Get("squash-ephemeral-plank-session", meta_v1.GetOptions{})
+			Expect(kerrors.IsNotFound(err)).To(BeTrue())
+		})
+
+		It("should leave other plank backends alone", func() {
+			da.Intent.PlankBackend = sqOpts.PlankBackendPod
+			cs := fake.NewSimpleClientset(&v1.ServiceAccount{ObjectMeta: meta_v1.ObjectMeta{
+				Name:      "squash-ephemeral-plank-session",
+				Namespace: namespace,
+			}})
+			Expect(RevokeEphemeralPlankAccess(cs, da)).To(Succeed())
+			_, err := cs.CoreV1().ServiceAccounts(namespace).Get("squash-ephemeral-plank-session", meta_v1.GetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+		})
+	})
+
+	Describe("container", func() {
+		var (
+			s         Squash
+			targetPod *v1.Pod
+		)
+		serviceAccountMount := v1.VolumeMount{
+			Name:      "kube-api-access-x7k2p",
+			MountPath: serviceAccountMountPath,
+			ReadOnly:  true,
+		}
+
+		BeforeEach(func() {
+			s = NewSquashConfig()
+			s.Debugger = "dlv"
+			s.Namespace = namespace
+			s.Pod = "app-1234"
+			s.Container = "app"
+			targetPod = &v1.Pod{
+				ObjectMeta: meta_v1.ObjectMeta{Name: "app-1234", Namespace: namespace},
+				Spec: v1.PodSpec{Containers: []v1.Container{{
+					Name:         "app",
+					VolumeMounts: []v1.VolumeMount{{Name: "data", MountPath: "/data"}, serviceAccountMount},
+				}, {
+					Name: "sidecar",
+				}}},
+			}
+		})
+
+		It("should borrow the target's service account mount and authenticate with its own token", func() {
+			container, err := s.ephemeralPlankContainer(targetPod, da)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(container.Name).To(Equal("plank-session"))
+			Expect(container.TargetContainerName).To(Equal("app"))
+			Expect(container.VolumeMounts).To(Equal([]v1.VolumeMount{serviceAccountMount}))
+			Expect(container.Env).To(ContainElement(v1.EnvVar{
+				Name: sqOpts.PlankEnvToken,
+				ValueFrom: &v1.EnvVarSource{
+					SecretKeyRef: &v1.SecretKeySelector{
+						LocalObjectReference: v1.LocalObjectReference{Name: "squash-ephemeral-plank-session"},
+						Key:                  v1.ServiceAccountTokenKey,
+					},
+				},
+			}))
+			Expect(container.Env).To(ContainElement(v1.EnvVar{
+				Name:  sqOpts.PlankEnvEphemeralContainer,
+				Value: "plank-session",
+			}))
+		})
+
+		It("should fail when the target does not mount a service account token", func() {
+			targetPod.Spec.Containers[0].VolumeMounts = nil
+			_, err := s.ephemeralPlankContainer(targetPod, da)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("does not mount a service account token"))
+		})
+
+		It("should fail when the target container does not exist", func() {
+			s.Container = "missing"
+			_, err := s.ephemeralPlankContainer(targetPod, da)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should name the plank of each re-attachment apart", func() {
+			da.Reattachments = &squashv1.Reattachments{Count: 2}
+			container, err := s.ephemeralPlankContainer(targetPod, da)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(container.Name).To(Equal("plank-session-2"))
+		})
+	})
+
+	Describe("errors", func() {
+		It("should explain Pod Security rejections", func() {
+			podSecurity := kerrors.NewForbidden(schema.GroupResource{Resource: "pods"}, "app-1234",
+				fmt.Errorf(`violates PodSecurity "baseline:latest": non-default capabilities (container "plank-session" must not include "SYS_PTRACE" in securityContext.capabilities.add)`))
+			err := ephemeralContainerError(podSecurity, "plank-session", "app-1234")
+			Expect(err.Error()).To(ContainSubstring("pod-security.kubernetes.io/enforce=privileged"))
+
+			other := kerrors.NewForbidden(schema.GroupResource{Resource: "pods"}, "app-1234", fmt.Errorf("no access"))
+			err = ephemeralContainerError(other, "plank-session", "app-1234")
+			Expect(err.Error()).NotTo(ContainSubstring("Pod Security"))
+			Expect(err.Error()).To(ContainSubstring("adding ephemeral container plank-session"))
+		})
+	})
+})
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/ghodss/yaml"
+	"github.com/pkg/errors"
+	sqOpts "github.com/solo-io/squash/pkg/options"
+	v1 "k8s.io/api/core/v1"
+)
+
+// PlankPodTemplate holds what clusters may require of plank pods, beyond what squash generates:
+// resources for quota-enforced namespaces, tolerations for tainted nodes, and metadata for admission policies
+type PlankPodTemplate struct {
+	// Labels are added to the plank pod's labels, the labels that squash finds planks by take precedence
+	Labels map[string]string `json:"labels,omitempty"`
+	// Annotations are added to the plank pod's annotations, such as seccomp or AppArmor profiles
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// Resources are the resource requests and limits of the plank container
+	Resources v1.ResourceRequirements `json:"resources,omitempty"`
+	// Tolerations let plank run on the tainted node of its target
+	Tolerations       []v1.Toleration `json:"tolerations,omitempty"`
+	PriorityClassName string          `json:"priorityClassName,omitempty"`
+	// ImagePullSecrets are used in addition to the squash image pull secret
+	ImagePullSecrets []v1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+}
+
+// IsEmpty is true when the template does not change the plank pod
+func (t PlankPodTemplate) IsEmpty() bool {
+	return len(t.Labels) == 0 &&
+		len(t.Annotations) == 0 &&
+		len(t.Resources.Limits) == 0 &&
+		len(t.Resources.Requests) == 0 &&
+		len(t.Tolerations) == 0 &&
+		t.PriorityClassName == "" &&
+		len(t.ImagePullSecrets) == 0
+}
+
+// ParsePlankPodTemplate reads a template from yaml or json
+func ParsePlankPodTemplate(data []byte) (PlankPodTemplate, error) {
+	var t PlankPodTemplate
+	if err := yaml.Unmarshal(data, &t); err != nil {
+		return t, errors.Wrap(err, "parsing plank pod template")
+	}
+	return t, nil
+}
+
+// PlankPodTemplateFromEnv reads the template that the squash deployment was installed with
+func PlankPodTemplateFromEnv() (PlankPodTemplate, error) {
+	data := os.Getenv(sqOpts.EnvPlankPodTemplate)
+	if data == "" {
+		return PlankPodTemplate{}, nil
+	}
+	return ParsePlankPodTemplate([]byte(data))
+}
+
+// PlankPodTemplateEnv passes the template to the squash deployment, which applies it to the planks it creates
+func PlankPodTemplateEnv(t PlankPodTemplate) ([]v1.EnvVar, error) {
+	if t.IsEmpty() {
+		return nil, nil
+	}
+	data, err := json.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+	return []v1.EnvVar{{
+		Name:  sqOpts.EnvPlankPodTemplate,
+		Value: string(data),
+	}}, nil
+}
+
+// applyTo merges the template into a generated plank pod, whose first container runs plank
+func (t PlankPodTemplate) applyTo(pod *v1.Pod) {
+	pod.Labels = mergeStringMaps(t.Labels, pod.Labels)
+	pod.Annotations = mergeStringMaps(pod.Annotations, t.Annotations)
+	if len(pod.Spec.Containers) > 0 {
+		pod.Spec.Containers[0].Resources = t.Resources
+	}
+	pod.Spec.Tolerations = append(pod.Spec.Tolerations, t.Tolerations...)
+	if t.PriorityClassName != "" {
+		pod.Spec.PriorityClassName = t.PriorityClassName
+	}
+	for _, secret := range t.ImagePullSecrets {
+		if !hasImagePullSecret(pod.Spec.ImagePullSecrets, secret.Name) {
+			pod.Spec.ImagePullSecrets = append(pod.Spec.ImagePullSecrets, secret)
+		}
+	}
+}
+
+// mergeStringMaps returns the entries of both maps, override takes precedence
+func mergeStringMaps(base, override map[string]string) map[string]string {
+	if len(base) == 0 && len(override) == 0 {
+		return nil
+	}
+	merged := make(map[string]string, len(base)+len(override))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range override {
+		merged[k] = v
+	}
+	return merged
+}
+
+func hasImagePullSecret(secrets []v1.LocalObjectReference, name string) bool {
+	for _, secret := range secrets {
+		if secret.Name == name {
+			return true
+		}
+	}
+	return false
+}
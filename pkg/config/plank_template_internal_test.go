@@ -0,0 +1,112 @@
+package config
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ = Describe("PlankPodTemplate", func() {
+	generatedPod := func() *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: meta_v1.ObjectMeta{
+				Labels:      map[string]string{"squash": "plank", "debug_attachment_name": "session"},
+				Annotations: map[string]string{"squash.solo.io/created-at": "2019-03-04T05:06:07Z"},
+			},
+			Spec: v1.PodSpec{
+				Containers: []v1.Container{
+					{
+						Name: "plank",
+						Resources: v1.ResourceRequirements{
+							Requests: v1.ResourceList{v1.ResourceMemory: resource.MustParse("64Mi")},
+						},
+					},
+					{Name: "sidecar"},
+				},
+				Tolerations:       []v1.Toleration{{Operator: v1.TolerationOpExists}},
+				PriorityClassName: "system-node-critical",
+				ImagePullSecrets:  []v1.LocalObjectReference{{Name: "squash-sa-image-pull-secret"}},
+			},
+		}
+	}
+
+	template, err := ParsePlankPodTemplate([]byte(`
+labels: {squash: hijacked, team: payments}
+annotations: {squash.solo.io/created-at: template, seccomp.security.alpha.kubernetes.io/pod: runtime/default}
+resources:
+  requests: {cpu: 100m}
+  limits: {cpu: 500m, memory: 512Mi}
+tolerations:
+- {key: nvidia.com/gpu, operator: Exists, effect: NoSchedule}
+priorityClassName: debug
+imagePullSecrets:
+- name: squash-sa-image-pull-secret
+- name: team-registry
+`))
+
+	It("should parse yaml", func() {
+		Expect(err).NotTo(HaveOccurred())
+		Expect(template.IsEmpty()).To(BeFalse())
+		Expect(PlankPodTemplate{}.IsEmpty()).To(BeTrue())
+	})
+
+	It("should keep the labels that squash finds planks by", func() {
+		pod := generatedPod()
+		template.applyTo(pod)
+		Expect(pod.Labels).To(Equal(map[string]string{"squash": "plank", "debug_attachment_name": "session", "team": "payments"}))
+	})
+
+	It("should let the template's annotations win", func() {
+		pod := generatedPod()
+		template.applyTo(pod)
+		Expect(pod.Annotations).To(Equal(map[string]string{
+			"squash.solo.io/created-at":                "template",
+			"seccomp.security.alpha.kubernetes.io/pod": "runtime/default",
+		}))
+	})
+
+	It("should replace the resources of the plank container only", func() {
+		pod := generatedPod()
+		template.applyTo(pod)
+		Expect(pod.Spec.Containers[0].Resources).To(Equal(v1.ResourceRequirements{
+			Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("100m")},
+			Limits:   v1.ResourceList{v1.ResourceCPU: resource.MustParse("500m"), v1.ResourceMemory: resource.MustParse("512Mi")},
+		}))
+		Expect(pod.Spec.Containers[1].Resources).To(Equal(v1.ResourceRequirements{}))
+	})
+
+	It("should add tolerations and image pull secrets to the generated ones", func() {
+		pod := generatedPod()
+		template.applyTo(pod)
+		Expect(pod.Spec.Tolerations).To(Equal([]v1.Toleration{
+			{Operator: v1.TolerationOpExists},
+			{Key: "nvidia.com/gpu", Operator: v1.TolerationOpExists, Effect: v1.TaintEffectNoSchedule},
+		}))
+		Expect(pod.Spec.ImagePullSecrets).To(Equal([]v1.LocalObjectReference{{Name: "squash-sa-image-pull-secret"}, {Name: "team-registry"}}))
+		Expect(pod.Spec.PriorityClassName).To(Equal("debug"))
+	})
+
+	It("should leave the generated pod alone when empty", func() {
+		pod := generatedPod()
+		PlankPodTemplate{}.applyTo(pod)
+		expected := generatedPod()
+		// an empty template still sets the plank container's resources
+		expected.Spec.Containers[0].Resources = v1.ResourceRequirements{}
+		Expect(pod).To(Equal(expected))
+	})
+
+	It("should pass through the environment of the squash deployment", func() {
+		env, err := PlankPodTemplateEnv(template)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(env).To(HaveLen(1))
+		parsed, err := ParsePlankPodTemplate([]byte(env[0].Value))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(parsed).To(Equal(template))
+
+		env, err = PlankPodTemplateEnv(PlankPodTemplate{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(env).To(BeEmpty())
+	})
+})
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	sqOpts "github.com/solo-io/squash/pkg/options"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// ProbeDebugger runs a short-lived plank pod that inspects the target process and reports which debugger suits it.
+// Returns "" if the plank could not tell.
+func (s *Squash) ProbeDebugger() (string, error) {
+	cs, err := s.getClientSet()
+	if err != nil {
+		return "", err
+	}
+	targetPod, err := cs.CoreV1().Pods(s.Namespace).Get(s.Pod, meta_v1.GetOptions{})
+	if err != nil {
+		return "", errors.Wrap(err, "fetching pod")
+	}
+
+	labels := map[string]string{
+		sqOpts.SquashLabelSelectorKey: sqOpts.SquashLabelSelectorValue,
+		sqOpts.PlankProbeLabelKey:     "true",
+	}
+	probePod := s.plankPodFor(targetPod, containerNameFromSpec(""), labels, []v1.EnvVar{{
+		Name:  sqOpts.PlankEnvProbeTargetNamespace,
+		Value: s.Namespace,
+	}, {
+		Name:  sqOpts.PlankEnvProbeTargetPod,
+		Value: s.Pod,
+	}, {
+		Name:  sqOpts.PlankEnvProbeTargetContainer,
+		Value: s.Container,
+	}, {
+		Name:  sqOpts.PlankEnvProbeProcessMatcher,
+		Value: s.ProcessName,
+	}})
+	createdPod, err := cs.CoreV1().Pods(s.SquashNamespace).Create(probePod)
+	if err != nil {
+		return "", fmt.Errorf("Could not create probe pod: %v", err)
+	}
+	defer cs.CoreV1().Pods(s.SquashNamespace).Delete(createdPod.Name, &meta_v1.DeleteOptions{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.waitTimeout())
+	defer cancel()
+	w, err := cs.CoreV1().Pods(s.SquashNamespace).Watch(meta_v1.ListOptions{
+		FieldSelector:   fields.OneTermEqualSelector("metadata.name", createdPod.Name).String(),
+		ResourceVersion: createdPod.ResourceVersion,
+	})
+	if err != nil {
+		return "", errors.Wrap(err, "watching probe pod")
+	}
+	defer w.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("probe did not complete in the allotted time")
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				return "", fmt.Errorf("probe pod watch closed unexpectedly")
+			}
+			if event.Type == watch.Error || event.Type == watch.Deleted {
+				return "", fmt.Errorf("probe pod %v did not complete", createdPod.Name)
+			}
+			pod, ok := event.Object.(*v1.Pod)
+			if !ok {
+				continue
+			}
+			switch pod.Status.Phase {
+			case v1.PodSucceeded:
+				return probeResult(pod), nil
+			case v1.PodFailed:
+				return "", fmt.Errorf("probe failed: %v", probeResult(pod))
+			}
+		}
+	}
+}
+
+// the probe reports through its termination message
+func probeResult(pod *v1.Pod) string {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.State.Terminated != nil {
+			return strings.TrimSpace(status.State.Terminated.Message)
+		}
+	}
+	return ""
+}
@@ -5,9 +5,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"net/url"
 	"os"
 	"strings"
-	"time"
 
 	squashkubeutils "github.com/solo-io/squash/pkg/utils/kubeutils"
 
@@ -41,9 +41,39 @@ type Squash struct {
 	Machine            bool
 	DebugServerAddress string
 	ProcessName        string
-
+	// MatchAllProcesses attaches to every process that matches ProcessName, rather than only the first
+	MatchAllProcesses bool
+	// AdditionalContainers are debugged in the same session as Container
+	AdditionalContainers []string
+	// FollowProcesses attaches to matching processes that start after the session began
+	FollowProcesses bool
+	// ReattachOnRestart keeps the session across restarts of the target containers
+	ReattachOnRestart bool
+
+	// Selector, Deployment, StatefulSet and Service choose the pod to debug among the pods that they select,
+	// at most one of them is set, and only when Pod is not
+	Selector    string
+	Deployment  string
+	StatefulSet string
+	Service     string
+	// Workload records what the pod to debug was chosen from, once it was resolved from one of the above
+	Workload *squashv1.Workload
+
+	// PlankBackend selects how plank is run, one of the sqOpts.PlankBackend values. Defaults to a plank pod
+	PlankBackend string
+
+	// CRISock is the path of the CRI socket on the node, plank discovers it if this is empty
 	CRISock string
 
+	// PlankTemplate is merged into the plank pods
+	PlankTemplate PlankPodTemplate
+
+	// KubeConfig selects the cluster, the default kubeconfig and its current context if empty
+	KubeConfig squashkubeutils.KubeConfig
+
+	// PrintEditorData, if set, reports ready debug sessions to the editor in machine mode
+	PrintEditorData func(EditorData) error
+
 	clientset kubernetes.Interface
 
 	SquashNamespace string
@@ -59,6 +89,47 @@ type DebugTarget struct {
 }
 
 func StartDebugContainer(s Squash, dbt DebugTarget) (*v1.Pod, error) {
+	createdPod, err := s.startPlank()
+	if createdPod != nil && !s.Machine && !s.NoClean {
+		// do not remove the pod on a debug server as it is waiting for a
+		// connection
+		// TODO: handle returned error
+		defer s.deletePod(createdPod)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.ReportOrConnectToCreatedDebuggerPod(); err != nil {
+		return nil, err
+	}
+
+	return createdPod, nil
+}
+
+// StartPlank creates a plank pod and waits until it has attached to the debug target.
+// Unlike StartDebugContainer, it does not connect to the debugger, so it is suitable for the squash server.
+func StartPlank(s Squash) (*squashv1.DebugAttachment, error) {
+	if _, err := s.startPlank(); err != nil {
+		return nil, err
+	}
+	_, da, err := s.waitForCreatedDebugAttachment()
+	return da, err
+}
+
+// startPlank runs plank with the configured backend. It returns the created plank pod, if plank runs in a pod of its own
+func (s *Squash) startPlank() (*v1.Pod, error) {
+	switch s.PlankBackend {
+	case "", sqOpts.PlankBackendPod:
+		return s.startPlankPod()
+	case sqOpts.PlankBackendEphemeral:
+		return nil, s.startEphemeralPlank()
+	}
+	return nil, fmt.Errorf("unknown plank backend %v, use %v or %v", s.PlankBackend, sqOpts.PlankBackendPod, sqOpts.PlankBackendEphemeral)
+}
+
+// startPlankPod returns the created pod, if any, even when it fails to start
+func (s *Squash) startPlankPod() (*v1.Pod, error) {
 	dbgpod, err := s.debugPodFor()
 	if err != nil {
 		return nil, err
@@ -78,26 +149,14 @@ func StartDebugContainer(s Squash, dbt DebugTarget) (*v1.Pod, error) {
 		return nil, fmt.Errorf("Could not create pod: %v", err)
 	}
 
-	if !s.Machine && !s.NoClean {
-		// do not remove the pod on a debug server as it is waiting for a
-		// connection
-		// TODO: handle returned error
-		defer s.deletePod(createdPod)
-	}
-
 	// wait for running state
-	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Second)
-	err = <-s.waitForPod(ctx, createdPod)
+	ctx, cancel := context.WithTimeout(context.Background(), s.waitTimeout())
+	err = s.waitForPod(ctx, createdPod)
 	cancel()
 	if err != nil {
 		// s.printError(createdPodName)
-		return nil, fmt.Errorf("Waiting for pod: %v", err)
-	}
-
-	if err := s.ReportOrConnectToCreatedDebuggerPod(); err != nil {
-		return nil, err
+		return createdPod, fmt.Errorf("Waiting for pod: %v", err)
 	}
-
 	return createdPod, nil
 }
 
@@ -147,74 +206,138 @@ func (s *Squash) getDebuggerPodNamespace() string {
 }
 
 func (s *Squash) ReportOrConnectToCreatedDebuggerPod() error {
-	da, err := s.getDebugAttachment()
+	daClient, da, err := s.waitForCreatedDebugAttachment()
 	if err != nil {
 		return err
 	}
-	remoteDbgPort, err := local.GetDebugPortFromCrd(da.Metadata.Name, s.Namespace)
+	return s.ConnectToDebugAttachment(daClient, da)
+}
+
+// ConnectToDebugAttachment forwards local ports to the debuggers of an attached debug attachment.
+// In machine mode it reports them to the editor, otherwise it starts the local debugger client.
+// Either way, the debug session outlives the connection.
+func (s *Squash) ConnectToDebugAttachment(daClient squashv1.DebugAttachmentClient, da *squashv1.DebugAttachment) error {
+	if s.Machine {
+		return s.printEditorExtensionData(daClient, da)
+	}
+	return s.connectUser(daClient, da)
+}
+
+// waitForCreatedDebugAttachment finds the debug attachment for the user's intent and waits until its debugger is attached
+func (s *Squash) waitForCreatedDebugAttachment() (squashv1.DebugAttachmentClient, *squashv1.DebugAttachment, error) {
+	daClient, err := utils.GetBasicDebugAttachmentClientFor(context.Background(), s.KubeConfig)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
-	if s.Machine {
-		return s.printEditorExtensionData(remoteDbgPort)
+	// Refactor - eventually Intent will be created during config/user entry
+	intent := s.GetIntent()
+	da, err := intent.GetDebugAttachment(daClient)
+	if err != nil {
+		return nil, nil, err
 	}
-	return s.connectUser(da, remoteDbgPort)
+	ctx, cancel := context.WithTimeout(context.Background(), s.waitTimeout())
+	defer cancel()
+	da, err = s.waitForAttachment(ctx, daClient, da.Metadata.Namespace, da.Metadata.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+	return daClient, da, nil
 }
 
+// EditorData describes a ready debug session to editor extensions
 type EditorData struct {
-	PortForwardCmd string
+	// LocalAddress accepts debugger connections for as long as squashctl is running
+	// When several processes are attached, it belongs to the first of them
+	LocalAddress string
+	// Processes lists every attached process
+	Processes []EditorProcess
+}
+
+// EditorProcess describes where the debugger of one attached process can be reached
+type EditorProcess struct {
+	ContainerName string
+	Pid           int64
+	LocalAddress  string
+	// DebuggerUrl is the websocket url of debuggers such as the node inspector, on the local address
+	DebuggerUrl string `json:",omitempty"`
 }
 
-func (s *Squash) connectUser(da *squashv1.DebugAttachment, remoteDbgPort int) error {
+// forwardedProcess is an attached process with a local port forwarded to its debugger
+type forwardedProcess struct {
+	process *squashv1.AttachedProcess
+	fwd     *squashkubeutils.PortForwarder
+}
+
+func (s *Squash) connectUser(daClient squashv1.DebugAttachmentClient, da *squashv1.DebugAttachment) error {
 	if s.Machine {
 		return nil
 	}
-	debugger := local.GetParticularDebugger(s.Debugger)
-	kubectlCmd := debugger.GetRemoteConnectionCmd(
-		da.PlankName,
-		s.SquashNamespace,
-		s.Pod,
-		s.Namespace,
-		s.LocalPort,
-		remoteDbgPort,
-	)
-	// Starting port forward in background.
-	if err := kubectlCmd.Start(); err != nil {
-		// s.printError(createdPodName)
+	forwarded, err := s.forwardAttachedProcesses(da, s.LocalPort)
+	if err != nil {
 		return err
 	}
-	// kill the kubectl port-forward process on exit to free the port
-	// this defer must be called after Start() initializes Process
-	defer kubectlCmd.Process.Kill()
+	// free the ports on exit
+	defer closeForwards(forwarded)
 
-	// Delaying to allow port forwarding to complete.
-	time.Sleep(5 * time.Second)
 	if os.Getenv("DEBUG_SELF") != "" {
 		fmt.Println("FOR DEBUGGING SQUASH'S DEBUGGER CONTAINER:")
 		fmt.Println("TODO")
 		// s.printError(createdPod)
 	}
 
-	dbgCmd := debugger.GetDebugCmd(s.LocalPort)
+	// the interactive debugger connects to the first process, the others are reachable while it runs
+	for _, f := range forwarded[1:] {
+		fmt.Printf("Also attached to pid %v in container %v, debugger listening on %v\n", f.process.Pid, f.process.ContainerName, f.fwd.LocalAddress())
+	}
+	if debuggerUrl := forwarded[0].debuggerUrl(); debuggerUrl != "" {
+		fmt.Printf("Debugger websocket url: %v\n", debuggerUrl)
+	}
+	return s.runDebugger(daClient, da, forwarded)
+}
+
+// runDebugger runs the terminal client of the debugger against the first forwarded process.
+// Debuggers without one are used from an editor, so the session is held until squashctl is interrupted
+// or the debug attachment is deleted.
+func (s *Squash) runDebugger(daClient squashv1.DebugAttachmentClient, da *squashv1.DebugAttachment, forwarded []forwardedProcess) error {
+	dbgCmd := local.GetParticularDebugger(s.Debugger).GetDebugCmd(forwarded[0].fwd.LocalPort)
+	if dbgCmd == nil {
+		fmt.Printf("%v has no terminal client. Attach your editor to the debugger listening on %v, press Ctrl-C to end the session\n", s.Debugger, forwarded[0].fwd.LocalAddress())
+		return s.holdSession(daClient, da, forwarded)
+	}
 	return dbgCmd.Run()
 }
 
-func (s *Squash) printEditorExtensionData(remoteDbgPort int) error {
-	da, err := s.getDebugAttachment()
+// printEditorExtensionData reports the ready local addresses to the editor, then keeps forwarding
+// until squashctl is interrupted or the debug attachment is deleted
+func (s *Squash) printEditorExtensionData(daClient squashv1.DebugAttachmentClient, da *squashv1.DebugAttachment) error {
+	forwarded, err := s.forwardAttachedProcesses(da, 0)
 	if err != nil {
 		return err
 	}
+	if err := s.printEditorData(forwarded); err != nil {
+		closeForwards(forwarded)
+		return err
+	}
+	return s.holdSession(daClient, da, forwarded)
+}
 
-	debugger := local.GetParticularDebugger(s.Debugger)
-	kubectlCmd := debugger.GetEditorRemoteConnectionCmd(
-		da.PlankName,
-		s.SquashNamespace,
-		s.Pod,
-		s.Namespace,
-		remoteDbgPort,
-	)
-	ed := EditorData{
-		PortForwardCmd: kubectlCmd,
+// printEditorData reports the forwarded processes to the editor, with PrintEditorData if it is set,
+// otherwise as one line of json. It is reported again whenever the set of attached processes changes
+func (s *Squash) printEditorData(forwarded []forwardedProcess) error {
+	ed := EditorData{}
+	for _, f := range forwarded {
+		ed.Processes = append(ed.Processes, EditorProcess{
+			ContainerName: f.process.ContainerName,
+			Pid:           f.process.Pid,
+			LocalAddress:  f.fwd.LocalAddress(),
+			DebuggerUrl:   f.debuggerUrl(),
+		})
+	}
+	if len(forwarded) > 0 {
+		ed.LocalAddress = forwarded[0].fwd.LocalAddress()
+	}
+	if s.PrintEditorData != nil {
+		return s.PrintEditorData(ed)
 	}
 	json, err := json.Marshal(ed)
 	if err != nil {
@@ -224,7 +347,63 @@ func (s *Squash) printEditorExtensionData(remoteDbgPort int) error {
 	return nil
 }
 
-// TODO - remove this when V2 api is ready
+// forwardAttachedProcesses forwards a local port to the debugger of each attached process
+// localPort is used for the first process, the others get a free port
+func (s *Squash) forwardAttachedProcesses(da *squashv1.DebugAttachment, localPort int) ([]forwardedProcess, error) {
+	processes := da.ListAttachedProcesses()
+	if len(processes) == 0 {
+		return nil, fmt.Errorf("no processes are attached on debug attachment %v", da.Metadata.Name)
+	}
+	var forwarded []forwardedProcess
+	for i, process := range processes {
+		if i > 0 {
+			localPort = 0
+		}
+		fwd, err := s.forwardDebugPort(da, process, localPort)
+		if err != nil {
+			closeForwards(forwarded)
+			return nil, err
+		}
+		forwarded = append(forwarded, forwardedProcess{process: process, fwd: fwd})
+	}
+	return forwarded, nil
+}
+
+// debuggerUrl returns the websocket url of the process's debugger, with the host replaced by the forwarded address
+func (f forwardedProcess) debuggerUrl() string {
+	if f.process.DebuggerUrl == "" {
+		return ""
+	}
+	u, err := url.Parse(f.process.DebuggerUrl)
+	if err != nil {
+		return ""
+	}
+	u.Host = f.fwd.LocalAddress()
+	return u.String()
+}
+
+func closeForwards(forwarded []forwardedProcess) {
+	for _, f := range forwarded {
+		f.fwd.Close()
+	}
+}
+
+// forwardDebugPort forwards a local port to the pod that serves the process's debugger, a localPort of 0 picks a free port
+func (s *Squash) forwardDebugPort(da *squashv1.DebugAttachment, process *squashv1.AttachedProcess, localPort int) (*squashkubeutils.PortForwarder, error) {
+	debugPod, err := da.GetProcessDebugPod(process)
+	if err != nil {
+		return nil, err
+	}
+	remoteDbgPort, err := da.GetProcessDebugPort(process)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), s.waitTimeout())
+	defer cancel()
+	return squashkubeutils.PortForward(ctx, s.KubeConfig, debugPod.Namespace, debugPod.Name, localPort, remoteDbgPort)
+}
+
+// GetIntent describes the debug target and debugger chosen by the user
 func (s *Squash) GetIntent() squashv1.Intent {
 	return squashv1.Intent{
 		Debugger: s.Debugger,
@@ -232,18 +411,23 @@ func (s *Squash) GetIntent() squashv1.Intent {
 			Name:      s.Pod,
 			Namespace: s.Namespace,
 		},
-		ContainerName: s.Container,
+		ContainerName:            s.Container,
+		ProcessMatcher:           s.ProcessName,
+		MatchAllProcesses:        s.MatchAllProcesses,
+		AdditionalContainerNames: s.AdditionalContainers,
+		FollowProcesses:          s.FollowProcesses,
+		ReattachOnRestart:        s.ReattachOnRestart,
+		PlankBackend:             s.PlankBackend,
+		Workload:                 s.Workload,
 	}
 }
 
-func (s *Squash) getDebugAttachment() (*squashv1.DebugAttachment, error) {
-	// Refactor - eventually Intent will be created during config/user entry
-	intent := s.GetIntent()
-	daClient, err := utils.GetBasicDebugAttachmentClient(context.Background())
-	if err != nil {
-		return &squashv1.DebugAttachment{}, err
+// debug attachments created before the Plank field existed did not record the plank's namespace
+func (s *Squash) getPlankNamespace(da *squashv1.DebugAttachment) string {
+	if ns := da.GetPlank().GetPod().GetNamespace(); ns != "" {
+		return ns
 	}
-	return intent.GetDebugAttachment(daClient)
+	return s.SquashNamespace
 }
 
 func (s *Squash) deletePod(createdPod *v1.Pod) error {
@@ -255,61 +439,6 @@ func (s *Squash) deletePod(createdPod *v1.Pod) error {
 	return cs.CoreV1().Pods(s.Namespace).Delete(createdPod.ObjectMeta.Name, &options)
 }
 
-func (s *Squash) waitForPod(ctx context.Context, createdPod *v1.Pod) <-chan error {
-	errchan := make(chan error, 1)
-	go func() {
-		defer close(errchan)
-		name := createdPod.ObjectMeta.Name
-
-		for {
-			select {
-			case <-ctx.Done():
-				errchan <- ctx.Err()
-				return
-			case <-time.After(time.Second):
-
-				var options meta_v1.GetOptions
-				options.ResourceVersion = createdPod.ResourceVersion
-
-				cs, err := s.getClientSet()
-				if err != nil {
-					errchan <- err
-					return
-				}
-				createdPod, err = cs.CoreV1().Pods(s.SquashNamespace).Get(name, options)
-
-				if createdPod.Status.Phase == v1.PodPending {
-					if !s.Machine {
-						fmt.Println("Pod creating")
-					}
-					continue
-				}
-				if err != nil {
-					errchan <- errors.Wrap(err, "Error during read")
-					return
-				}
-				// TODO - consider refactor such that GetParticularDebugger is only ever called once per session
-				if !local.GetParticularDebugger(s.Debugger).ExpectRunningPlank() {
-					return
-				}
-				if createdPod.Status.Phase == v1.PodRunning {
-					return
-				}
-				if createdPod.Status.Phase != v1.PodPending {
-					// err := s.printError(createdPod)
-					if err != nil {
-						errchan <- errors.Wrapf(err, "pod is not running and not pending, status: %v", createdPod.Status.Phase)
-					} else {
-						errchan <- errors.New(fmt.Sprintf("pod is not running and not pending, status: %v", createdPod.Status.Phase))
-					}
-					return
-				}
-			}
-		}
-	}()
-	return errchan
-}
-
 func (s *Squash) printError(podName string) error {
 	cs, err := s.getClientSet()
 	if err != nil {
@@ -334,19 +463,26 @@ func (s *Squash) printError(podName string) error {
 }
 
 // Containers have a common name, suffixed by the particular debugger that they have installed
-// TODO(mitchdraft) - implement more specific debug containers (for example, bare containers for debuggers that don't need a specific process)
-// for now, default to the gdb variant
+// Debuggers without an image of their own use the gdb variant
 func containerNameFromSpec(debugger string) string {
 	containerVariant := "gdb"
-	if debugger == "dlv" {
+	switch debugger {
+	case "dlv":
 		containerVariant = "dlv"
+	case "nodejs", "nodejs8":
+		containerVariant = "nodejs"
+	case "python":
+		containerVariant = "python"
+	case "debugpy":
+		containerVariant = "debugpy"
+	case "lldb":
+		containerVariant = "lldb"
 	}
 	return fmt.Sprintf("%v-%v", sqOpts.ParticularContainerRootName, containerVariant)
 }
 
 func (s *Squash) debugPodFor() (*v1.Pod, error) {
 	it := s.GetIntent()
-	const crisockvolume = "crisock"
 	cs, err := s.getClientSet()
 	if err != nil {
 		return nil, err
@@ -356,28 +492,56 @@ func (s *Squash) debugPodFor() (*v1.Pod, error) {
 		return nil, err
 	}
 
-	// get debugAttachment name so Plank knows where to find it
-	daClient, err := utils.GetBasicDebugAttachmentClient(context.Background())
+	da, err := s.intendedDebugAttachment()
 	if err != nil {
 		return nil, err
 	}
-	da, err := it.GetDebugAttachment(daClient)
+	// labelled by debug attachment, so that ending one session leaves the planks of other sessions on the pod alone
+	labels := sqOpts.GeneratePlankLabels(&core.ResourceRef{
+		Name:      da.Metadata.Name,
+		Namespace: da.Metadata.Namespace,
+	})
+	return s.plankPodFor(targetPod, containerNameFromSpec(it.Debugger), labels, s.plankEnv(da)), nil
+}
+
+// intendedDebugAttachment finds the debug attachment that was created for the user's intent
+func (s *Squash) intendedDebugAttachment() (*squashv1.DebugAttachment, error) {
+	it := s.GetIntent()
+	daClient, err := utils.GetBasicDebugAttachmentClientFor(context.Background(), s.KubeConfig)
 	if err != nil {
 		return nil, err
 	}
+	return it.GetDebugAttachment(daClient)
+}
 
-	// this is our convention for naming the container images that contain specific debuggers
-	fullParticularContainerName := containerNameFromSpec(it.Debugger)
-	// repoRoot/containerName:tag
-	targetImage := fmt.Sprintf("%v/%v:%v", s.DebugContainerRepo, fullParticularContainerName, s.DebugContainerVersion)
-	templatePod := &v1.Pod{
+// plankEnv tells plank which debug attachment it serves
+func (s *Squash) plankEnv(da *squashv1.DebugAttachment) []v1.EnvVar {
+	return []v1.EnvVar{{
+		Name:  sqOpts.PlankEnvDebugAttachmentNamespace,
+		Value: da.Metadata.Namespace,
+	}, {
+		Name:  sqOpts.PlankEnvDebugAttachmentName,
+		Value: da.Metadata.Name,
+	}, {
+		Name:  sqOpts.PlankEnvDebugSquashNamespace,
+		Value: s.SquashNamespace,
+	}}
+}
+
+// plankPodFor returns a plank pod that can inspect the processes of the target pod
+func (s *Squash) plankPodFor(targetPod *v1.Pod, fullParticularContainerName string, labels map[string]string, env []v1.EnvVar) *v1.Pod {
+	// without a configured socket, plank discovers the node's CRI socket
+	volumes, volumeMounts, criEnv := squashkube.CRISocketVolumes(s.CRISock)
+	container := s.plankContainerFor(sqOpts.PlankContainerName, fullParticularContainerName, append(env, criEnv...))
+	container.VolumeMounts = volumeMounts
+	pod := &v1.Pod{
 		TypeMeta: meta_v1.TypeMeta{
 			Kind:       "Pod",
 			APIVersion: "v1",
 		},
 		ObjectMeta: meta_v1.ObjectMeta{
 			GenerateName: sqOpts.PlankContainerName,
-			Labels:       sqOpts.GeneratePlankLabels(it.Pod),
+			Labels:       labels,
 		},
 		Spec: v1.PodSpec{
 			ServiceAccountName: sqOpts.PlankServiceAccountName,
@@ -387,51 +551,46 @@ func (s *Squash) debugPodFor() (*v1.Pod, error) {
 			ImagePullSecrets: []v1.LocalObjectReference{{
 				Name: sqOpts.SquashServiceAccountImagePullSecretName,
 			}},
-			Containers: []v1.Container{{
-				Name:      sqOpts.PlankContainerName,
-				Image:     targetImage,
-				Stdin:     true,
-				StdinOnce: true,
-				TTY:       true,
-				VolumeMounts: []v1.VolumeMount{{
-					Name:      crisockvolume,
-					MountPath: squashkube.CriRuntime,
-				}},
-				SecurityContext: &v1.SecurityContext{
-					Capabilities: &v1.Capabilities{
-						Add: []v1.Capability{
-							"SYS_PTRACE",
-						},
-					},
-				},
-				Env: []v1.EnvVar{{
-					Name:  sqOpts.PlankEnvDebugAttachmentNamespace,
-					Value: it.Pod.Namespace,
-				}, {
-					Name:  sqOpts.PlankEnvDebugAttachmentName,
-					Value: da.Metadata.Name,
-				}, {
-					Name:  sqOpts.PlankEnvDebugSquashNamespace,
-					Value: s.SquashNamespace,
-				},
-				}},
-			},
-			Volumes: []v1.Volume{{
-				Name: crisockvolume,
-				VolumeSource: v1.VolumeSource{
-					HostPath: &v1.HostPathVolumeSource{
-						Path: s.CRISock,
-					},
-				},
-			}},
+			Containers: []v1.Container{container},
+			Volumes:    volumes,
 		}}
+	s.PlankTemplate.applyTo(pod)
+	return pod
+}
 
-	return templatePod, nil
+// plankContainerFor returns the container that runs plank with the debugger for the intent
+func (s *Squash) plankContainerFor(name, fullParticularContainerName string, env []v1.EnvVar) v1.Container {
+	// this is our convention for naming the container images that contain specific debuggers
+	// repoRoot/containerName:tag
+	targetImage := fmt.Sprintf("%v/%v:%v", s.DebugContainerRepo, fullParticularContainerName, s.DebugContainerVersion)
+	return v1.Container{
+		Name:      name,
+		Image:     targetImage,
+		Stdin:     true,
+		StdinOnce: true,
+		TTY:       true,
+		SecurityContext: &v1.SecurityContext{
+			Capabilities: &v1.Capabilities{
+				Add: plankCapabilities(fullParticularContainerName),
+			},
+		},
+		Env: env,
+	}
+}
+
+// plankCapabilities returns the capabilities that plank needs for the debugger of its image. The node plank
+// enters the network namespace of its target to reach inspectors that only listen on loopback, which takes SYS_ADMIN
+func plankCapabilities(fullParticularContainerName string) []v1.Capability {
+	capabilities := []v1.Capability{"SYS_PTRACE"}
+	if fullParticularContainerName == containerNameFromSpec("nodejs") {
+		capabilities = append(capabilities, "SYS_ADMIN")
+	}
+	return capabilities
 }
 
 func (s *Squash) getClientSet() (kubernetes.Interface, error) {
 	if s.clientset == nil {
-		cs, err := squashkubeutils.GetKubeClient()
+		cs, err := squashkubeutils.GetKubeClientFor(s.KubeConfig)
 		if err != nil {
 			return nil, err
 		}
@@ -446,7 +605,7 @@ func (s *Squash) getClientSet() (kubernetes.Interface, error) {
 // is terminated.
 func (s *Squash) DeletePlankPod() error {
 	intent := s.GetIntent()
-	daClient, err := utils.GetBasicDebugAttachmentClient(context.Background())
+	daClient, err := utils.GetBasicDebugAttachmentClientFor(context.Background(), s.KubeConfig)
 	if err != nil {
 		return err
 	}
@@ -456,10 +615,15 @@ func (s *Squash) DeletePlankPod() error {
 		return err
 	}
 
+	if da.GetPlank().GetEphemeralContainer() != "" {
+		// the plank pod is the target pod, plank exits once its debug attachment is deleted
+		return nil
+	}
+
 	cs, err := s.getClientSet()
 	if err != nil {
 		return err
 	}
 
-	return cs.CoreV1().Pods(s.SquashNamespace).Delete(da.PlankName, &meta_v1.DeleteOptions{})
+	return cs.CoreV1().Pods(s.getPlankNamespace(da)).Delete(da.GetPlank().GetPod().GetName(), &meta_v1.DeleteOptions{})
 }
@@ -0,0 +1,54 @@
+package config
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/solo-io/solo-kit/pkg/api/v1/clients"
+	"github.com/solo-io/solo-kit/pkg/api/v1/clients/factory"
+	"github.com/solo-io/solo-kit/pkg/api/v1/clients/memory"
+	"github.com/solo-io/solo-kit/pkg/api/v1/resources/core"
+	squashv1 "github.com/solo-io/squash/pkg/api/v1"
+	squashkubeutils "github.com/solo-io/squash/pkg/utils/kubeutils"
+)
+
+var _ = Describe("connecting the user", func() {
+	const namespace = "squash-debugger"
+	var (
+		daClient squashv1.DebugAttachmentClient
+		da       *squashv1.DebugAttachment
+	)
+
+	BeforeEach(func() {
+		var err error
+		daClient, err = squashv1.NewDebugAttachmentClient(&factory.MemoryResourceClientFactory{
+			Cache: memory.NewInMemoryResourceCache(),
+		})
+		Expect(err).NotTo(HaveOccurred())
+		da, err = daClient.Write(&squashv1.DebugAttachment{
+			Metadata: core.Metadata{Name: "session", Namespace: namespace},
+			Intent:   &squashv1.Intent{Debugger: "java-port"},
+			State:    squashv1.DebugAttachment_Attached,
+		}, clients.WriteOpts{})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should hold the session of a debugger without a terminal client until the debug attachment is deleted", func() {
+		s := Squash{Debugger: "java-port"}
+		forwarded := []forwardedProcess{{
+			process: &squashv1.AttachedProcess{ContainerName: "app", Pid: 7, PortSpec: squashv1.NewPlankPortSpec(5005)},
+			fwd:     &squashkubeutils.PortForwarder{LocalPort: 5005},
+		}}
+
+		done := make(chan error, 1)
+		go func() {
+			defer GinkgoRecover()
+			done <- s.runDebugger(daClient, da, forwarded)
+		}()
+		Consistently(done, 200*time.Millisecond).ShouldNot(Receive())
+
+		Expect(daClient.Delete(namespace, "session", clients.DeleteOpts{})).To(Succeed())
+		Eventually(done, 5*time.Second).Should(Receive(BeNil()))
+	})
+})
@@ -0,0 +1,246 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/solo-io/solo-kit/pkg/api/v1/clients"
+	squashv1 "github.com/solo-io/squash/pkg/api/v1"
+	"github.com/solo-io/squash/pkg/debuggers/local"
+	v1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+const defaultTimeoutSeconds = 300
+
+// waitTimeout is the time allowed for each stage of session setup
+func (s *Squash) waitTimeout() time.Duration {
+	if s.TimeoutSeconds <= 0 {
+		return defaultTimeoutSeconds * time.Second
+	}
+	return time.Duration(s.TimeoutSeconds) * time.Second
+}
+
+// waitForPod watches the plank pod until it is running
+// Debuggers that do not expect a running plank only need the pod to have been scheduled and started
+func (s *Squash) waitForPod(ctx context.Context, createdPod *v1.Pod) error {
+	cs, err := s.getClientSet()
+	if err != nil {
+		return err
+	}
+	expectRunning := local.GetParticularDebugger(s.Debugger).ExpectRunningPlank()
+	podReady := func(pod *v1.Pod) (bool, error) {
+		switch pod.Status.Phase {
+		case v1.PodPending:
+			if !s.Machine {
+				fmt.Println("Pod creating")
+			}
+			return false, nil
+		case v1.PodRunning:
+			return true, nil
+		}
+		if !expectRunning {
+			return true, nil
+		}
+		return false, fmt.Errorf("pod is not running and not pending, status: %v", pod.Status.Phase)
+	}
+
+	w, err := cs.CoreV1().Pods(createdPod.Namespace).Watch(meta_v1.ListOptions{
+		FieldSelector:   fields.OneTermEqualSelector("metadata.name", createdPod.Name).String(),
+		ResourceVersion: createdPod.ResourceVersion,
+	})
+	if err != nil {
+		return errors.Wrap(err, "watching plank pod")
+	}
+	defer w.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				return fmt.Errorf("plank pod watch closed unexpectedly")
+			}
+			switch event.Type {
+			case watch.Error:
+				return fmt.Errorf("error watching plank pod: %v", event.Object)
+			case watch.Deleted:
+				return fmt.Errorf("plank pod %v was deleted", createdPod.Name)
+			}
+			pod, ok := event.Object.(*v1.Pod)
+			if !ok {
+				continue
+			}
+			ready, err := podReady(pod)
+			if err != nil || ready {
+				return err
+			}
+		}
+	}
+}
+
+// waitForAttachment watches the debug attachment until the debugger is attached and reachable
+func (s *Squash) waitForAttachment(ctx context.Context, daClient squashv1.DebugAttachmentClient, namespace, name string) (*squashv1.DebugAttachment, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	dac, errc, err := daClient.Watch(namespace, clients.WatchOpts{Ctx: ctx})
+	if err != nil {
+		return nil, err
+	}
+	for {
+		select {
+		case err := <-errc:
+			return nil, err
+		case <-ctx.Done():
+			return nil, fmt.Errorf("debugger was not attached in the allotted time")
+		case das, ok := <-dac:
+			if !ok {
+				return nil, fmt.Errorf("could not read watch channel")
+			}
+			da, err := das.Find(namespace, name)
+			if err != nil {
+				// not written yet
+				continue
+			}
+			da.ConvertDeprecatedFields()
+			switch da.State {
+			case squashv1.DebugAttachment_RequestingDelete, squashv1.DebugAttachment_PendingDelete:
+				return nil, fmt.Errorf("debug attachment %v was deleted before the debugger was attached", name)
+			case squashv1.DebugAttachment_Attached:
+				if da.PortSpec != nil {
+					return da, nil
+				}
+			}
+		}
+	}
+}
+
+// holdSession blocks until squashctl is interrupted, a port-forward stops, or the debug attachment is deleted
+// Processes that are attached or detached in the meantime, as when following processes, are forwarded or released.
+// holdSession closes the forwards when it returns.
+func (s *Squash) holdSession(daClient squashv1.DebugAttachmentClient, da *squashv1.DebugAttachment, forwarded []forwardedProcess) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	type forwardStopped struct {
+		key string
+		err error
+	}
+	fwdDone := make(chan forwardStopped)
+	active := make(map[string]forwardedProcess)
+	track := func(f forwardedProcess) {
+		key := attachedProcessKey(f.process)
+		active[key] = f
+		go func() {
+			select {
+			case err := <-f.fwd.Done():
+				select {
+				case fwdDone <- forwardStopped{key: key, err: err}:
+				case <-ctx.Done():
+				}
+			case <-ctx.Done():
+			}
+		}()
+	}
+	for _, f := range forwarded {
+		track(f)
+	}
+	defer func() {
+		for _, f := range active {
+			f.fwd.Close()
+		}
+	}()
+
+	// forwards the processes that are new on the debug attachment and releases those that are gone
+	syncForwards := func(current *squashv1.DebugAttachment) error {
+		var updated []forwardedProcess
+		wanted := make(map[string]bool)
+		changed := false
+		for _, process := range current.ListAttachedProcesses() {
+			key := attachedProcessKey(process)
+			wanted[key] = true
+			if f, ok := active[key]; ok {
+				updated = append(updated, f)
+				continue
+			}
+			fwd, err := s.forwardDebugPort(current, process, 0)
+			if err != nil {
+				return err
+			}
+			f := forwardedProcess{process: process, fwd: fwd}
+			track(f)
+			updated = append(updated, f)
+			changed = true
+		}
+		for key, f := range active {
+			if !wanted[key] {
+				delete(active, key)
+				f.fwd.Close()
+				changed = true
+			}
+		}
+		if !changed {
+			return nil
+		}
+		return s.printEditorData(updated)
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	namespace, name := da.Metadata.Namespace, da.Metadata.Name
+	dac, errc, err := daClient.Watch(namespace, clients.WatchOpts{Ctx: ctx})
+	if err != nil {
+		return err
+	}
+	for {
+		select {
+		case <-signals:
+			return nil
+		case stopped := <-fwdDone:
+			if _, ok := active[stopped.key]; !ok {
+				// released because its process is no longer attached
+				continue
+			}
+			if da.Intent.GetReattachOnRestart() {
+				// the plank goes away when a target container restarts, the processes it attaches next are forwarded again
+				delete(active, stopped.key)
+				continue
+			}
+			return errors.Wrap(stopped.err, "port-forward stopped")
+		case err := <-errc:
+			return err
+		case das, ok := <-dac:
+			if !ok {
+				return fmt.Errorf("could not read watch channel")
+			}
+			current, err := das.Find(namespace, name)
+			if err != nil {
+				// the debug attachment was deleted
+				return nil
+			}
+			switch current.State {
+			case squashv1.DebugAttachment_RequestingDelete, squashv1.DebugAttachment_PendingDelete:
+				return nil
+			}
+			current.ConvertDeprecatedFields()
+			if err := syncForwards(current); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// attachedProcessKey identifies an attached process and the port its debugger listens on
+func attachedProcessKey(process *squashv1.AttachedProcess) string {
+	return fmt.Sprintf("%v/%v/%v", process.ContainerName, process.Pid, process.GetPortSpec().String())
+}
@@ -0,0 +1,174 @@
+package detect
+
+import (
+	"debug/elf"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/solo-io/squash/pkg/utils"
+)
+
+// ContainerSpec describes what a container runs, as declared in its pod spec
+type ContainerSpec struct {
+	Image string
+	// Command is the container's command followed by its args
+	Command []string
+	Env     map[string]string
+}
+
+// environment variables that the jvm reads extra arguments from
+var javaOptionsEnvVars = []string{"JAVA_TOOL_OPTIONS", "_JAVA_OPTIONS", "JAVA_OPTS"}
+
+// FromContainerSpec guesses the debugger for a container from its image, command, and environment.
+// Returns "" when there is no good guess.
+func FromContainerSpec(spec ContainerSpec) string {
+	// commands are often wrapped in a shell, as in: sh -c "java -agentlib:jdwp=... Main"
+	var tokens []string
+	for _, part := range spec.Command {
+		tokens = append(tokens, strings.Fields(part)...)
+	}
+	if debugger := fromArgs(tokens); debugger != "" {
+		return debugger
+	}
+	for _, envVar := range javaOptionsEnvVars {
+		if hasJdwpArg(strings.Fields(spec.Env[envVar])) {
+			return "java"
+		}
+	}
+	return fromImage(spec.Image)
+}
+
+// FromProcess guesses the debugger for a running process by inspecting its executable and command line
+// Returns "" when there is no good guess.
+func FromProcess(pid int) (string, error) {
+	args, err := utils.GetCmdArgsByPid(pid)
+	if err != nil {
+		return "", err
+	}
+	exe, err := os.Readlink(fmt.Sprintf("/proc/%d/exe", pid))
+	if err != nil {
+		return "", err
+	}
+	if debugger := fromArgs(append([]string{exe}, args...)); debugger != "" {
+		return debugger, nil
+	}
+	return fromExecutable(fmt.Sprintf("/proc/%d/exe", pid))
+}
+
+func fromArgs(args []string) string {
+	for i, arg := range args {
+		switch name := binaryName(arg); {
+		case name == "dlv":
+			return "dlv"
+		case name == "java":
+			// JVMs without a JDWP agent get one loaded on attach
+			return "java"
+		case name == "node" || name == "nodejs":
+			for _, nodeArg := range args[i:] {
+				if strings.HasPrefix(nodeArg, "--debug") {
+					return "nodejs"
+				}
+			}
+			return "nodejs8"
+		case strings.HasPrefix(name, "python"):
+			for _, pyArg := range args[i:] {
+				if strings.Contains(pyArg, "ptvsd") {
+					return "python"
+				}
+			}
+			return ""
+		}
+	}
+	return ""
+}
+
+func fromImage(image string) string {
+	// the repository name, without registry, namespace, tag, or digest
+	repo := image
+	if i := strings.LastIndex(repo, "/"); i >= 0 {
+		repo = repo[i+1:]
+	}
+	if i := strings.IndexAny(repo, ":@"); i >= 0 {
+		repo = repo[:i]
+	}
+	switch {
+	case strings.HasPrefix(repo, "golang"):
+		return "dlv"
+	case strings.HasPrefix(repo, "node"):
+		return "nodejs8"
+	case strings.HasPrefix(repo, "openjdk"):
+		return "java"
+	}
+	return ""
+}
+
+// Go binaries are debugged with dlv. Other native binaries are not guessed: interpreters such as CPython
+// are native binaries too, and gdb would be the wrong debugger for them
+func fromExecutable(path string) (string, error) {
+	f, err := elf.Open(path)
+	if err != nil {
+		// not a native binary
+		return "", nil
+	}
+	defer f.Close()
+	for _, section := range []string{".go.buildinfo", ".gopclntab", ".note.go.buildid"} {
+		if f.Section(section) != nil {
+			return "dlv", nil
+		}
+	}
+	// fall back to the symbols of the go runtime
+	symbols, _ := f.Symbols()
+	for _, symbol := range symbols {
+		if symbol.Name == "runtime.main" {
+			return "dlv", nil
+		}
+	}
+	return "", nil
+}
+
+func binaryName(arg string) string {
+	return filepath.Base(arg)
+}
+
+func hasJdwpArg(args []string) bool {
+	for _, arg := range args {
+		if strings.HasPrefix(arg, "-agentlib:jdwp") || strings.HasPrefix(arg, "-Xrunjdwp") {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseJdwpPort returns the port from a java JDWP agent argument, or 0 if arg does not configure JDWP
+// Examples:
+// -Xrunjdwp:server=y,transport=dt_socket,address=4000,suspend=n
+// -agentlib:jdwp=transport=dt_socket,server=y,address=8000,suspend=n
+// -agentlib:jdwp=transport=dt_socket,server=y,address=*:8000,suspend=n
+func ParseJdwpPort(arg string) (int, error) {
+	if strings.HasPrefix(arg, "-agentlib") || strings.HasPrefix(arg, "-Xrunjdwp") {
+		ss := strings.Split(arg, ",")
+		for _, s := range ss {
+			if strings.HasPrefix(s, "address") {
+				a := strings.Split(s, "=")
+				if len(a) > 1 {
+					// java 9 and later take a host, as in address=*:8000
+					address := a[1]
+					if i := strings.LastIndex(address, ":"); i >= 0 {
+						address = address[i+1:]
+					}
+					port, err := strconv.Atoi(address)
+					if err != nil {
+						return 0, err
+					}
+					// Got the port number
+					return port, nil
+				}
+				break
+			}
+		}
+	}
+	return 0, nil
+}
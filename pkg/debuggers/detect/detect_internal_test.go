@@ -0,0 +1,104 @@
+package detect
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("detect debugger from arguments", func() {
+	It("should map command lines to debuggers", func() {
+		for _, c := range []struct {
+			args     []string
+			debugger string
+		}{
+			{[]string{"/go/bin/dlv", "exec", "/app"}, "dlv"},
+			{[]string{"/usr/bin/java", "-jar", "app.jar"}, "java"},
+			{[]string{"node", "--debug=5858", "server.js"}, "nodejs"},
+			{[]string{"node", "--debug-brk", "server.js"}, "nodejs"},
+			{[]string{"/usr/local/bin/node", "--inspect", "server.js"}, "nodejs8"},
+			{[]string{"nodejs", "server.js"}, "nodejs8"},
+			{[]string{"python", "-m", "ptvsd", "--host", "0.0.0.0", "app.py"}, "python"},
+			{[]string{"/usr/bin/python3.7", "app.py"}, ""},
+			{[]string{"/app/server", "--port", "8080"}, ""},
+			{[]string{"sh", "-c", "exec", "/app/server"}, ""},
+			{nil, ""},
+		} {
+			Expect(fromArgs(c.args)).To(Equal(c.debugger), strings.Join(c.args, " "))
+		}
+	})
+
+	It("should only read the flags of the detected binary", func() {
+		// --debug belongs to the wrapper, not to node
+		Expect(fromArgs([]string{"wrapper", "--debug", "node", "server.js"})).To(Equal("nodejs8"))
+	})
+})
+
+var _ = Describe("detect debugger from executable", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "detect")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	It("should map executables to debuggers", func() {
+		if runtime.GOOS != "linux" {
+			Skip("executables are only inspected as ELF binaries")
+		}
+		// this test binary is a go binary
+		self, err := os.Executable()
+		Expect(err).NotTo(HaveOccurred())
+		script := filepath.Join(dir, "script.sh")
+		Expect(ioutil.WriteFile(script, []byte("#!/bin/sh\necho hello\n"), 0755)).To(Succeed())
+
+		for _, c := range []struct {
+			path     string
+			debugger string
+		}{
+			{self, "dlv"},
+			{script, ""},
+			{filepath.Join(dir, "missing"), ""},
+		} {
+			debugger, err := fromExecutable(c.path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(debugger).To(Equal(c.debugger), c.path)
+		}
+	})
+
+	It("should not guess a debugger for native binaries that are not go", func() {
+		// an interpreter such as CPython is a native binary that gdb would be wrong for
+		native := ""
+		for _, candidate := range []string{"/bin/sh", "/bin/ls", "/usr/bin/env"} {
+			if isNativeNonGo(candidate) {
+				native = candidate
+				break
+			}
+		}
+		if native == "" {
+			Skip("no native binary without the go runtime was found")
+		}
+		debugger, err := fromExecutable(native)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(debugger).To(Equal(""))
+	})
+})
+
+// isNativeNonGo is true for ELF binaries that the go toolchain did not build
+func isNativeNonGo(path string) bool {
+	data, err := ioutil.ReadFile(path)
+	if err != nil || !strings.HasPrefix(string(data), "\x7fELF") {
+		return false
+	}
+	return !strings.Contains(string(data), "runtime.main")
+}
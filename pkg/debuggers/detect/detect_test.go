@@ -0,0 +1,94 @@
+package detect_test
+
+import (
+	"os"
+	"runtime"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/solo-io/squash/pkg/debuggers/detect"
+)
+
+var _ = Describe("detect debugger from container spec", func() {
+	It("should detect java with a JDWP agent", func() {
+		Expect(detect.FromContainerSpec(detect.ContainerSpec{
+			Command: []string{"java", "-agentlib:jdwp=transport=dt_socket,server=y,address=8000,suspend=n", "HelloWorld"},
+		})).To(Equal("java"))
+	})
+
+	It("should look inside shell wrapped commands", func() {
+		Expect(detect.FromContainerSpec(detect.ContainerSpec{
+			Command: []string{"/bin/sh", "-c", "java -Xrunjdwp:server=y,transport=dt_socket,address=4000,suspend=n HelloWorld"},
+		})).To(Equal("java"))
+	})
+
+	It("should detect JDWP agents configured through the environment", func() {
+		Expect(detect.FromContainerSpec(detect.ContainerSpec{
+			Image:   "openjdk:8",
+			Command: []string{"java", "-jar", "app.jar"},
+			Env:     map[string]string{"JAVA_TOOL_OPTIONS": "-agentlib:jdwp=transport=dt_socket,server=y,address=5005"},
+		})).To(Equal("java"))
+	})
+
+	It("should detect java without a debug port, a JDWP agent is loaded on attach", func() {
+		Expect(detect.FromContainerSpec(detect.ContainerSpec{
+			Command: []string{"java", "-jar", "app.jar"},
+		})).To(Equal("java"))
+	})
+
+	It("should detect node inspector and legacy debug protocols", func() {
+		Expect(detect.FromContainerSpec(detect.ContainerSpec{
+			Command: []string{"node", "--inspect=0.0.0.0:9229", "server.js"},
+		})).To(Equal("nodejs8"))
+		Expect(detect.FromContainerSpec(detect.ContainerSpec{
+			Command: []string{"/usr/local/bin/node", "--debug", "server.js"},
+		})).To(Equal("nodejs"))
+	})
+
+	It("should only detect python when ptvsd is in use", func() {
+		Expect(detect.FromContainerSpec(detect.ContainerSpec{
+			Command: []string{"python3", "-m", "ptvsd", "--host", "0.0.0.0", "app.py"},
+		})).To(Equal("python"))
+		Expect(detect.FromContainerSpec(detect.ContainerSpec{
+			Command: []string{"python3", "app.py"},
+		})).To(Equal(""))
+	})
+
+	It("should fall back to the image name", func() {
+		Expect(detect.FromContainerSpec(detect.ContainerSpec{Image: "docker.io/library/golang:1.11"})).To(Equal("dlv"))
+		Expect(detect.FromContainerSpec(detect.ContainerSpec{Image: "openjdk:11-jre-slim"})).To(Equal("java"))
+		Expect(detect.FromContainerSpec(detect.ContainerSpec{Image: "soloio/example-service1:v0.1"})).To(Equal(""))
+	})
+})
+
+var _ = Describe("detect debugger from process", func() {
+	It("should detect a go binary", func() {
+		if runtime.GOOS != "linux" {
+			Skip("process inspection requires /proc")
+		}
+		// this test binary is a go binary
+		debugger, err := detect.FromProcess(os.Getpid())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(debugger).To(Equal("dlv"))
+	})
+})
+
+var _ = Describe("parse JDWP port", func() {
+	It("should parse the port of JDWP agent arguments", func() {
+		port, err := detect.ParseJdwpPort("-agentlib:jdwp=transport=dt_socket,server=y,address=8000,suspend=n")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(port).To(Equal(8000))
+	})
+
+	It("should parse the port of addresses with a host", func() {
+		port, err := detect.ParseJdwpPort("-agentlib:jdwp=transport=dt_socket,server=y,address=*:5005,suspend=n")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(port).To(Equal(5005))
+	})
+
+	It("should ignore other arguments", func() {
+		port, err := detect.ParseJdwpPort("-Xmx512m")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(port).To(Equal(0))
+	})
+})
@@ -0,0 +1,22 @@
+package local
+
+import (
+	"os/exec"
+)
+
+// DebugpyInterface connects to the debugpy listener that plank injects into the target
+type DebugpyInterface struct{}
+
+// GetDebugCmd returns nil: debugpy has no terminal client, it is debugged from an editor that speaks the debug adapter protocol
+func (d *DebugpyInterface) GetDebugCmd(localPort int) *exec.Cmd {
+	return nil
+}
+
+// ExpectRunningPlank is false, the listener runs in the target and outlives plank
+func (d *DebugpyInterface) ExpectRunningPlank() bool {
+	return false
+}
+
+func (d *DebugpyInterface) WindowsSupportWarning() string {
+	return ""
+}
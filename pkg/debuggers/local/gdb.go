@@ -8,15 +8,6 @@ import (
 
 type GdbInterface struct{}
 
-func (g *GdbInterface) GetRemoteConnectionCmd(plankName, plankNamespace, podName, podNamespace string, localPort, remotePort int) *exec.Cmd {
-	// proxy through the debug container
-	return GetPortForwardCmd(plankName, plankNamespace, localPort, remotePort)
-}
-
-func (g *GdbInterface) GetEditorRemoteConnectionCmd(plankName, plankNamespace, podName, podNamespace string, remotePort int) string {
-	return getPortForwardWithRandomLocalCmd(plankName, plankNamespace, remotePort)
-}
-
 func (d *GdbInterface) GetDebugCmd(localPort int) *exec.Cmd {
 	fmt.Printf("gdb debug port available on local port %v.\n", localPort)
 	// TODO(mitchdraft) - do this in a less hacky way
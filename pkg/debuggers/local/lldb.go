@@ -0,0 +1,25 @@
+package local
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+type LldbInterface struct{}
+
+func (d *LldbInterface) GetDebugCmd(localPort int) *exec.Cmd {
+	cmd := exec.Command("lldb", "-o", fmt.Sprintf("gdb-remote 127.0.0.1:%v", localPort))
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Stdin = os.Stdin
+	return cmd
+}
+
+func (d *LldbInterface) ExpectRunningPlank() bool {
+	return true
+}
+
+func (l *LldbInterface) WindowsSupportWarning() string {
+	return ""
+}
@@ -6,33 +6,40 @@ import (
 	"os/exec"
 )
 
+// NodeJsDebugger connects to the legacy V8 debugger protocol of node versions before 8
 type NodeJsDebugger struct{}
 
-func (g *NodeJsDebugger) GetRemoteConnectionCmd(plankName, plankNamespace, podName, podNamespace string, localPort, remotePort int) *exec.Cmd {
-	// TODO
-	return GetPortForwardCmd(podName, podNamespace, localPort, remotePort)
+func (d *NodeJsDebugger) GetDebugCmd(localPort int) *exec.Cmd {
+	cmd := exec.Command("node", "debug", fmt.Sprintf("127.0.0.1:%v", localPort))
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Stdin = os.Stdin
+	return cmd
 }
 
-func (n *NodeJsDebugger) GetEditorRemoteConnectionCmd(plankName, plankNamespace, podName, podNamespace string, remotePort int) string {
-	return getPortForwardWithRandomLocalCmd(podName, podNamespace, remotePort)
+func (d *NodeJsDebugger) ExpectRunningPlank() bool {
+	return false
 }
 
-func (d *NodeJsDebugger) GetDebugCmd(localPort int) *exec.Cmd {
-	// TODO - will it work?
-	fmt.Printf("Node debug port available on local port %v.\n", localPort)
-	// TODO(mitchdraft) - do this in a less hacky way
-	cmd := exec.Command("sleep", "200000")
+func (n *NodeJsDebugger) WindowsSupportWarning() string {
+	return ""
+}
+
+// NodeJs8Debugger connects to the inspector protocol of node 8 and later
+type NodeJs8Debugger struct{}
+
+func (d *NodeJs8Debugger) GetDebugCmd(localPort int) *exec.Cmd {
+	cmd := exec.Command("node", "inspect", fmt.Sprintf("127.0.0.1:%v", localPort))
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
 	cmd.Stdin = os.Stdin
 	return cmd
 }
 
-func (d *NodeJsDebugger) ExpectRunningPlank() bool {
-	// TODO
+func (d *NodeJs8Debugger) ExpectRunningPlank() bool {
 	return false
 }
 
-func (n *NodeJsDebugger) WindowsSupportWarning() string {
+func (n *NodeJs8Debugger) WindowsSupportWarning() string {
 	return ""
 }
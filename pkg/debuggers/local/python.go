@@ -4,24 +4,15 @@ import (
 	"os/exec"
 )
 
+// PythonInterface connects to ptvsd, which services enable with ptvsd.enable_attach
 type PythonInterface struct{}
 
-func (i *PythonInterface) GetRemoteConnectionCmd(plankName, plankNamespace, podName, podNamespace string, localPort, remotePort int) *exec.Cmd {
-	// TODO
-	return GetPortForwardCmd(podName, podNamespace, localPort, remotePort)
-}
-
-func (p *PythonInterface) GetEditorRemoteConnectionCmd(plankName, plankNamespace, podName, podNamespace string, remotePort int) string {
-	return getPortForwardWithRandomLocalCmd(podName, podNamespace, remotePort)
-}
-
+// GetDebugCmd returns nil: ptvsd has no terminal client, it is debugged from an editor that speaks the debug adapter protocol
 func (d *PythonInterface) GetDebugCmd(localPort int) *exec.Cmd {
-	// TODO
 	return nil
 }
 
 func (d *PythonInterface) ExpectRunningPlank() bool {
-	// TODO
 	return false
 }
 
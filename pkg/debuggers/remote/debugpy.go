@@ -0,0 +1,143 @@
+package remote
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DebugpyPort is the first port tried for the debugpy listener in the target's network namespace
+	DebugpyPort = 5678
+	// DebugpyPackageDir holds the debugpy package in the plank image
+	DebugpyPackageDir = "/debugpy"
+	// DebugpyTargetDir is where debugpy is copied to in the target's file system
+	DebugpyTargetDir = "/tmp/squash-debugpy"
+)
+
+// DebugpyInterface injects the debugpy listener into a running CPython process, the way `debugpy --pid` does.
+// The target needs no debug code of its own.
+type DebugpyInterface struct{}
+
+type debugpyDebugServer struct {
+	port int
+}
+
+// Detach leaves the listener running: debugpy cannot be unloaded, a later session reuses it
+func (d *debugpyDebugServer) Detach() error {
+	return nil
+}
+
+func (d *debugpyDebugServer) Port() int {
+	return d.port
+}
+
+func (d *debugpyDebugServer) HostType() DebugHostType {
+	return DebugHostTypeTarget
+}
+
+// Done is nil, there is no debugger process to wait for
+func (d *debugpyDebugServer) Done() <-chan struct{} {
+	return nil
+}
+
+func (d *debugpyDebugServer) ExitError() error {
+	return nil
+}
+
+func (i *DebugpyInterface) Attach(pid int) (DebugServer, error) {
+
+	log.WithField("pid", pid).Debug("AttachToLiveSession called")
+	if err := copyDebugpy(pid); err != nil {
+		return nil, err
+	}
+	port, err := freePort(pid, DebugpyPort)
+	if err != nil {
+		return nil, err
+	}
+	port, err = injectDebugpy(pid, port)
+	if err != nil {
+		log.WithFields(log.Fields{"pid": pid, "err": err}).Error("can't inject debugpy")
+		return nil, err
+	}
+	if err := waitForListener(pid, port, "debugpy"); err != nil {
+		return nil, err
+	}
+
+	log.WithFields(log.Fields{"pid": pid, "port": port}).Debug("debugpy is listening")
+	return &debugpyDebugServer{port: port}, nil
+}
+
+// copyDebugpy makes debugpy importable by the target, whose file system is only reachable through /proc
+func copyDebugpy(pid int) error {
+	return copyPackage(pid, DebugpyPackageDir, DebugpyTargetDir, "debugpy")
+}
+
+// copyPackage copies the directory src of the plank image, which holds the python package name, to dir in the
+// file system of pid, unless an earlier session already did
+func copyPackage(pid int, src, dir, name string) error {
+	dst := targetPath(pid, dir)
+	if _, err := os.Stat(filepath.Join(dst, name)); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		return errors.Wrapf(err, "creating %v in the file system of pid %v", dir, pid)
+	}
+	out, err := exec.Command("cp", "-r", src+"/.", dst).CombinedOutput()
+	if err != nil {
+		return errors.Wrapf(err, "copying %v to pid %v: %s", name, pid, out)
+	}
+	return nil
+}
+
+// injectDebugpy runs debugpy.listen in the target with gdb, and returns the port that debugpy listens on.
+// A target that was injected by an earlier session keeps its listener, so its port is returned instead.
+func injectDebugpy(pid, port int) (int, error) {
+	portFile := filepath.Join(DebugpyTargetDir, fmt.Sprintf("port-%v", pid))
+	// the target only writes the port file once debugpy listens, one left by an earlier session would pass for it
+	if err := os.Remove(targetPath(pid, portFile)); err != nil && !os.IsNotExist(err) {
+		return 0, errors.Wrap(err, "removing the debugpy port file")
+	}
+
+	out, err := exec.Command("gdb", debugpyGdbArgs(pid, port, portFile)...).CombinedOutput()
+	log.WithFields(log.Fields{"pid": pid, "output": string(out)}).Debug("gdb injected debugpy")
+	if err != nil {
+		return 0, errors.Wrapf(err, "running gdb: %s", out)
+	}
+
+	data, err := ioutil.ReadFile(targetPath(pid, portFile))
+	if os.IsNotExist(err) {
+		// PyRun_SimpleString prints the python exception to the target's stderr
+		return 0, errors.Errorf("debugpy.listen failed in pid %v, see the logs of the target process. gdb output: %s", pid, out)
+	}
+	if err != nil {
+		return 0, errors.Wrap(err, "reading the debugpy port")
+	}
+	return strconv.Atoi(strings.TrimSpace(string(data)))
+}
+
+// debugpyGdbArgs has gdb run the python code that starts debugpy, and writes its port to portFile, in pid
+func debugpyGdbArgs(pid, port int, portFile string) []string {
+	code := strings.Join([]string{
+		"import sys",
+		fmt.Sprintf("'%[1]v' in sys.path or sys.path.insert(0, '%[1]v')", DebugpyTargetDir),
+		"import debugpy",
+		fmt.Sprintf("sys._squash_debugpy = getattr(sys, '_squash_debugpy', None) or debugpy.listen(('127.0.0.1', %v))", port),
+		fmt.Sprintf("open('%v', 'w').write(str(sys._squash_debugpy[1]))", portFile),
+	}, "; ")
+
+	return []string{"-batch", "-nx",
+		"-iex", "set sysroot " + targetPath(pid, "/"),
+		"-p", strconv.Itoa(pid),
+		"-ex", "call (int)PyGILState_Ensure()",
+		"-ex", fmt.Sprintf(`call (int)PyRun_SimpleString("%v")`, code),
+		"-ex", "call (void)PyGILState_Release($1)",
+	}
+}
@@ -0,0 +1,81 @@
+package remote
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("debugpy", func() {
+	It("should have gdb start debugpy in the target's interpreter", func() {
+		Expect(debugpyGdbArgs(42, 5679, "/tmp/squash-debugpy/port-42")).To(Equal([]string{
+			"-batch", "-nx",
+			"-iex", "set sysroot /proc/42/root",
+			"-p", "42",
+			"-ex", "call (int)PyGILState_Ensure()",
+			"-ex", `call (int)PyRun_SimpleString("import sys; ` +
+				`'/tmp/squash-debugpy' in sys.path or sys.path.insert(0, '/tmp/squash-debugpy'); ` +
+				`import debugpy; ` +
+				`sys._squash_debugpy = getattr(sys, '_squash_debugpy', None) or debugpy.listen(('127.0.0.1', 5679)); ` +
+				`open('/tmp/squash-debugpy/port-42', 'w').write(str(sys._squash_debugpy[1]))")`,
+			"-ex", "call (void)PyGILState_Release($1)",
+		}))
+	})
+
+	Describe("copyPackage", func() {
+		var src, dir string
+
+		BeforeEach(func() {
+			var err error
+			image, err := ioutil.TempDir("", "plank")
+			Expect(err).NotTo(HaveOccurred())
+			// pip installs the package into a directory of its own
+			src = filepath.Join(image, "debugpy")
+			Expect(os.MkdirAll(filepath.Join(src, "debugpy", "server"), 0755)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(src, "debugpy", "__init__.py"), []byte("# debugpy\n"), 0644)).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(src, "debugpy", "server", "api.py"), []byte("# api\n"), 0644)).To(Succeed())
+
+			target, err := ioutil.TempDir("", "target")
+			Expect(err).NotTo(HaveOccurred())
+			dir = filepath.Join(target, "squash-debugpy")
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(filepath.Dir(src))
+			os.RemoveAll(filepath.Dir(dir))
+		})
+
+		It("should copy the package into the file system of the target", func() {
+			// this process sees its own file system through /proc/<pid>/root
+			pid := os.Getpid()
+			Expect(targetPath(pid, dir)).To(Equal(filepath.Join("/proc", strconv.Itoa(pid), "root", dir)))
+			Expect(copyPackage(pid, src, dir, "debugpy")).To(Succeed())
+
+			data, err := ioutil.ReadFile(filepath.Join(dir, "debugpy", "server", "api.py"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(data)).To(Equal("# api\n"))
+			_, err = os.Stat(filepath.Join(dir, "debugpy", "__init__.py"))
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should leave a package that an earlier session copied", func() {
+			pid := os.Getpid()
+			Expect(copyPackage(pid, src, dir, "debugpy")).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(dir, "debugpy", "__init__.py"), []byte("# in use\n"), 0644)).To(Succeed())
+
+			Expect(copyPackage(pid, src, dir, "debugpy")).To(Succeed())
+			data, err := ioutil.ReadFile(filepath.Join(dir, "debugpy", "__init__.py"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(data)).To(Equal("# in use\n"))
+		})
+
+		It("should report a package that the plank image lacks", func() {
+			err := copyPackage(os.Getpid(), filepath.Join(filepath.Dir(src), "missing"), dir, "debugpy")
+			Expect(err).To(MatchError(ContainSubstring("copying debugpy to pid")))
+		})
+	})
+})
@@ -14,16 +14,16 @@ type DLV struct {
 }
 
 type DLVLiveDebugSession struct {
-	client  *rpc1.RPCClient
-	port    int
-	process *os.Process
-	cmd     *exec.Cmd
+	*debuggerProcess
+	client *rpc1.RPCClient
+	port   int
 }
 
 func (d *DLVLiveDebugSession) Detach() error {
-	d.client.Detach(false)
-	d.process.Kill()
-	return nil
+	err := d.client.Detach(false)
+	// dlv may already have exited after detaching, killing it only makes sure it releases the target
+	d.cmd.Process.Kill()
+	return err
 }
 
 func (d *DLVLiveDebugSession) Port() int {
@@ -34,23 +34,18 @@ func (d *DLVLiveDebugSession) HostType() DebugHostType {
 	return DebugHostTypeClient
 }
 
-func (d *DLVLiveDebugSession) Cmd() *exec.Cmd {
-	return d.cmd
-}
-
 func (d *DLV) attachTo(pid int) (*DLVLiveDebugSession, error) {
-	cmd, port, err := d.startDebugServer(pid)
+	process, port, err := d.startDebugServer(pid)
 	if err != nil {
 		return nil, err
 	}
 	// use rpc1 client for vscode extension support
 	client := rpc1.NewClient(fmt.Sprintf("localhost:%d", port))
 	dls := &DLVLiveDebugSession{
-		client:  client,
-		port:    port,
-		process: cmd.Process,
-		// store the cmd so we can Wait() for its compltion in the proxy
-		cmd: cmd,
+		// the proxy waits for its completion
+		debuggerProcess: process,
+		client:          client,
+		port:            port,
 	}
 	return dls, nil
 }
@@ -59,7 +54,7 @@ func (d *DLV) Attach(pid int) (DebugServer, error) {
 	return d.attachTo(pid)
 }
 
-func (d *DLV) startDebugServer(pid int) (*exec.Cmd, int, error) {
+func (d *DLV) startDebugServer(pid int) (*debuggerProcess, int, error) {
 
 	log.WithField("pid", pid).Debug("StartDebugServer called")
 	cmd := exec.Command("dlv", "attach", fmt.Sprintf("%d", pid), "--listen=127.0.0.1:0", "--accept-multiclient=true", "--api-version=2", "--headless", "--log")
@@ -67,7 +62,7 @@ func (d *DLV) startDebugServer(pid int) (*exec.Cmd, int, error) {
 	cmd.Stderr = os.Stderr
 	log.WithFields(log.Fields{"cmd": cmd, "args": cmd.Args}).Debug("dlv command")
 
-	err := cmd.Start()
+	process, err := startDebuggerProcess(cmd)
 	if err != nil {
 		log.WithField("err", err).Error("Failed to start dlv")
 		return nil, 0, err
@@ -75,13 +70,12 @@ func (d *DLV) startDebugServer(pid int) (*exec.Cmd, int, error) {
 
 	log.Debug("starting headless dlv for user started, trying to get port")
 	time.Sleep(2 * time.Second)
-	port, err := GetPort(cmd.Process.Pid)
+	port, err := GetPort(process.pid())
 	if err != nil {
 		log.WithField("err", err).Error("can't get headless dlv port")
-		cmd.Process.Kill()
-		cmd.Process.Release()
-		return cmd, 0, err
+		process.kill()
+		return nil, 0, err
 	}
 
-	return cmd, port, nil
+	return process, port, nil
 }
@@ -13,13 +13,12 @@ import (
 type GdbInterface struct{}
 
 type gdbDebugServer struct {
+	*debuggerProcess
 	port int
-	cmd  *exec.Cmd
 }
 
 func (g *gdbDebugServer) Detach() error {
-	g.cmd.Process.Signal(syscall.SIGINT)
-	return nil
+	return g.cmd.Process.Signal(syscall.SIGINT)
 }
 
 func (g *gdbDebugServer) Port() int {
@@ -30,30 +29,27 @@ func (g *gdbDebugServer) HostType() DebugHostType {
 	return DebugHostTypeClient
 }
 
-func (g *gdbDebugServer) Cmd() *exec.Cmd {
-	return g.cmd
-}
-
 func (g *GdbInterface) Attach(pid int) (DebugServer, error) {
 
 	log.WithField("pid", pid).Debug("AttachToLiveSession called")
 	cmd := exec.Command("gdbserver", "--attach", ":0", fmt.Sprintf("%d", pid))
-	cmd.Start()
+	process, err := startDebuggerProcess(cmd)
+	if err != nil {
+		log.WithField("err", err).Error("can't start gdbserver")
+		return nil, err
+	}
 	log.Debug("starting gdbserver for user started, trying to get port")
 	time.Sleep(time.Second)
-	port, err := GetPort(cmd.Process.Pid)
+	port, err := GetPort(process.pid())
 	if err != nil {
 		log.WithField("err", err).Error("can't get gdbserver port")
-		cmd.Process.Kill()
-		cmd.Process.Release()
+		process.kill()
 		return nil, err
 	}
 
-	// be polite and wait
-	go cmd.Wait()
 	gds := &gdbDebugServer{
-		port: port,
-		cmd:  cmd,
+		debuggerProcess: process,
+		port:            port,
 	}
 	return gds, nil
 }
@@ -1,9 +1,5 @@
 package remote
 
-import (
-	"os/exec"
-)
-
 // DebugHostType - type of host to connect debugger
 type DebugHostType int
 
@@ -21,8 +17,17 @@ type DebugServer interface {
 	HostType() DebugHostType
 	/// Return the port that the debug server listens on.
 	Port() int
-	// Return the cmd representing the debugger process, if any
-	Cmd() *exec.Cmd
+	// Done is closed once the debugger process exits. It is nil if plank did not start a debugger process,
+	// as with debuggers that run in the target
+	Done() <-chan struct{}
+	// ExitError returns the error that the debugger process exited with, once Done is closed
+	ExitError() error
+}
+
+// DebuggerUrlProvider is implemented by the debug servers that clients reach over a websocket
+type DebuggerUrlProvider interface {
+	// DebuggerUrl returns the websocket url of the debugger, with the host that it listens on
+	DebuggerUrl() string
 }
 
 /// Debugger interface. implement this to add a new debugger support to squash.
@@ -1,8 +1,6 @@
 package remote
 
 import (
-	"os/exec"
-
 	log "github.com/sirupsen/logrus"
 )
 
@@ -24,7 +22,12 @@ func (g *javaDebugServer) HostType() DebugHostType {
 	return DebugHostTypeTarget
 }
 
-func (d *javaDebugServer) Cmd() *exec.Cmd {
+// Done is nil, there is no debugger process to wait for
+func (d *javaDebugServer) Done() <-chan struct{} {
+	return nil
+}
+
+func (d *javaDebugServer) ExitError() error {
 	return nil
 }
 
@@ -33,8 +36,16 @@ func (g *JavaInterface) Attach(pid int) (DebugServer, error) {
 	log.WithField("pid", pid).Debug("AttachToLiveSession called")
 	port, err := GetPortOfJavaProcess(pid)
 	if err != nil {
-		log.WithField("err", err).Error("can't get java debug port")
-		return nil, err
+		// the JVM was started without a JDWP agent, load one into it
+		log.WithFields(log.Fields{"pid": pid, "err": err}).Info("no JDWP agent on the command line, loading one")
+		port, err = loadJdwpAgent(pid)
+		if err != nil {
+			log.WithField("err", err).Error("can't load a JDWP agent")
+			return nil, err
+		}
+		if err := waitForListener(pid, port, "JDWP agent"); err != nil {
+			return nil, err
+		}
 	}
 
 	gds := &javaDebugServer{
@@ -0,0 +1,201 @@
+package remote
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/solo-io/squash/pkg/utils/socket"
+)
+
+const (
+	// JdwpPort is the first port tried for a dynamically loaded JDWP agent in the target's network namespace
+	JdwpPort = 5005
+
+	// the attach listener of a HotSpot JVM starts when it finds this file and receives SIGQUIT
+	jvmAttachFilePrefix = ".attach_pid"
+	jvmSocketPrefix     = ".java_pid"
+	// records the port of an agent that an earlier session loaded, the agent cannot be loaded twice
+	jdwpPortFilePrefix = ".squash_jdwp_"
+
+	jvmAttachTimeout = 10 * time.Second
+)
+
+var jvmReturnCode = regexp.MustCompile(`(-?\d+)\s*$`)
+
+// loadJdwpAgent loads the JDWP agent into a running HotSpot JVM with the attach API, as jcmd and jattach do,
+// and returns the port that the agent listens on
+func loadJdwpAgent(pid int) (int, error) {
+	if !isHotSpotJvm(pid) {
+		return 0, errors.Errorf("pid %v is not a HotSpot JVM, it cannot load a JDWP agent", pid)
+	}
+	nspid, err := namespacePid(pid)
+	if err != nil {
+		return 0, err
+	}
+	portFile := targetPath(pid, filepath.Join("/tmp", fmt.Sprintf("%v%v", jdwpPortFilePrefix, nspid)))
+	if data, err := ioutil.ReadFile(portFile); err == nil {
+		if port, err := strconv.Atoi(strings.TrimSpace(string(data))); err == nil {
+			log.WithFields(log.Fields{"pid": pid, "port": port}).Debug("JDWP agent was loaded by an earlier session")
+			return port, nil
+		}
+	}
+
+	port, err := freePort(pid, JdwpPort)
+	if err != nil {
+		return 0, err
+	}
+	conn, err := attachJvm(pid, nspid)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	// only reachable through the port forward to the target pod, not from the rest of the cluster
+	options := fmt.Sprintf("transport=dt_socket,server=y,suspend=n,address=127.0.0.1:%v", port)
+	if err := jvmCommand(conn, "load", "jdwp", "false", options); err != nil {
+		// the JDWP agent of HotSpot has no Agent_OnAttach, the JVM refuses to load it after startup
+		return 0, errors.Wrapf(err, "pid %v can't load the JDWP agent while it runs, start it with "+
+			"-agentlib:jdwp=transport=dt_socket,server=y,suspend=n,address=%v to debug it", pid, JdwpPort)
+	}
+	if err := ioutil.WriteFile(portFile, []byte(strconv.Itoa(port)), 0644); err != nil {
+		log.WithFields(log.Fields{"pid": pid, "err": err}).Warn("can't record the JDWP port")
+	}
+	return port, nil
+}
+
+// attachJvm connects to the attach listener of the JVM, and starts the listener if it is not running yet
+func attachJvm(pid, nspid int) (net.Conn, error) {
+	info, err := os.Stat(fmt.Sprintf("/proc/%v", pid))
+	if err != nil {
+		return nil, err
+	}
+	owner := info.Sys().(*syscall.Stat_t)
+	uid, gid := int(owner.Uid), int(owner.Gid)
+
+	// the JVM resolves /tmp in its own mount namespace, which plank reaches through /proc
+	socketPath := targetPath(pid, filepath.Join("/tmp", fmt.Sprintf("%v%v", jvmSocketPrefix, nspid)))
+	if _, err := os.Stat(socketPath); err != nil {
+		if err := startAttachListener(pid, nspid, uid, gid, socketPath); err != nil {
+			return nil, err
+		}
+	}
+	// the JVM only accepts peers with its own credentials
+	conn, err := socket.DialUnixAs(socketPath, uid, gid)
+	if err != nil {
+		return nil, errors.Wrapf(err, "connecting to the attach listener of pid %v", pid)
+	}
+	return conn, nil
+}
+
+func startAttachListener(pid, nspid, uid, gid int, socketPath string) error {
+	attachFileName := fmt.Sprintf("%v%v", jvmAttachFilePrefix, nspid)
+	// the JVM looks in its working directory first, then in /tmp
+	attachFile := filepath.Join("/proc", strconv.Itoa(pid), "cwd", attachFileName)
+	if err := ioutil.WriteFile(attachFile, nil, 0600); err != nil {
+		attachFile = targetPath(pid, filepath.Join("/tmp", attachFileName))
+		if err := ioutil.WriteFile(attachFile, nil, 0600); err != nil {
+			return errors.Wrapf(err, "creating the attach file of pid %v", pid)
+		}
+	}
+	defer os.Remove(attachFile)
+	// the JVM ignores attach files that other users created
+	if err := os.Chown(attachFile, uid, gid); err != nil {
+		return errors.Wrapf(err, "handing the attach file to the owner of pid %v", pid)
+	}
+
+	if err := syscall.Kill(pid, syscall.SIGQUIT); err != nil {
+		return errors.Wrapf(err, "signaling pid %v", pid)
+	}
+	deadline := time.Now().Add(jvmAttachTimeout)
+	for {
+		if _, err := os.Stat(socketPath); err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return errors.Errorf("the attach listener of pid %v did not start, is it started with -XX:+DisableAttachMechanism?", pid)
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+}
+
+// jvmCommand sends a command to the attach listener, which answers with a return code followed by the command's output
+func jvmCommand(conn net.Conn, command string, args ...string) error {
+	// the protocol version, the command, and always three arguments, each terminated by a null byte
+	request := []string{"1", command}
+	for i := 0; i < 3; i++ {
+		arg := ""
+		if i < len(args) {
+			arg = args[i]
+		}
+		request = append(request, arg)
+	}
+	if _, err := conn.Write([]byte(strings.Join(request, "\x00") + "\x00")); err != nil {
+		return err
+	}
+
+	// the JVM closes the connection after its response
+	response, err := ioutil.ReadAll(conn)
+	if err != nil {
+		return err
+	}
+	reader := bufio.NewReader(strings.NewReader(string(response)))
+	codeLine, _ := reader.ReadString('\n')
+	if code := strings.TrimSpace(codeLine); code != "0" {
+		return errors.Errorf("attach listener returned %v: %s", code, response)
+	}
+	// load reports the result of the agent's initialization, as "0" or as "return code: 0"
+	output, _ := ioutil.ReadAll(reader)
+	if match := jvmReturnCode.FindSubmatch(output); match != nil && string(match[1]) != "0" {
+		return errors.Errorf("agent failed to start: %s", strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// namespacePid returns the pid of a process in its own pid namespace, which names its attach files
+func namespacePid(pid int) (int, error) {
+	status, err := ioutil.ReadFile(fmt.Sprintf("/proc/%v/status", pid))
+	if err != nil {
+		return 0, err
+	}
+	return parseNamespacePid(string(status), pid)
+}
+
+// parseNamespacePid reads the innermost pid from the NSpid line of /proc/<pid>/status
+func parseNamespacePid(status string, pid int) (int, error) {
+	for _, line := range strings.Split(status, "\n") {
+		if !strings.HasPrefix(line, "NSpid:") {
+			continue
+		}
+		// the pid in each nested namespace, the innermost last
+		fields := strings.Fields(strings.TrimPrefix(line, "NSpid:"))
+		if len(fields) > 0 {
+			return strconv.Atoi(fields[len(fields)-1])
+		}
+	}
+	// kernels before 4.1 do not report it
+	return pid, nil
+}
+
+// isHotSpotJvm protects other processes from SIGQUIT, which only starts the attach listener of a JVM
+func isHotSpotJvm(pid int) bool {
+	maps, err := ioutil.ReadFile(fmt.Sprintf("/proc/%v/maps", pid))
+	if err != nil {
+		return false
+	}
+	return mapsLibjvm(string(maps))
+}
+
+// mapsLibjvm reports whether /proc/<pid>/maps has the HotSpot VM library mapped
+func mapsLibjvm(maps string) bool {
+	return strings.Contains(maps, "/libjvm.so")
+}
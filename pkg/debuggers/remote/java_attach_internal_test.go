@@ -0,0 +1,128 @@
+package remote
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("java attach", func() {
+	Describe("jvmCommand", func() {
+		// attachListener answers one command on a pipe like the JVM does, and returns the request it read
+		attachListener := func(response string) (net.Conn, <-chan string) {
+			client, server := net.Pipe()
+			requests := make(chan string, 1)
+			go func() {
+				defer GinkgoRecover()
+				defer server.Close()
+				// the protocol version, the command and three arguments
+				var request []byte
+				buf := make([]byte, 1)
+				for bytes.Count(request, []byte{0}) < 5 {
+					if _, err := io.ReadFull(server, buf); err != nil {
+						requests <- string(request)
+						return
+					}
+					request = append(request, buf[0])
+				}
+				requests <- string(request)
+				server.Write([]byte(response))
+			}()
+			return client, requests
+		}
+
+		It("should send the command with three arguments", func() {
+			conn, requests := attachListener("0\n")
+			defer conn.Close()
+			Expect(jvmCommand(conn, "load", "jdwp", "false")).To(Succeed())
+			Expect(<-requests).To(Equal("1\x00load\x00jdwp\x00false\x00\x00"))
+		})
+
+		It("should read the return code and the result of the agent", func() {
+			cases := []struct {
+				description string
+				response    string
+				fails       bool
+			}{
+				{description: "command without output", response: "0\n"},
+				{description: "agent started, as java 8 reports it", response: "0\n0\n"},
+				{description: "agent started, as java 9 and later report it", response: "0\nreturn code: 0\n"},
+				{description: "agent without Agent_OnAttach, as java 8 reports it", response: "-1\n", fails: true},
+				{description: "agent failed to start, as java 9 and later report it", response: "0\nreturn code: -1\n", fails: true},
+				{description: "agent failed to start", response: "0\n100\n", fails: true},
+				{description: "unknown command", response: "101\n", fails: true},
+				{description: "no response", response: "", fails: true},
+			}
+			for _, c := range cases {
+				conn, _ := attachListener(c.response)
+				err := jvmCommand(conn, "load", "jdwp", "false", "transport=dt_socket")
+				conn.Close()
+				if c.fails {
+					Expect(err).To(HaveOccurred(), c.description)
+				} else {
+					Expect(err).NotTo(HaveOccurred(), c.description)
+				}
+			}
+		})
+	})
+
+	Describe("parseNamespacePid", func() {
+		It("should take the pid of the innermost namespace", func() {
+			cases := []struct {
+				description string
+				status      string
+				pid         int
+			}{
+				{description: "nested pid namespace", status: "Name:\tjava\nTgid:\t4321\nPid:\t4321\nNSpid:\t4321\t1\n", pid: 1},
+				{description: "twice nested pid namespace", status: "Name:\tjava\nNSpid:\t4321\t87\t7\n", pid: 7},
+				{description: "host pid namespace", status: "Name:\tjava\nNSpid:\t4321\n", pid: 4321},
+				{description: "kernel without NSpid", status: "Name:\tjava\nPid:\t4321\n", pid: 4321},
+			}
+			for _, c := range cases {
+				pid, err := parseNamespacePid(c.status, 4321)
+				Expect(err).NotTo(HaveOccurred(), c.description)
+				Expect(pid).To(Equal(c.pid), c.description)
+			}
+		})
+
+		It("should find the pid of a process in its own namespace", func() {
+			Expect(namespacePid(os.Getpid())).To(Equal(os.Getpid()))
+		})
+	})
+
+	Describe("isHotSpotJvm", func() {
+		It("should look for the HotSpot library in the mapped files", func() {
+			cases := []struct {
+				description string
+				maps        string
+				hotspot     bool
+			}{
+				{
+					description: "HotSpot JVM",
+					maps: "7f1c2a000000-7f1c2b000000 r-xp 00000000 08:01 1234 /usr/lib/jvm/java-11-openjdk-amd64/lib/server/libjvm.so\n" +
+						"7f1c2c000000-7f1c2c100000 r-xp 00000000 08:01 1235 /usr/lib/jvm/java-11-openjdk-amd64/lib/libjava.so\n",
+					hotspot: true,
+				},
+				{
+					description: "OpenJ9 JVM",
+					maps:        "7f1c2a000000-7f1c2b000000 r-xp 00000000 08:01 1234 /opt/java/openjdk/lib/default/libj9vm29.so\n",
+				},
+				{
+					description: "python",
+					maps:        "55d0c1a00000-55d0c1c00000 r-xp 00000000 08:01 99 /usr/bin/python3.8\n",
+				},
+			}
+			for _, c := range cases {
+				Expect(mapsLibjvm(c.maps)).To(Equal(c.hotspot), c.description)
+			}
+		})
+
+		It("should not signal a process that isn't a JVM", func() {
+			Expect(isHotSpotJvm(os.Getpid())).To(BeFalse())
+		})
+	})
+})
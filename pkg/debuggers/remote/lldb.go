@@ -0,0 +1,54 @@
+package remote
+
+import (
+	"fmt"
+	"os/exec"
+	"syscall"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// LldbInterface serves native targets, such as C, C++ and Rust programs, with lldb-server
+type LldbInterface struct{}
+
+type lldbDebugServer struct {
+	*debuggerProcess
+	port int
+}
+
+func (l *lldbDebugServer) Detach() error {
+	return l.cmd.Process.Signal(syscall.SIGINT)
+}
+
+func (l *lldbDebugServer) Port() int {
+	return l.port
+}
+
+func (l *lldbDebugServer) HostType() DebugHostType {
+	return DebugHostTypeClient
+}
+
+func (l *LldbInterface) Attach(pid int) (DebugServer, error) {
+
+	log.WithField("pid", pid).Debug("AttachToLiveSession called")
+	// port 0 lets lldb-server pick a free port, it is found by the sockets of the server
+	cmd := exec.Command("lldb-server", "gdbserver", "127.0.0.1:0", "--attach", fmt.Sprintf("%d", pid))
+	process, err := startDebuggerProcess(cmd)
+	if err != nil {
+		log.WithField("err", err).Error("can't start lldb-server")
+		return nil, err
+	}
+	log.Debug("starting lldb-server for user started, trying to get port")
+	port, err := waitForPort(process, "lldb-server")
+	if err != nil {
+		log.WithField("err", err).Error("can't get lldb-server port")
+		process.kill()
+		return nil, err
+	}
+
+	lds := &lldbDebugServer{
+		debuggerProcess: process,
+		port:            port,
+	}
+	return lds, nil
+}
@@ -0,0 +1,20 @@
+package remote
+
+import (
+	"os/exec"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("lldb", func() {
+	It("should interrupt lldb-server on detach, and report when it can't", func() {
+		process, err := startDebuggerProcess(exec.Command("sleep", "30"))
+		Expect(err).NotTo(HaveOccurred())
+		server := &lldbDebugServer{debuggerProcess: process}
+
+		Expect(server.Detach()).To(Succeed())
+		Eventually(server.Done(), "5s").Should(BeClosed())
+		Expect(server.Detach()).To(HaveOccurred())
+	})
+})
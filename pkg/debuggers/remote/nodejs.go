@@ -1,28 +1,51 @@
 package remote
 
 import (
-	"os/exec"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
 	"syscall"
+	"time"
 
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
+	"github.com/solo-io/squash/pkg/utils"
+	"github.com/solo-io/squash/pkg/utils/socket"
 )
 
 const (
 	DebuggerPort  = 5858
 	InspectorPort = 9229
+
+	nodeListenTimeout = 10 * time.Second
+	nodeDialTimeout   = 5 * time.Second
 )
 
-type NodeJsDebugger struct{}
+// NodeJsDebugger activates the debugger of a running node process with SIGUSR1
+type NodeJsDebugger struct {
+	// the port that node opens without an --inspect-port override
+	defaultPort int
+}
 
 type nodejsDebugServer struct {
 	port int
+	// forwards to a debugger that only listens on the loopback of the target, nil if squashctl can reach it directly
+	proxy *targetProxy
+	url   string
 }
 
-func NewNodeDebugger(p int) *nodejsDebugServer {
-	return &nodejsDebugServer{port: p}
+func NewNodeDebugger(p int) *NodeJsDebugger {
+	return &NodeJsDebugger{defaultPort: p}
 }
 
 func (g *nodejsDebugServer) Detach() error {
+	if g.proxy != nil {
+		return g.proxy.Close()
+	}
 	return nil
 }
 
@@ -31,20 +54,150 @@ func (g *nodejsDebugServer) Port() int {
 }
 
 func (g *nodejsDebugServer) HostType() DebugHostType {
+	if g.proxy != nil {
+		return DebugHostTypeClient
+	}
 	return DebugHostTypeTarget
 }
 
-func (d *nodejsDebugServer) Cmd() *exec.Cmd {
+// Done is nil, there is no debugger process to wait for
+func (d *nodejsDebugServer) Done() <-chan struct{} {
 	return nil
 }
 
-func (g *nodejsDebugServer) Attach(pid int) (DebugServer, error) {
+func (d *nodejsDebugServer) ExitError() error {
+	return nil
+}
+
+func (g *nodejsDebugServer) DebuggerUrl() string {
+	return g.url
+}
+
+func (g *NodeJsDebugger) Attach(pid int) (DebugServer, error) {
 
 	log.WithField("pid", pid).Debug("AttachToLiveSession called")
-	err := syscall.Kill(pid, syscall.SIGUSR1)
+	port := g.defaultPort
+	if args, err := utils.GetCmdArgsByPid(pid); err == nil {
+		if override := parseNodeDebugPort(args); override != 0 {
+			port = override
+		}
+	}
+	before, err := socket.GetListeningPortsFor(pid)
+	if err != nil {
+		return nil, err
+	}
+
+	err = syscall.Kill(pid, syscall.SIGUSR1)
 	if err != nil {
 		log.WithField("err", err).Error("can't send SIGUSR1 to the process")
 		return nil, err
 	}
-	return g, nil
+	address, err := waitForNodeListener(pid, port, before)
+	if err != nil {
+		return nil, err
+	}
+	log.WithFields(log.Fields{"pid": pid, "address": address}).Debug("node debugger is listening")
+
+	ds := &nodejsDebugServer{port: address.Port}
+	if address.IP.IsLoopback() {
+		proxy, err := startTargetProxy(pid, address.String())
+		if err != nil {
+			return nil, err
+		}
+		ds.proxy = proxy
+		ds.port = proxy.Port()
+	}
+	if g.defaultPort == InspectorPort {
+		url, err := inspectorUrl(pid, address)
+		if err != nil {
+			// the debugger works without it, only clients that need the url are affected
+			log.WithFields(log.Fields{"pid": pid, "err": err}).Warn("can't read the inspector's websocket url")
+		}
+		ds.url = url
+	}
+	return ds, nil
+}
+
+// parseNodeDebugPort finds the debugger port that node was started with, in flags such as
+// --inspect-port=9230, --inspect=0.0.0.0:9230, --inspect-brk=9230 or --debug-port=5859. It returns 0 if there is none.
+func parseNodeDebugPort(args []string) int {
+	for i, arg := range args {
+		name, value := arg, ""
+		if idx := strings.Index(arg, "="); idx >= 0 {
+			name, value = arg[:idx], arg[idx+1:]
+		} else if (arg == "--inspect-port" || arg == "--debug-port") && i+1 < len(args) {
+			value = args[i+1]
+		}
+		switch name {
+		case "--inspect-port", "--debug-port", "--inspect", "--inspect-brk", "--debug", "--debug-brk":
+		default:
+			continue
+		}
+		if idx := strings.LastIndex(value, ":"); idx >= 0 {
+			value = value[idx+1:]
+		}
+		if port, err := strconv.Atoi(value); err == nil {
+			return port
+		}
+	}
+	return 0
+}
+
+// waitForNodeListener waits for node to open its debugger after SIGUSR1. It prefers the expected port,
+// and otherwise accepts a single port that node was not listening on before the signal.
+func waitForNodeListener(pid, port int, before []int) (*net.TCPAddr, error) {
+	deadline := time.Now().Add(nodeListenTimeout)
+	for {
+		addresses, err := socket.GetListeningAddressesFor(pid)
+		if err != nil {
+			return nil, err
+		}
+		var opened []*net.TCPAddr
+		for _, address := range addresses {
+			if address.Port == port {
+				return address, nil
+			}
+			if !containsPort(before, address.Port) {
+				opened = append(opened, address)
+			}
+		}
+		if len(opened) == 1 {
+			return opened[0], nil
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("node did not open its debugger on port %v after SIGUSR1, it listens on %v", port, addresses)
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+}
+
+// inspectorUrl asks the inspector for the websocket url of its debug target
+func inspectorUrl(pid int, address *net.TCPAddr) (string, error) {
+	host := address.IP.String()
+	if address.IP.IsUnspecified() {
+		host = "127.0.0.1"
+	}
+	client := &http.Client{
+		Timeout: nodeDialTimeout,
+		Transport: &http.Transport{
+			DialContext: func(_ context.Context, _, addr string) (net.Conn, error) {
+				return socket.DialInNetworkOf(pid, addr, nodeDialTimeout)
+			},
+		},
+	}
+	resp, err := client.Get(fmt.Sprintf("http://%v/json/list", net.JoinHostPort(host, strconv.Itoa(address.Port))))
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	var targets []struct {
+		WebSocketDebuggerUrl string `json:"webSocketDebuggerUrl"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&targets); err != nil {
+		return "", errors.Wrap(err, "reading the inspector's targets")
+	}
+	if len(targets) == 0 {
+		return "", errors.New("the inspector has no targets")
+	}
+	return targets[0].WebSocketDebuggerUrl, nil
 }
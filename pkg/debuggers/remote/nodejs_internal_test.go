@@ -0,0 +1,31 @@
+package remote
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("nodejs", func() {
+	It("should find the debug port in node's arguments", func() {
+		cases := []struct {
+			description string
+			args        []string
+			port        int
+		}{
+			{description: "--inspect with a host and port", args: []string{"node", "--inspect=0.0.0.0:9230", "app.js"}, port: 9230},
+			{description: "--inspect with a port", args: []string{"node", "--inspect=9231", "app.js"}, port: 9231},
+			{description: "--inspect-brk with a host and port", args: []string{"node", "--inspect-brk=127.0.0.1:9232", "app.js"}, port: 9232},
+			{description: "--inspect-port", args: []string{"node", "--inspect-port=9233", "app.js"}, port: 9233},
+			{description: "--debug-port with a separate value", args: []string{"node", "--debug-port", "9234", "app.js"}, port: 9234},
+			{description: "--debug-port with a host", args: []string{"node", "--debug-port=[::1]:9235", "app.js"}, port: 9235},
+			{description: "legacy --debug", args: []string{"node", "--debug=5858", "app.js"}, port: 5858},
+			{description: "bare --inspect uses node's default", args: []string{"node", "--inspect", "app.js"}, port: 0},
+			{description: "bare --inspect-brk uses node's default", args: []string{"node", "--inspect-brk", "app.js"}, port: 0},
+			{description: "--debug-port without a value", args: []string{"node", "--debug-port"}, port: 0},
+			{description: "no debug flags", args: []string{"node", "app.js", "--port=8080"}, port: 0},
+		}
+		for _, c := range cases {
+			Expect(parseNodeDebugPort(c.args)).To(Equal(c.port), c.description)
+		}
+	})
+})
@@ -0,0 +1,50 @@
+package remote
+
+import (
+	"os/exec"
+)
+
+// debuggerProcess is a debug server process that plank started. It is the only waiter of the process,
+// since an exec.Cmd can only be waited for once, others wait for Done instead.
+type debuggerProcess struct {
+	cmd  *exec.Cmd
+	done chan struct{}
+	err  error
+}
+
+// startDebuggerProcess starts cmd, and waits for it in the background
+func startDebuggerProcess(cmd *exec.Cmd) (*debuggerProcess, error) {
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	p := &debuggerProcess{
+		cmd:  cmd,
+		done: make(chan struct{}),
+	}
+	go func() {
+		p.err = cmd.Wait()
+		close(p.done)
+	}()
+	return p, nil
+}
+
+// Done is closed once the process has exited
+func (p *debuggerProcess) Done() <-chan struct{} {
+	return p.done
+}
+
+// ExitError returns the error that the process exited with, it blocks until the process has exited
+func (p *debuggerProcess) ExitError() error {
+	<-p.done
+	return p.err
+}
+
+func (p *debuggerProcess) pid() int {
+	return p.cmd.Process.Pid
+}
+
+// kill ends the process, and returns once it has exited
+func (p *debuggerProcess) kill() {
+	p.cmd.Process.Kill()
+	<-p.done
+}
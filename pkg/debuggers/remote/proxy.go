@@ -0,0 +1,76 @@
+package remote
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/solo-io/squash/pkg/utils/socket"
+)
+
+const (
+	// ProxyListenHost is where plank accepts connections for debuggers that only listen on the loopback of their target
+	ProxyListenHost = "127.0.0.1"
+
+	proxyDialTimeout = 5 * time.Second
+)
+
+// targetProxy makes a debugger that only listens on the loopback of its target reachable through plank
+type targetProxy struct {
+	listener net.Listener
+	pid      int
+	address  string
+}
+
+func startTargetProxy(pid int, address string) (*targetProxy, error) {
+	l, err := net.Listen("tcp", fmt.Sprintf("%v:0", ProxyListenHost))
+	if err != nil {
+		return nil, err
+	}
+	p := &targetProxy{listener: l, pid: pid, address: address}
+	go p.serve()
+	return p, nil
+}
+
+func (p *targetProxy) Port() int {
+	return p.listener.Addr().(*net.TCPAddr).Port
+}
+
+// Close stops accepting connections, connections that were accepted stay open until either side closes them
+func (p *targetProxy) Close() error {
+	return p.listener.Close()
+}
+
+func (p *targetProxy) serve() {
+	for {
+		conn, err := p.listener.Accept()
+		if err != nil {
+			return
+		}
+		go p.forward(conn)
+	}
+}
+
+func (p *targetProxy) forward(conn net.Conn) {
+	defer conn.Close()
+	target, err := socket.DialInNetworkOf(p.pid, p.address, proxyDialTimeout)
+	if err != nil {
+		log.WithFields(log.Fields{"pid": p.pid, "address": p.address, "err": err}).Error("can't connect to the debugger")
+		return
+	}
+	defer target.Close()
+
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(target, conn)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(conn, target)
+		done <- struct{}{}
+	}()
+	// either side closing ends the connection
+	<-done
+}
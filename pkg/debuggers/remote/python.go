@@ -6,17 +6,17 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"regexp"
-	"strings"
 
 	log "github.com/sirupsen/logrus"
 )
 
 const (
-	PtvsdPortEnvVariable  = "PTVSD_PORT_NUMBER"
-	PtvsdSearchString     = `ptvsd\.enable_attach.*`
+	PtvsdPortEnvVariable = "PTVSD_PORT_NUMBER"
+	PtvsdSearchString    = `ptvsd\.enable_attach.*`
+	// PtvsdAddressPattern matches the address tuple passed to enable_attach, with or without a secret
+	PtvsdAddressPattern   = `\(\s*['"][^'"]*['"]\s*,\s*(\d+)\s*\)`
 	PtvsdMaxFileSize      = 1024 * 1024
 	PtvsdMaxNumberOfFiles = 1000
 )
@@ -39,7 +39,12 @@ func (d *ptvsdDebugServer) HostType() DebugHostType {
 	return DebugHostTypeTarget
 }
 
-func (d *ptvsdDebugServer) Cmd() *exec.Cmd {
+// Done is nil, there is no debugger process to wait for
+func (d *ptvsdDebugServer) Done() <-chan struct{} {
+	return nil
+}
+
+func (d *ptvsdDebugServer) ExitError() error {
 	return nil
 }
 
@@ -61,6 +66,7 @@ func (i *PythonInterface) Attach(pid int) (DebugServer, error) {
 
 // Search /proc/{PID}/cwd for file with "ptvsd.enable_attach" string and extracts port from it
 // Example of config: ptvsd.enable_attach("my_secret", address = ('0.0.0.0', 3000)), returns 3000
+// and so does ptvsd.enable_attach(address=('0.0.0.0', 3000))
 func getPtvsdPort(pid int) (int, error) {
 	port := 0
 	fileNum := 0
@@ -74,6 +80,7 @@ func getPtvsdPort(pid int) (int, error) {
 	root := filepath.Join("/proc", fmt.Sprintf("%d", pid), "cwd")
 	log.WithField("root", root).Debug("searching root")
 	re := regexp.MustCompile(PtvsdSearchString)
+	addressRe := regexp.MustCompile(PtvsdAddressPattern)
 
 	werr := filepath.Walk(root+"/", func(p string, fi os.FileInfo, err error) error {
 		if err != nil || fi == nil || fi.IsDir() {
@@ -105,9 +112,8 @@ func getPtvsdPort(pid int) (int, error) {
 		if err != nil {
 			return err
 		}
-		args := strings.Split(string(b), ",")
-		if len(args) > 2 {
-			_, err = fmt.Sscanf(args[2], "%d", &port)
+		if match := addressRe.FindSubmatch(b); match != nil {
+			_, err = fmt.Sscanf(string(match[1]), "%d", &port)
 			if err != nil {
 				return err
 			}
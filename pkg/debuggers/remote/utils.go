@@ -3,10 +3,12 @@ package remote
 import (
 	"errors"
 	"fmt"
+	"path/filepath"
 	"strconv"
-	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
+	"github.com/solo-io/squash/pkg/debuggers/detect"
 	"github.com/solo-io/squash/pkg/utils"
 	"github.com/solo-io/squash/pkg/utils/socket"
 )
@@ -37,6 +39,83 @@ func GetPort(pid int) (int, error) {
 	return port, nil
 }
 
+// listenTimeout bounds how long a debugger that was started in the target may take to listen, tests shorten it
+var listenTimeout = 10 * time.Second
+
+// how often to check whether a debugger listens
+const listenPollInterval = 200 * time.Millisecond
+
+// freePort returns the first port from first that nothing listens on in the network namespace of pid
+func freePort(pid, first int) (int, error) {
+	ports, err := socket.GetListeningPortsInNetworkOf(pid)
+	if err != nil {
+		return 0, fmt.Errorf("listing the ports in use by pid %v: %v", pid, err)
+	}
+	port := first
+	for containsPort(ports, port) {
+		port++
+	}
+	return port, nil
+}
+
+// waitForListener blocks until the port is listened on in the network namespace of pid
+func waitForListener(pid, port int, debugger string) error {
+	deadline := time.Now().Add(listenTimeout)
+	for {
+		ports, err := socket.GetListeningPortsInNetworkOf(pid)
+		if err != nil {
+			return err
+		}
+		if containsPort(ports, port) {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%v did not listen on port %v of pid %v", debugger, port, pid)
+		}
+		time.Sleep(listenPollInterval)
+	}
+}
+
+// waitForPort returns the port that the debugger process listens on, once it does.
+// It gives up when the process exits, or when it does not listen within listenTimeout.
+func waitForPort(process *debuggerProcess, debugger string) (int, error) {
+	deadline := time.Now().Add(listenTimeout)
+	for {
+		ports, err := socket.GetListeningPortsFor(process.pid())
+		if err == nil && len(ports) > 0 {
+			return GetPort(process.pid())
+		}
+		if time.Now().After(deadline) {
+			if err != nil {
+				return 0, fmt.Errorf("%v did not listen within %v: %v", debugger, listenTimeout, err)
+			}
+			return 0, fmt.Errorf("%v did not listen within %v", debugger, listenTimeout)
+		}
+		select {
+		case <-process.Done():
+			if err := process.ExitError(); err != nil {
+				return 0, fmt.Errorf("%v exited before it listened: %v", debugger, err)
+			}
+			return 0, fmt.Errorf("%v exited before it listened", debugger)
+		case <-time.After(listenPollInterval):
+		}
+	}
+}
+
+// targetPath resolves a path in the file system of pid
+func targetPath(pid int, path string) string {
+	return filepath.Join("/proc", strconv.Itoa(pid), "root", path)
+}
+
+func containsPort(ports []int, port int) bool {
+	for _, p := range ports {
+		if p == port {
+			return true
+		}
+	}
+	return false
+}
+
 func GetPortOfJavaProcess(pid int) (int, error) {
 
 	args, err := utils.GetCmdArgsByPid(pid)
@@ -51,7 +130,7 @@ func GetPortOfJavaProcess(pid int) (int, error) {
 	// /bin/sh java -agentlib:jdwp=transport=dt_socket,server=y,address=8000,suspend=n HelloWorld
 	port := 0
 	for _, arg := range args {
-		port, err = checkAndParseArgument(arg)
+		port, err = detect.ParseJdwpPort(arg)
 		if err != nil {
 			log.WithFields(log.Fields{"pid": pid, "err": err, "arg": arg}).Error("Can't get command line arguments")
 			break
@@ -67,38 +146,21 @@ func GetPortOfJavaProcess(pid int) (int, error) {
 	return port, err
 }
 
-func checkAndParseArgument(arg string) (int, error) {
-	if strings.HasPrefix(arg, "-agentlib") || strings.HasPrefix(arg, "-Xrunjdwp") {
-		ss := strings.Split(arg, ",")
-		for _, s := range ss {
-			if strings.HasPrefix(s, "address") {
-				a := strings.Split(s, "=")
-				if len(a) > 1 {
-					port, err := strconv.Atoi(a[1])
-					if err != nil {
-						return 0, err
-					}
-					// Got the port number
-					return port, nil
-				}
-				break
-			}
-		}
-	}
-	return 0, nil
-}
-
 func GetParticularDebugger(dbgtype string) Remote {
 	var g GdbInterface
 	var d DLV
 	var j JavaInterface
 	var p PythonInterface
+	var dp DebugpyInterface
+	var l LldbInterface
 
 	switch dbgtype {
 	case "dlv":
 		return &d
 	case "gdb":
 		return &g
+	case "lldb":
+		return &l
 	case "java":
 		return &j
 	case "java-port":
@@ -109,6 +171,8 @@ func GetParticularDebugger(dbgtype string) Remote {
 		return NewNodeDebugger(InspectorPort)
 	case "python":
 		return &p
+	case "debugpy":
+		return &dp
 	default:
 		return nil
 	}
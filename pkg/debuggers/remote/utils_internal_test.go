@@ -0,0 +1,53 @@
+package remote
+
+import (
+	"os/exec"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("waitForPort", func() {
+	var timeout time.Duration
+
+	BeforeEach(func() {
+		timeout = listenTimeout
+	})
+
+	AfterEach(func() {
+		listenTimeout = timeout
+	})
+
+	start := func(command string) *debuggerProcess {
+		process, err := startDebuggerProcess(exec.Command("sh", "-c", command))
+		Expect(err).NotTo(HaveOccurred())
+		return process
+	}
+
+	It("should give up when the debugger exits before it listens", func() {
+		cases := []struct {
+			description string
+			command     string
+			err         string
+		}{
+			{description: "debugger failed", command: "exit 3", err: "lldb-server exited before it listened: exit status 3"},
+			{description: "debugger exited cleanly", command: "exit 0", err: "lldb-server exited before it listened"},
+		}
+		for _, c := range cases {
+			_, err := waitForPort(start(c.command), "lldb-server")
+			Expect(err).To(MatchError(c.err), c.description)
+		}
+	})
+
+	It("should give up when the debugger does not listen in time", func() {
+		listenTimeout = 300 * time.Millisecond
+		process := start("sleep 30")
+		defer process.kill()
+
+		began := time.Now()
+		_, err := waitForPort(process, "lldb-server")
+		Expect(err).To(MatchError(HavePrefix("lldb-server did not listen within 300ms")))
+		Expect(time.Since(began)).To(BeNumerically("<", 5*time.Second))
+	})
+})
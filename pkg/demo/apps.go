@@ -2,6 +2,7 @@ package demo
 
 import (
 	"fmt"
+	"io"
 
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
@@ -25,7 +26,8 @@ var (
 	DemoIds    = []string{DemoGoGo, DemoGoJava}
 )
 
-func DeployTemplate(cs *kubernetes.Clientset, namespace, appName, templateName, appType string, containerPort int) error {
+// DeployTemplate deploys a demo app and its service, progress is written to out
+func DeployTemplate(cs *kubernetes.Clientset, out io.Writer, namespace, appName, templateName, appType string, containerPort int) error {
 
 	service2url := ""
 	if appType == DemoGoJava {
@@ -100,15 +102,15 @@ func DeployTemplate(cs *kubernetes.Clientset, namespace, appName, templateName,
 	if err != nil {
 		return err
 	}
-	fmt.Printf("Deployed demo app %v in namespace %v\n", appName, namespace)
+	fmt.Fprintf(out, "Deployed demo app %v in namespace %v\n", appName, namespace)
 
 	return nil
 }
 
 func int32Ptr(i int32) *int32 { return &i }
 
-func DeployGoGo(cs *kubernetes.Clientset, namespace, namespace2 string) error {
-	fmt.Println("deploying go-go sample microservice")
+func DeployGoGo(cs *kubernetes.Clientset, out io.Writer, namespace, namespace2 string) error {
+	fmt.Fprintln(out, "deploying go-go sample microservice")
 	app1Name := GoApp1Name
 	template1Name := GoTemplate1Name
 
@@ -117,17 +119,17 @@ func DeployGoGo(cs *kubernetes.Clientset, namespace, namespace2 string) error {
 
 	containerPort := 8080
 
-	if err := DeployTemplate(cs, namespace, app1Name, template1Name, DemoGoGo, containerPort); err != nil {
+	if err := DeployTemplate(cs, out, namespace, app1Name, template1Name, DemoGoGo, containerPort); err != nil {
 		return err
 	}
-	if err := DeployTemplate(cs, namespace2, app2Name, template2Name, DemoGoGo, containerPort); err != nil {
+	if err := DeployTemplate(cs, out, namespace2, app2Name, template2Name, DemoGoGo, containerPort); err != nil {
 		return err
 	}
 	return nil
 }
 
-func DeployGoJava(cs *kubernetes.Clientset, namespace, namespace2 string) error {
-	fmt.Println("deploying go-java sample microservice")
+func DeployGoJava(cs *kubernetes.Clientset, out io.Writer, namespace, namespace2 string) error {
+	fmt.Fprintln(out, "deploying go-java sample microservice")
 
 	app1Name := GoApp1Name
 	template1Name := GoTemplate1Name
@@ -137,10 +139,10 @@ func DeployGoJava(cs *kubernetes.Clientset, namespace, namespace2 string) error
 
 	containerPort := 8080
 
-	if err := DeployTemplate(cs, namespace, app1Name, template1Name, DemoGoJava, containerPort); err != nil {
+	if err := DeployTemplate(cs, out, namespace, app1Name, template1Name, DemoGoJava, containerPort); err != nil {
 		return err
 	}
-	if err := DeployTemplate(cs, namespace2, app2Name, template2Name, DemoGoJava, containerPort); err != nil {
+	if err := DeployTemplate(cs, out, namespace2, app2Name, template2Name, DemoGoJava, containerPort); err != nil {
 		return err
 	}
 	return nil
@@ -2,8 +2,11 @@ package install
 
 import (
 	"fmt"
+	"io"
 
+	"github.com/solo-io/squash/pkg/config"
 	sqOpts "github.com/solo-io/squash/pkg/options"
+	squashkube "github.com/solo-io/squash/pkg/platforms/kubernetes"
 	"gopkg.in/yaml.v2"
 	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
@@ -14,17 +17,18 @@ import (
 
 var (
 	ContainerPort = 1234
-	volumeName    = "crisock"
 )
 
 // InstallSquash creates the resources needed for Squash to run in secure mode
-// If preview is set, it prints the configuration and does not apply it.
+// Progress is written to out. If preview is set, the configuration is written to out instead of being applied.
 // The created resources include:
 // ServiceAccount - for Squash
 // ClusterRole - enabling pod creation
 // ClusterRoleBinding - bind ClusterRole to Squash's ServiceAccount
 // Deployment - Squash itself
-func InstallSquash(cs *kubernetes.Clientset, namespace, containerRepo, containerVersion string, preview bool) error {
+// If criSocket is empty, Squash and its planks discover the CRI socket on each node.
+// Squash merges plankTemplate into the plank pods that it creates.
+func InstallSquash(cs *kubernetes.Clientset, out io.Writer, namespace, containerRepo, containerVersion, criSocket string, plankTemplate config.PlankPodTemplate, preview bool) error {
 
 	sa := v1.ServiceAccount{
 		ObjectMeta: metav1.ObjectMeta{
@@ -45,6 +49,25 @@ func InstallSquash(cs *kubernetes.Clientset, namespace, containerRepo, container
 				Resources: []string{"pods"},
 				APIGroups: []string{""},
 			},
+			{
+				// for the ephemeral plank backend
+				Verbs:     []string{"patch"},
+				Resources: []string{"pods/ephemeralcontainers"},
+				APIGroups: []string{""},
+			},
+			{
+				// ephemeral planks authenticate as a service account of their own, which squash creates with a role
+				// that only reaches the plank's debug attachment. Kubernetes only lets squash grant access it has itself.
+				Verbs:     []string{"create", "delete"},
+				Resources: []string{"roles", "rolebindings"},
+				APIGroups: []string{"rbac.authorization.k8s.io"},
+			},
+			{
+				// the service account of an ephemeral plank and its token
+				Verbs:     []string{"create", "delete"},
+				Resources: []string{"serviceaccounts", "secrets"},
+				APIGroups: []string{""},
+			},
 			{
 				Verbs:     []string{"list"},
 				Resources: []string{"namespaces"},
@@ -95,6 +118,11 @@ func InstallSquash(cs *kubernetes.Clientset, namespace, containerRepo, container
 		},
 	}
 
+	volumes, volumeMounts, criEnv := squashkube.CRISocketVolumes(criSocket)
+	templateEnv, err := config.PlankPodTemplateEnv(plankTemplate)
+	if err != nil {
+		return err
+	}
 	privileged := true
 	deployment := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
@@ -119,14 +147,9 @@ func InstallSquash(cs *kubernetes.Clientset, namespace, containerRepo, container
 					ServiceAccountName: sqOpts.SquashServiceAccountName,
 					Containers: []v1.Container{
 						{
-							Name:  sqOpts.SquashPodName,
-							Image: fmt.Sprintf("%v/%v:%v", containerRepo, sqOpts.SquashPodName, containerVersion),
-							VolumeMounts: []v1.VolumeMount{
-								{
-									Name:      volumeName,
-									MountPath: "/var/run/cri.sock",
-								},
-							},
+							Name:         sqOpts.SquashPodName,
+							Image:        fmt.Sprintf("%v/%v:%v", containerRepo, sqOpts.SquashPodName, containerVersion),
+							VolumeMounts: volumeMounts,
 							SecurityContext: &v1.SecurityContext{
 								Privileged: &privileged,
 							},
@@ -137,7 +160,7 @@ func InstallSquash(cs *kubernetes.Clientset, namespace, containerRepo, container
 									ContainerPort: int32(ContainerPort),
 								},
 							},
-							Env: []v1.EnvVar{
+							Env: append([]v1.EnvVar{
 								{
 									Name: "POD_NAME",
 									ValueFrom: &v1.EnvVarSource{
@@ -170,19 +193,10 @@ func InstallSquash(cs *kubernetes.Clientset, namespace, containerRepo, container
 										},
 									},
 								},
-							},
-						},
-					},
-					Volumes: []v1.Volume{
-						{
-							Name: volumeName,
-							VolumeSource: v1.VolumeSource{
-								HostPath: &v1.HostPathVolumeSource{
-									Path: "/var/run/dockershim.sock",
-								},
-							},
+							}, append(criEnv, templateEnv...)...),
 						},
 					},
+					Volumes: volumes,
 				},
 			},
 		},
@@ -192,74 +206,74 @@ func InstallSquash(cs *kubernetes.Clientset, namespace, containerRepo, container
 		// TODO - also include permissions etc
 		// TODO - use k8s printer to avoid printing null values
 		// TODO - produce valid yaml for `kubectl apply -f`
-		if err := simplePrinter(crb); err != nil {
+		if err := simplePrinter(out, crb); err != nil {
 			return err
 		}
-		if err := simplePrinter(deployment); err != nil {
+		if err := simplePrinter(out, deployment); err != nil {
 			return err
 		}
 		return nil
 	}
 
 	// create the resources
-	fmt.Printf("Creating namespace %v\n", namespace)
-	_, err := cs.CoreV1().Namespaces().Create(&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}})
+	fmt.Fprintf(out, "Creating namespace %v\n", namespace)
+	_, err = cs.CoreV1().Namespaces().Create(&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}})
 	if err != nil {
-		fmt.Println(err)
+		fmt.Fprintln(out, err)
 	}
 
-	fmt.Printf("Creating service account %v\n", sqOpts.SquashServiceAccountName)
+	fmt.Fprintf(out, "Creating service account %v\n", sqOpts.SquashServiceAccountName)
 	_, err = cs.CoreV1().ServiceAccounts(namespace).Create(&sa)
 	if err != nil {
-		fmt.Println(err)
+		fmt.Fprintln(out, err)
 	}
 
-	fmt.Printf("Creating clusterRole %v\n", sqOpts.SquashClusterRoleName)
+	fmt.Fprintf(out, "Creating clusterRole %v\n", sqOpts.SquashClusterRoleName)
 	_, err = cs.Rbac().ClusterRoles().Create(cr)
 	if err != nil {
-		fmt.Println(err)
+		fmt.Fprintln(out, err)
 	}
 
-	fmt.Printf("Creating clusterRoleBinding %v\n", sqOpts.SquashClusterRoleBindingName)
+	fmt.Fprintf(out, "Creating clusterRoleBinding %v\n", sqOpts.SquashClusterRoleBindingName)
 	_, err = cs.Rbac().ClusterRoleBindings().Create(crb)
 	if err != nil {
-		fmt.Println(err)
+		fmt.Fprintln(out, err)
 	}
 
-	fmt.Println("Creating Squash deployment")
+	fmt.Fprintln(out, "Creating Squash deployment")
 	_, err = cs.AppsV1().Deployments(namespace).Create(deployment)
 	if err != nil {
-		cleanupDeployment(cs, namespace)
+		cleanupDeployment(cs, out, namespace)
 		return err
 	}
 	return nil
 }
 
-func simplePrinter(val interface{}) error {
+func simplePrinter(out io.Writer, val interface{}) error {
 	yml, err := yaml.Marshal(val)
 	if err != nil {
 		return err
 	}
-	fmt.Println(string(yml))
+	fmt.Fprintln(out, string(yml))
 	return nil
 }
 
-func cleanupDeployment(cs *kubernetes.Clientset, namespace string) {
+func cleanupDeployment(cs *kubernetes.Clientset, out io.Writer, namespace string) {
 	delOp := &metav1.DeleteOptions{}
 
 	if err := cs.CoreV1().ServiceAccounts(namespace).Delete(sqOpts.SquashServiceAccountName, delOp); err != nil {
-		fmt.Println(err)
+		fmt.Fprintln(out, err)
 	}
 
 	if err := cs.Rbac().ClusterRoles().Delete(sqOpts.SquashClusterRoleName, delOp); err != nil {
-		fmt.Println(err)
+		fmt.Fprintln(out, err)
 	}
 
 	if err := cs.Rbac().ClusterRoleBindings().Delete(sqOpts.SquashClusterRoleBindingName, delOp); err != nil {
-		fmt.Println(err)
+		fmt.Fprintln(out, err)
 	}
 
 	if err := cs.AppsV1().Deployments(namespace).Delete(sqOpts.SquashPodName, delOp); err != nil {
-		fmt.Println(err)
+		fmt.Fprintln(out, err)
 	}
 }
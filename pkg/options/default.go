@@ -2,6 +2,7 @@ package options
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/solo-io/solo-kit/pkg/api/v1/resources/core"
 )
@@ -25,9 +26,7 @@ var (
 	SquashLabelSelectorValue = PlankContainerName
 	PlankLabelSelectorString = fmt.Sprintf("%v=%v", SquashLabelSelectorKey, SquashLabelSelectorValue)
 
-	// TODO(mitchdraft) - enable these debuggers
-	// AvailableDebuggers = []string{"dlv", "gdb", "java", "java-port", "nodejs", "nodejs8", "python"}
-	AvailableDebuggers = []string{"dlv", "java", "java-port", "gdb"}
+	AvailableDebuggers = []string{"dlv", "java", "java-port", "gdb", "lldb", "nodejs", "nodejs8", "python", "debugpy"}
 
 	SquashPodName   = "squash"
 	SquashNamespace = "squash-debugger"
@@ -43,10 +42,49 @@ var (
 	PlankServiceAccountName     = "squash-plank"
 	PlankClusterRoleName        = "squash-plank-cr"
 	PlankClusterRoleBindingName = "squash-plank-crb"
+	// prefix of the service account, token and role that let an ephemeral plank manage its own debug attachment
+	EphemeralPlankRoleName = "squash-ephemeral-plank"
 
 	PlankEnvDebugAttachmentNamespace = "SQUASH_DEBUG_ATTACHMENT_NAMESPACE"
 	PlankEnvDebugAttachmentName      = "SQUASH_DEBUG_ATTACHMENT_NAME"
 	PlankEnvDebugSquashNamespace     = "SQUASH_DEBUG_SQUASH_NAMESPACE"
+	// Set when plank runs as an ephemeral container of the target pod, names that container
+	PlankEnvEphemeralContainer = "SQUASH_PLANK_EPHEMERAL_CONTAINER"
+	// Set for ephemeral planks, the token of the service account they authenticate as
+	PlankEnvToken = "SQUASH_PLANK_TOKEN"
+
+	// Plank backends, they select how plank is run
+	// PlankBackendPod runs plank in a privileged pod of its own, on the target's node
+	PlankBackendPod = "pod"
+	// PlankBackendEphemeral injects plank into the target pod as an ephemeral container
+	PlankBackendEphemeral = "ephemeral"
+
+	// Plank pods run with these set only detect the debugger for a target container, rather than attaching one
+	PlankEnvProbeTargetNamespace = "SQUASH_PROBE_TARGET_NAMESPACE"
+	PlankEnvProbeTargetPod       = "SQUASH_PROBE_TARGET_POD"
+	PlankEnvProbeTargetContainer = "SQUASH_PROBE_TARGET_CONTAINER"
+	PlankEnvProbeProcessMatcher  = "SQUASH_PROBE_PROCESS_MATCHER"
+	// Identifies plank pods that are probing for a debugger
+	PlankProbeLabelKey = "squash_probe"
+
+	// Debug attachments created for a match request carry this label, its value is the name of the match request
+	MatchRequestLabelKey = "squash_match_request"
+	// Records when a match request became active, pods created earlier are not debugged
+	MatchRequestSinceAnnotation = "squash.solo.io/match-request-since"
+	// Records the UIDs of the pods that a match request created debug attachments for, comma separated.
+	// Attachments that the user deleted are not created again when squash restarts
+	MatchRequestMatchedPodsAnnotation = "squash.solo.io/match-request-matched-pods"
+	// Records when a debug attachment was created, in RFC3339. Solo-kit metadata does not carry a creation timestamp
+	CreatedAtAnnotation = "squash.solo.io/created-at"
+
+	// Path of the CRI socket on the node, set on squash and plank pods when the socket is configured rather than discovered
+	EnvCRISocket = "SQUASH_CRI_SOCKET"
+
+	// Plank pod template of the squash deployment, as json, merged into the plank pods that squash creates
+	EnvPlankPodTemplate = "SQUASH_PLANK_POD_TEMPLATE"
+
+	// How long squash and squashctl wait for plank to detach its debugger before they delete its pod
+	PlankDetachTimeout = 30 * time.Second
 
 	KubeEnvPodName = "HOSTNAME"
 
@@ -9,6 +9,7 @@ import (
 
 	gokubeutils "github.com/solo-io/go-utils/kubeutils"
 	"github.com/solo-io/solo-kit/pkg/api/v1/clients"
+	"github.com/solo-io/solo-kit/pkg/api/v1/resources/core"
 	v1 "github.com/solo-io/squash/pkg/api/v1"
 	sqOpts "github.com/solo-io/squash/pkg/options"
 	"github.com/solo-io/squash/pkg/utils"
@@ -38,11 +39,15 @@ func GetConfig(ctx context.Context) (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
+	if token := os.Getenv(sqOpts.PlankEnvToken); token != "" {
+		// ephemeral planks borrow the target's service account mount for the cluster's CA, but not its identity
+		restCfg.BearerToken = token
+	}
 	kubeClient, err := kubernetes.NewForConfig(restCfg)
 	if err != nil {
 		return nil, err
 	}
-	daClient, err := utils.GetBasicDebugAttachmentClient(ctx)
+	daClient, err := utils.GetBasicDebugAttachmentClientForConfig(ctx, restCfg)
 	if err != nil {
 		return nil, err
 	}
@@ -52,10 +57,11 @@ func GetConfig(ctx context.Context) (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
+	da.ConvertDeprecatedFields()
 	if err := validateDebugAttachmentForPlankInit(da); err != nil {
 		return nil, err
 	}
-	da.PlankName = plankName
+	da.Plank = plankFor(da)
 	da, err = daClient.Write(da, clients.WriteOpts{Ctx: ctx, OverwriteExisting: true})
 	if err != nil {
 		return nil, err
@@ -72,11 +78,14 @@ func GetConfig(ctx context.Context) (*Config, error) {
 
 // assert all the requirements for a debug attachment when it is read by a plank pod during startup
 func validateDebugAttachmentForPlankInit(da *v1.DebugAttachment) error {
+	if da.Intent == nil {
+		return fmt.Errorf("Invalid Debug Attachment for Plank init: no intent specified")
+	}
 	errorMsg := ""
-	assertNotNilString(&errorMsg, da.Pod, "Pod")
-	assertNotNilString(&errorMsg, da.Container, "Container")
-	assertNotNilString(&errorMsg, da.Debugger, "Debugger")
-	assertNotNilString(&errorMsg, da.DebugNamespace, "DebugNamespace")
+	assertNotNilString(&errorMsg, da.Intent.GetPod().GetName(), "Intent.Pod.Name")
+	assertNotNilString(&errorMsg, da.Intent.GetPod().GetNamespace(), "Intent.Pod.Namespace")
+	assertNotNilString(&errorMsg, da.Intent.ContainerName, "Intent.ContainerName")
+	assertNotNilString(&errorMsg, da.Intent.Debugger, "Intent.Debugger")
 	if errorMsg != "" {
 		return fmt.Errorf("Invalid Debug Attachment for Plank init: %v", errorMsg)
 	}
@@ -85,6 +94,25 @@ func validateDebugAttachmentForPlankInit(da *v1.DebugAttachment) error {
 
 func assertNotNilString(errs *string, value, name string) {
 	if value == "" {
-		*errs = fmt.Sprintf("%v\n field %v should not be empty", errs, name)
+		*errs = fmt.Sprintf("%v\n field %v should not be empty", *errs, name)
+	}
+}
+
+// plankFor describes where this plank runs: in a pod of its own, or in an ephemeral container of the target pod
+func plankFor(da *v1.DebugAttachment) *v1.Plank {
+	if container := os.Getenv(sqOpts.PlankEnvEphemeralContainer); container != "" {
+		return &v1.Plank{
+			Pod: &core.ResourceRef{
+				Name:      da.GetIntent().GetPod().GetName(),
+				Namespace: da.GetIntent().GetPod().GetNamespace(),
+			},
+			EphemeralContainer: container,
+		}
+	}
+	return &v1.Plank{
+		Pod: &core.ResourceRef{
+			Name:      os.Getenv(sqOpts.KubeEnvPodName),
+			Namespace: os.Getenv(sqOpts.PlankEnvDebugSquashNamespace),
+		},
 	}
 }
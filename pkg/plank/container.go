@@ -3,11 +3,15 @@ package plank
 import (
 	"context"
 	"fmt"
+	"os"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
+	"github.com/solo-io/solo-kit/pkg/api/v1/clients"
 	v1 "github.com/solo-io/squash/pkg/api/v1"
+	sqOpts "github.com/solo-io/squash/pkg/options"
 	"github.com/solo-io/squash/pkg/utils"
 
 	"github.com/solo-io/squash/pkg/platforms"
@@ -16,53 +20,187 @@ import (
 
 const ListenHost = "127.0.0.1"
 
+const (
+	// how long plank waits for a target container that has not started yet
+	containerStartTimeout = 4 * time.Minute
+	// short enough to attach before the target's startup code has run
+	containerStartPollInterval = 100 * time.Millisecond
+)
+
 func Debug(ctx context.Context) error {
 	cfg, err := GetConfig(ctx)
 	if err != nil {
 		return err
 	}
 
-	var containerProcess platforms.ContainerProcess
-
-	containerProcess, err = kubernetes.NewContainerProcess()
+	containerProcess, err := containerProcessFor(ctx, cfg)
 	if err != nil {
-		containerProcess, err = kubernetes.NewCRIContainerProcessAlphaV1()
+		return err
+	}
+
+	var targets []attachTarget
+	var containers []watchedContainer
+	for _, containerName := range targetContainers(&cfg.Attachment) {
+		// resolve each container as though it were the only one requested
+		da := cfg.Attachment
+		intent := *da.Intent
+		intent.ContainerName = containerName
+		da.Intent = &intent
+
+		info, pids, err := findProcesses(ctx, containerProcess, &da)
 		if err != nil {
 			return err
 		}
+		for _, pid := range pids {
+			targets = append(targets, attachTarget{containerName: containerName, pid: pid})
+		}
+		containers = append(containers, watchedContainer{name: containerName, info: info})
+	}
+	fmt.Println("about to serve")
+
+	return startDebugging(cfg, targets, containers)
+}
+
+// findProcesses finds the container's processes to attach to. The debug attachments of match requests are made
+// before the target container starts, so for those plank waits for the container to run a matching process,
+// and attaches in time to debug its startup.
+func findProcesses(ctx context.Context, containerProcess platforms.ContainerProcess, da *v1.DebugAttachment) (*platforms.ContainerInfo, []int, error) {
+	containerName := da.GetIntent().GetContainerName()
+	if _, ok := da.Metadata.Labels[sqOpts.MatchRequestLabelKey]; !ok {
+		info, err := containerProcess.GetContainerInfo(ctx, da)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "could not find container %v", containerName)
+		}
+		pids, err := getPids(da, info)
+		return info, pids, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, containerStartTimeout)
+	defer cancel()
+	for {
+		info, err := containerProcess.GetContainerInfo(ctx, da)
+		if err == nil {
+			var pids []int
+			if pids, err = getPids(da, info); err == nil {
+				return info, pids, nil
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil, nil, errors.Wrapf(err, "container %v did not start a matching process in time", containerName)
+		case <-time.After(containerStartPollInterval):
+		}
+	}
+}
+
+// targetContainers lists the containers to debug, the primary container first
+func targetContainers(da *v1.DebugAttachment) []string {
+	containers := []string{da.GetIntent().GetContainerName()}
+	for _, name := range da.GetIntent().GetAdditionalContainerNames() {
+		if name != containers[0] {
+			containers = append(containers, name)
+		}
+	}
+	return containers
+}
+
+// containerProcessFor returns how plank finds the target's processes. Unless plank shares the target's
+// process namespace, it asks the node's container runtime, which it notes on the debug attachment.
+func containerProcessFor(ctx context.Context, cfg *Config) (platforms.ContainerProcess, error) {
+	if container := os.Getenv(sqOpts.PlankEnvEphemeralContainer); container != "" {
+		return newSharedPidContainerProcess(cfg.Attachment.GetIntent().GetContainerName()), nil
+	}
+	containerProcess, runtime, err := kubernetes.NewNodeContainerProcess()
+	if err != nil {
+		return nil, err
+	}
+	if err := recordContainerRuntime(ctx, cfg, runtime); err != nil {
+		return nil, err
 	}
+	return containerProcess, nil
+}
 
-	info, err := containerProcess.GetContainerInfo(ctx, &cfg.Attachment)
+// recordContainerRuntime notes on the debug attachment which container runtime plank uses, to help diagnose failures
+func recordContainerRuntime(ctx context.Context, cfg *Config, runtime *v1.ContainerRuntime) error {
+	da, err := cfg.daClient.Read(cfg.Attachment.Metadata.Namespace, cfg.Attachment.Metadata.Name, clients.ReadOpts{Ctx: ctx})
 	if err != nil {
 		return err
 	}
-
-	pid, err := getPid(&cfg.Attachment, info)
+	da.ContainerRuntime = runtime
+	da, err = cfg.daClient.Write(da, clients.WriteOpts{Ctx: ctx, OverwriteExisting: true})
 	if err != nil {
 		return err
 	}
-	fmt.Println("about to serve")
-
-	return startDebugging(cfg, pid)
+	cfg.Attachment = *da
+	return nil
 }
 
 func getPid(da *v1.DebugAttachment, info *platforms.ContainerInfo) (int, error) {
-	if da.ProcessName == "" {
-		return info.Pids[0], nil
+	pids, err := getPids(da, info)
+	if err != nil {
+		return 0, err
+	}
+	return pids[0], nil
+}
+
+// getPids returns the processes selected by the intent's process matcher.
+// Only the first match is returned, unless the intent asks to match all processes.
+func getPids(da *v1.DebugAttachment, info *platforms.ContainerInfo) ([]int, error) {
+	if len(info.Pids) == 0 {
+		return nil, errors.Errorf("no processes found in container %v", da.GetIntent().GetContainerName())
 	}
-	reg, err := regexp.Compile(strings.ToLower(da.ProcessName))
+	matchAll := da.GetIntent().GetMatchAllProcesses()
+	processMatcher := da.GetIntent().GetProcessMatcher()
+	if processMatcher == "" {
+		if matchAll {
+			return info.Pids, nil
+		}
+		return info.Pids[:1], nil
+	}
+	reg, err := processMatcherRegexp(da)
 	if err != nil {
-		return 0, errors.Wrapf(err, "unable to match process name, invalid match specification")
+		return nil, err
 	}
+	var pids []int
 	for _, pid := range info.Pids {
-		cmdLines, err := utils.GetCmdArgsByPid(pid)
+		matches, err := matchesProcess(reg, pid)
 		if err != nil {
-			return 0, errors.Wrapf(err, "could not get command line for pid %v", pid)
+			return nil, err
 		}
-		preparedCmdLine := strings.ToLower(strings.Join(cmdLines, ""))
-		if reg.MatchString(preparedCmdLine) {
-			return pid, nil
+		if matches {
+			pids = append(pids, pid)
+			if !matchAll {
+				break
+			}
 		}
 	}
-	return 0, errors.Wrapf(err, "could not find a command line matching %v", da.ProcessName)
+	if len(pids) == 0 {
+		return nil, errors.Errorf("could not find a command line matching %v", processMatcher)
+	}
+	return pids, nil
+}
+
+// processMatcherRegexp compiles the intent's process matcher, it returns nil if every process matches
+func processMatcherRegexp(da *v1.DebugAttachment) (*regexp.Regexp, error) {
+	processMatcher := da.GetIntent().GetProcessMatcher()
+	if processMatcher == "" {
+		return nil, nil
+	}
+	reg, err := regexp.Compile(strings.ToLower(processMatcher))
+	if err != nil {
+		return nil, errors.Wrapf(err, "unable to match process name, invalid match specification")
+	}
+	return reg, nil
+}
+
+func matchesProcess(reg *regexp.Regexp, pid int) (bool, error) {
+	if reg == nil {
+		return true, nil
+	}
+	cmdLines, err := utils.GetCmdArgsByPid(pid)
+	if err != nil {
+		return false, errors.Wrapf(err, "could not get command line for pid %v", pid)
+	}
+	preparedCmdLine := strings.ToLower(strings.Join(cmdLines, ""))
+	return reg.MatchString(preparedCmdLine), nil
 }
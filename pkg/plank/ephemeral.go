@@ -0,0 +1,81 @@
+package plank
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+
+	"github.com/pkg/errors"
+	v1 "github.com/solo-io/squash/pkg/api/v1"
+	"github.com/solo-io/squash/pkg/platforms"
+	"github.com/solo-io/squash/pkg/utils/processwatcher"
+)
+
+// sharedPidContainerProcess finds the target's processes when plank runs as an ephemeral container
+// that shares the process namespace of the target container. There is no container runtime to ask:
+// every process that plank can see, and that is not in plank's own mnt namespace, belongs to the target.
+type sharedPidContainerProcess struct {
+	targetContainer string
+}
+
+var _ platforms.ContainerProcess = &sharedPidContainerProcess{}
+
+func newSharedPidContainerProcess(targetContainer string) *sharedPidContainerProcess {
+	return &sharedPidContainerProcess{targetContainer: targetContainer}
+}
+
+func (c *sharedPidContainerProcess) GetContainerInfo(ctx context.Context, attachment *v1.DebugAttachment) (*platforms.ContainerInfo, error) {
+	containerName := attachment.GetIntent().GetContainerName()
+	if containerName != c.targetContainer {
+		return nil, errors.Errorf("plank only shares the process namespace of container %v, it cannot debug container %v", c.targetContainer, containerName)
+	}
+
+	const nstocheck = "mnt"
+	ownNS, err := processwatcher.PathToInode(filepath.Join("/proc", "self", "ns", nstocheck))
+	if err != nil {
+		return nil, err
+	}
+	targetNS, err := firstForeignNS(ownNS, nstocheck)
+	if err != nil {
+		return nil, err
+	}
+	pids, err := processwatcher.FindPidsInNS(targetNS, nstocheck)
+	if err != nil {
+		return nil, err
+	}
+	pod := attachment.GetIntent().GetPod()
+	return &platforms.ContainerInfo{
+		Pids:         pids,
+		Name:         fmt.Sprintf("%s.%s", pod.GetName(), pod.GetNamespace()),
+		MntNamespace: targetNS,
+	}, nil
+}
+
+// firstForeignNS returns the namespace of the lowest pid that is not in plank's own namespace
+func firstForeignNS(ownNS uint64, ns string) (uint64, error) {
+	files, err := ioutil.ReadDir("/proc")
+	if err != nil {
+		return 0, err
+	}
+	lowest := 0
+	var lowestNS uint64
+	for _, f := range files {
+		pid, err := strconv.Atoi(f.Name())
+		if err != nil || !f.IsDir() {
+			continue
+		}
+		inod, err := processwatcher.PathToInode(filepath.Join("/proc", f.Name(), "ns", ns))
+		if err != nil || inod == ownNS {
+			continue
+		}
+		if lowest == 0 || pid < lowest {
+			lowest, lowestNS = pid, inod
+		}
+	}
+	if lowest == 0 {
+		return 0, errors.New("no target processes are visible, plank must share the target container's process namespace")
+	}
+	return lowestNS, nil
+}
@@ -0,0 +1,91 @@
+package plank
+
+import (
+	"context"
+	"regexp"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/solo-io/squash/pkg/platforms"
+	"github.com/solo-io/squash/pkg/utils/processwatcher"
+)
+
+// watchedContainer is a target container, as it was when the session began
+type watchedContainer struct {
+	name string
+	info *platforms.ContainerInfo
+}
+
+// follow attaches to matching processes that start in the target containers after the session began
+func (s *debugSession) follow(ctx context.Context, containers []watchedContainer) {
+	for _, p := range s.processes() {
+		go s.forgetOnExit(ctx, p)
+	}
+	for _, c := range containers {
+		s.watchContainer(ctx, c)
+	}
+}
+
+// watchContainer starts following the processes of a container
+func (s *debugSession) watchContainer(ctx context.Context, c watchedContainer) {
+	reg, err := processMatcherRegexp(&s.cfg.Attachment)
+	if err != nil {
+		log.WithField("err", err).Warn("cannot follow processes")
+		return
+	}
+	if reg == nil {
+		// every process that starts in the container would be attached, including shells and probes
+		log.WithField("container", c.name).Warn("cannot follow processes without a process matcher")
+		return
+	}
+	if c.info.MntNamespace == 0 {
+		log.WithField("container", c.name).Warn("cannot follow processes, the container's mnt namespace is unknown")
+		return
+	}
+	w := processwatcher.NewNamespaceWatcher(c.info.MntNamespace, "mnt")
+	// the processes that were running already have been considered
+	w.Ignore(c.info.Pids...)
+	go s.followContainer(ctx, c.name, w.Watch(ctx), reg)
+}
+
+func (s *debugSession) followContainer(ctx context.Context, containerName string, pids <-chan int, reg *regexp.Regexp) {
+	for pid := range pids {
+		logger := log.WithFields(log.Fields{"container": containerName, "pid": pid})
+		matches, err := matchesProcess(reg, pid)
+		if err != nil {
+			// short lived processes may exit before they are inspected
+			logger.WithField("err", err).Debug("could not inspect new process")
+			continue
+		}
+		if !matches {
+			continue
+		}
+		p, err := s.attach(attachTarget{containerName: containerName, pid: pid})
+		if err != nil {
+			logger.WithField("err", err).Warn("could not attach to new process")
+			continue
+		}
+		logger.Info("attached to new process")
+		if err := s.publish(ctx); err != nil {
+			logger.WithField("err", err).Warn("could not publish new process")
+		}
+		go s.forgetOnExit(ctx, p)
+	}
+}
+
+// forgetOnExit removes a process from the debug attachment once its debug server exits,
+// which is usually because the process itself has exited
+func (s *debugSession) forgetOnExit(ctx context.Context, p attachedProcess) {
+	// debuggers that plank did not start cannot be tracked
+	if p.dbgServer.Done() == nil {
+		return
+	}
+	select {
+	case <-ctx.Done():
+		return
+	case <-p.dbgServer.Done():
+	}
+	s.remove(p.pid)
+	if err := s.publish(ctx); err != nil {
+		log.WithFields(log.Fields{"err": err, "pid": p.pid}).Warn("could not remove exited process")
+	}
+}
@@ -0,0 +1,55 @@
+package plank
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+
+	"github.com/solo-io/solo-kit/pkg/api/v1/resources/core"
+	v1 "github.com/solo-io/squash/pkg/api/v1"
+	"github.com/solo-io/squash/pkg/debuggers/detect"
+	sqOpts "github.com/solo-io/squash/pkg/options"
+	"github.com/solo-io/squash/pkg/platforms/kubernetes"
+)
+
+// where kubernetes reads the termination message from, by default
+const terminationMessagePath = "/dev/termination-log"
+
+// IsProbe indicates that this plank should only detect the target's debugger
+func IsProbe() bool {
+	return os.Getenv(sqOpts.PlankEnvProbeTargetPod) != ""
+}
+
+// Probe detects the debugger that suits the target process, without attaching to it.
+// The result is reported through the pod's termination message.
+func Probe(ctx context.Context) error {
+	// the target is described by the environment, there is no debug attachment yet
+	da := &v1.DebugAttachment{
+		Intent: &v1.Intent{
+			Pod: &core.ResourceRef{
+				Name:      os.Getenv(sqOpts.PlankEnvProbeTargetPod),
+				Namespace: os.Getenv(sqOpts.PlankEnvProbeTargetNamespace),
+			},
+			ContainerName:  os.Getenv(sqOpts.PlankEnvProbeTargetContainer),
+			ProcessMatcher: os.Getenv(sqOpts.PlankEnvProbeProcessMatcher),
+		},
+	}
+
+	containerProcess, _, err := kubernetes.NewNodeContainerProcess()
+	if err != nil {
+		return err
+	}
+	info, err := containerProcess.GetContainerInfo(ctx, da)
+	if err != nil {
+		return err
+	}
+	pid, err := getPid(da, info)
+	if err != nil {
+		return err
+	}
+	debugger, err := detect.FromProcess(pid)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(terminationMessagePath, []byte(debugger), 0644)
+}
@@ -5,38 +5,225 @@ import (
 	"fmt"
 	"io"
 	"net"
-	"os"
+	"sync"
+	"time"
 
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"github.com/solo-io/solo-kit/pkg/api/v1/clients"
+	skerrors "github.com/solo-io/solo-kit/pkg/errors"
 	v1 "github.com/solo-io/squash/pkg/api/v1"
 	"github.com/solo-io/squash/pkg/debuggers/remote"
 	"github.com/solo-io/squash/pkg/options"
-	"github.com/solo-io/squash/pkg/utils"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
 )
 
-func startDebugging(cfg *Config, pid int) error {
+// how often plank checks whether its debug attachment is being deleted
+const deleteRequestPollInterval = time.Second
 
-	particularDebugger := remote.GetParticularDebugger(cfg.Attachment.Debugger)
-	dbgServer, err := particularDebugger.Attach(pid)
-	if err != nil {
-		return err
+// attachTarget is a process that plank should attach a debugger to
+type attachTarget struct {
+	containerName string
+	pid           int
+}
+
+// attachedProcess is a target with the debug server attached to it
+type attachedProcess struct {
+	attachTarget
+	dbgServer remote.DebugServer
+}
+
+func startDebugging(cfg *Config, targets []attachTarget, containers []watchedContainer) error {
+	ctx, cancel := context.WithCancel(cfg.ctx)
+	defer cancel()
+
+	session := newDebugSession(cfg)
+	for _, target := range targets {
+		if _, err := session.attach(target); err != nil {
+			session.detachAll()
+			return err
+		}
 	}
+	attached := session.processes()
 
-	if err := connectLocalPrepare(cfg.ctx, dbgServer, cfg.Attachment); err != nil {
+	if err := connectLocalPrepare(ctx, cfg.daClient, attached, cfg.Attachment); err != nil {
+		session.detachAll()
 		return err
 	}
-	if err := proxyConnection(dbgServer); err != nil {
-		return err
+
+	// stay alive until either the debug session ends or squash asks us to detach
+	errchan := make(chan error, 2)
+	go func() {
+		errchan <- waitForDeleteRequest(cfg, session)
+	}()
+	// restarts of the target containers are handled by the squash server, which replaces this plank
+	switch {
+	case cfg.Attachment.Intent.GetFollowProcesses():
+		// processes come and go, so the session only ends on a delete request
+		session.follow(ctx, containers)
+	case len(attached) == 1:
+		if attached[0].dbgServer.Done() != nil {
+			go func() {
+				errchan <- proxyConnection(attached[0].dbgServer)
+			}()
+		}
+	default:
+		// there is a single proxy port, so with several debuggers the session ends when they have all exited
+		go func() {
+			errchan <- waitForDebugServers(attached)
+		}()
+	}
+	return <-errchan
+}
+
+// debugSession tracks the processes that plank is attached to
+type debugSession struct {
+	cfg      *Config
+	debugger remote.Remote
+
+	lock     sync.Mutex
+	attached []attachedProcess
+	// set once the session is over, later attachments are detached right away
+	detached bool
+
+	// serializes writes to the debug attachment
+	publishLock sync.Mutex
+}
+
+func newDebugSession(cfg *Config) *debugSession {
+	return &debugSession{
+		cfg:      cfg,
+		debugger: remote.GetParticularDebugger(cfg.Attachment.Intent.Debugger),
+	}
+}
+
+func (s *debugSession) attach(target attachTarget) (attachedProcess, error) {
+	dbgServer, err := s.debugger.Attach(target.pid)
+	if err != nil {
+		return attachedProcess{}, errors.Wrapf(err, "could not attach to pid %v in container %v", target.pid, target.containerName)
+	}
+	p := attachedProcess{attachTarget: target, dbgServer: dbgServer}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.detached {
+		detach(p)
+		return attachedProcess{}, errors.Errorf("debug session ended before pid %v was attached", target.pid)
+	}
+	s.attached = append(s.attached, p)
+	return p, nil
+}
+
+func (s *debugSession) processes() []attachedProcess {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]attachedProcess(nil), s.attached...)
+}
+
+func (s *debugSession) remove(pid int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for i, p := range s.attached {
+		if p.pid == pid {
+			s.attached = append(s.attached[:i], s.attached[i+1:]...)
+			return
+		}
+	}
+}
+
+// publish writes the current set of attached processes to the debug attachment
+func (s *debugSession) publish(ctx context.Context) error {
+	s.publishLock.Lock()
+	defer s.publishLock.Unlock()
+	return connectLocalPrepare(ctx, s.cfg.daClient, s.processes(), s.cfg.Attachment)
+}
+
+func (s *debugSession) detachAll() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.detached = true
+	for _, p := range s.attached {
+		detach(p)
+	}
+}
+
+func detach(p attachedProcess) {
+	if err := p.dbgServer.Detach(); err != nil {
+		log.WithFields(log.Fields{"err": err, "pid": p.pid}).Warn("error detaching debugger")
+	}
+}
+
+// waitForDebugServers blocks until every debug server that was started by this process has exited
+func waitForDebugServers(attached []attachedProcess) error {
+	var started []remote.DebugServer
+	for _, p := range attached {
+		if p.dbgServer.Done() != nil {
+			started = append(started, p.dbgServer)
+		}
+	}
+	if len(started) == 0 {
+		// nothing to wait for, the session ends on a delete request
+		select {}
+	}
+	for _, dbgServer := range started {
+		<-dbgServer.Done()
+		if err := dbgServer.ExitError(); err != nil {
+			log.WithField("err", err).Debug("debug server exited")
+		}
 	}
 	return nil
 }
 
+// waitForDeleteRequest blocks until the debug attachment is marked for deletion (or is removed),
+// then detaches from the target processes so that squash can safely delete the plank pod.
+// It polls rather than watches, so that plank only needs access to its own debug attachment.
+func waitForDeleteRequest(cfg *Config, session *debugSession) error {
+	ctx, cancel := context.WithCancel(cfg.ctx)
+	defer cancel()
+	namespace, name := cfg.Attachment.Metadata.Namespace, cfg.Attachment.Metadata.Name
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(deleteRequestPollInterval):
+		}
+		da, err := cfg.daClient.Read(namespace, name, clients.ReadOpts{Ctx: ctx})
+		if err != nil && !skerrors.IsNotExist(err) && !kerrors.IsForbidden(errors.Cause(err)) {
+			log.WithField("err", err).Warn("error reading debug attachment")
+			continue
+		}
+		// an ephemeral plank loses access to its debug attachment once it is deleted
+		if err == nil && !isBeingDeleted(da) && !isReplaced(cfg.Attachment, da) {
+			continue
+		}
+		log.WithFields(log.Fields{"da.Name": name, "da.Namespace": namespace}).Info("debug attachment is being deleted or re-attached, detaching")
+		session.detachAll()
+		// a replaced ephemeral plank records its detachment for squash, unless the next plank already took over
+		if err == nil && da.Plank != nil && da.Plank.EphemeralContainer == cfg.Attachment.Plank.GetEphemeralContainer() {
+			da.Plank.ReadyForConnect = false
+			if _, err := cfg.daClient.Write(da, clients.WriteOpts{Ctx: ctx, OverwriteExisting: true}); err != nil {
+				log.WithField("err", err).Debug("could not record detachment")
+			}
+		}
+		return nil
+	}
+}
+
+func isBeingDeleted(da *v1.DebugAttachment) bool {
+	return da.State == v1.DebugAttachment_RequestingDelete || da.State == v1.DebugAttachment_PendingDelete
+}
+
+// isReplaced tells an ephemeral plank that squash re-attaches its debug attachment after the target restarted.
+// Unlike a plank pod, its container cannot be deleted, so it stops by itself to make way for the next plank.
+func isReplaced(started v1.DebugAttachment, da *v1.DebugAttachment) bool {
+	return started.Plank.GetEphemeralContainer() != "" && da.GetReattachments().GetCount() > started.GetReattachments().GetCount()
+}
+
 // we proxy so we can exit the debugger when disconnection occurs
 // and so that we don't need to know the port the debugger is using
 func proxyConnection(dbgServer remote.DebugServer) error {
 	// only proxy the debuggers that are called by this process
-	if dbgServer.Cmd() == nil {
+	if dbgServer.Done() == nil {
 		return nil
 	}
 	errchan := make(chan error, 1)
@@ -47,7 +234,8 @@ func proxyConnection(dbgServer remote.DebugServer) error {
 		}
 	}
 	go func() {
-		reporterr(dbgServer.Cmd().Wait())
+		<-dbgServer.Done()
+		reporterr(dbgServer.ExitError())
 	}()
 
 	conn, err := startLocalServer()
@@ -75,17 +263,11 @@ func proxyConnection(dbgServer remote.DebugServer) error {
 	return <-errchan
 }
 
-func connectLocalPrepare(ctx context.Context, dbgServer remote.DebugServer, att v1.DebugAttachment) error {
+// connectLocalPrepare publishes the attached processes on the debug attachment
+func connectLocalPrepare(ctx context.Context, daClient v1.DebugAttachmentClient, attached []attachedProcess, att v1.DebugAttachment) error {
 	// Some debuggers work best when connected "locally"
-	// For these, we connect directly via `kubectl port-forward`
-	// We write the target port to a CRD to be read from squashctl
-
-	// get client
-	daClient, err := utils.GetBasicDebugAttachmentClient(ctx)
-	if err != nil {
-		log.WithField("err", err).Error("getting debug attachment client")
-		return err
-	}
+	// For these, squashctl port-forwards directly to the debugger
+	// We write the target ports to a CRD to be read from squashctl
 
 	// try to find a pre-existing CRD for this debug activity
 	// create one if none exist
@@ -93,11 +275,29 @@ func connectLocalPrepare(ctx context.Context, dbgServer remote.DebugServer, att
 	if err != nil {
 		return err
 	}
+	if isBeingDeleted(da) {
+		return errors.Errorf("debug attachment %v is being deleted", da.Metadata.Name)
+	}
 
-	// set port value
-	da.DebugServerAddress = fmt.Sprintf("inferfrompod:%v", dbgServer.Port())
-	// write own plank pod name
-	da.PlankName = os.Getenv("HOSTNAME")
+	// set port values, the first process is also described by the top level port spec
+	da.AttachedProcesses = nil
+	for _, p := range attached {
+		da.AttachedProcesses = append(da.AttachedProcesses, &v1.AttachedProcess{
+			ContainerName: p.containerName,
+			Pid:           int64(p.pid),
+			PortSpec:      portSpecFor(p.dbgServer),
+			DebuggerUrl:   debuggerUrlFor(p.dbgServer),
+		})
+	}
+	da.PortSpec = nil
+	if len(da.AttachedProcesses) > 0 {
+		da.PortSpec = da.AttachedProcesses[0].PortSpec
+	}
+	// write own plank pod reference
+	da.Plank = plankFor(da)
+	da.Plank.ReadyForConnect = true
+	// squashctl waits for this state before connecting
+	da.State = v1.DebugAttachment_Attached
 	if _, err := daClient.Write(da, clients.WriteOpts{Ctx: ctx, OverwriteExisting: true}); err != nil {
 		return err
 	}
@@ -105,6 +305,22 @@ func connectLocalPrepare(ctx context.Context, dbgServer remote.DebugServer, att
 	return nil
 }
 
+func portSpecFor(dbgServer remote.DebugServer) *v1.PortSpec {
+	switch dbgServer.HostType() {
+	case remote.DebugHostTypeTarget:
+		return v1.NewTargetPortSpec(dbgServer.Port())
+	default:
+		return v1.NewPlankPortSpec(dbgServer.Port())
+	}
+}
+
+func debuggerUrlFor(dbgServer remote.DebugServer) string {
+	if provider, ok := dbgServer.(remote.DebuggerUrlProvider); ok {
+		return provider.DebuggerUrl()
+	}
+	return ""
+}
+
 func startLocalServer() (net.Conn, error) {
 	l, err := net.Listen("tcp", fmt.Sprintf("%v:%v", ListenHost, options.OutPort))
 	if err != nil {
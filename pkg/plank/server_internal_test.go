@@ -0,0 +1,53 @@
+package plank
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "github.com/solo-io/squash/pkg/api/v1"
+)
+
+var _ = Describe("isReplaced", func() {
+	It("should only stop ephemeral planks once squash re-attaches", func() {
+		ephemeral := v1.DebugAttachment{Plank: &v1.Plank{EphemeralContainer: "plank-session"}}
+		reattached := v1.DebugAttachment{
+			Plank:         &v1.Plank{EphemeralContainer: "plank-session-1"},
+			Reattachments: &v1.Reattachments{Count: 1},
+		}
+		for _, c := range []struct {
+			description string
+			started     v1.DebugAttachment
+			current     *v1.DebugAttachment
+			replaced    bool
+		}{
+			{
+				description: "not re-attached",
+				started:     ephemeral,
+				current:     &v1.DebugAttachment{Plank: &v1.Plank{EphemeralContainer: "plank-session"}},
+			},
+			{
+				description: "re-attached",
+				started:     ephemeral,
+				current:     &v1.DebugAttachment{Reattachments: &v1.Reattachments{Count: 1}},
+				replaced:    true,
+			},
+			{
+				description: "started after the re-attachment",
+				started:     reattached,
+				current:     &v1.DebugAttachment{Reattachments: &v1.Reattachments{Count: 1}},
+			},
+			{
+				description: "re-attached again",
+				started:     reattached,
+				current:     &v1.DebugAttachment{Reattachments: &v1.Reattachments{Count: 2}},
+				replaced:    true,
+			},
+			{
+				description: "plank pods are deleted instead",
+				started:     v1.DebugAttachment{Plank: &v1.Plank{}},
+				current:     &v1.DebugAttachment{Reattachments: &v1.Reattachments{Count: 1}},
+			},
+		} {
+			Expect(isReplaced(c.started, c.current)).To(Equal(c.replaced), c.description)
+		}
+	})
+})
@@ -26,6 +26,8 @@ type ContainerLocator interface {
 type ContainerInfo struct {
 	Pids []int
 	Name string
+	// inode of the container's mnt namespace, processes that start later can be found by it
+	MntNamespace uint64
 }
 
 /// Get the information of a process that runs in the container. the pid should be in our pid namespace,
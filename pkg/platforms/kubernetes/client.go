@@ -1,3 +1,4 @@
 package kubernetes
 
+// CriRuntime is where a CRI socket that was configured, rather than discovered, is mounted
 const CriRuntime = "/var/run/cri.sock"
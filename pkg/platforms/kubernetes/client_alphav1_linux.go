@@ -4,9 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"io/ioutil"
 	"net"
-	"path/filepath"
 	"regexp"
 	"strconv"
 	"time"
@@ -25,11 +23,14 @@ import (
 	k8models "github.com/solo-io/squash/pkg/platforms/kubernetes/models"
 )
 
-type CRIContainerProcessAlphaV1 struct{}
+type CRIContainerProcessAlphaV1 struct {
+	// path of the CRI socket in this container
+	socket string
+}
 
-func NewCRIContainerProcessAlphaV1() (*CRIContainerProcessAlphaV1, error) {
+func NewCRIContainerProcessAlphaV1(socket string) (*CRIContainerProcessAlphaV1, error) {
 	// test that we have access to the runtime service
-	cc, err := grpc.Dial(CriRuntime, grpc.WithInsecure(), grpc.WithDialer(getDialer))
+	cc, err := grpc.Dial(socket, grpc.WithInsecure(), grpc.WithDialer(getDialer))
 	if err != nil {
 		return nil, err
 	}
@@ -42,7 +43,28 @@ func NewCRIContainerProcessAlphaV1() (*CRIContainerProcessAlphaV1, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &CRIContainerProcessAlphaV1{}, nil
+	return &CRIContainerProcessAlphaV1{socket: socket}, nil
+}
+
+// Runtime asks the runtime that serves the CRI socket for its name and version
+func (c *CRIContainerProcessAlphaV1) Runtime() (*squashv1.ContainerRuntime, error) {
+	cc, err := grpc.Dial(c.socket, grpc.WithInsecure(), grpc.WithDialer(getDialer))
+	if err != nil {
+		return nil, err
+	}
+	defer cc.Close()
+	runtimeService := kubeapi.NewRuntimeServiceClient(cc)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	version, err := runtimeService.Version(ctx, &kubeapi.VersionRequest{Version: kubeRuntimeAPIVersion})
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+	return &squashv1.ContainerRuntime{
+		Name:    version.RuntimeName,
+		Version: version.RuntimeVersion,
+	}, nil
 }
 
 func getDialer(a string, t time.Duration) (net.Conn, error) {
@@ -63,7 +85,7 @@ func (c *CRIContainerProcessAlphaV1) GetContainerInfo(maincontext context.Contex
 
 	// contact the local CRI and get the container
 
-	cc, err := grpc.Dial(CriRuntime, grpc.WithInsecure(), grpc.WithDialer(getDialer))
+	cc, err := grpc.Dial(c.socket, grpc.WithInsecure(), grpc.WithDialer(getDialer))
 	runtimeService := kubeapi.NewRuntimeServiceClient(cc)
 
 	labels := make(map[string]string)
@@ -129,48 +151,43 @@ func (c *CRIContainerProcessAlphaV1) GetContainerInfo(maincontext context.Contex
 
 	// we check the mnt namespace cause this is the one that cannot be shared with the host...
 	nstocheck := "mnt"
-	// get pids
-	nsinod, err := getNSAlphav1(maincontext, runtimeService, nstocheck, containerid)
+	// get pids, exec'ing into the container only if its processes cannot be found from the host
+	nsinod, err := getNSFromHost(containerid, nstocheck)
 	if err != nil {
-		log.WithField("err", err).Warn("getNSAlphav1 error")
-		return nil, err
+		log.WithField("err", err).Info("cannot find container namespace from the host, asking the runtime for its pid")
+		nsinod, err = getNSFromStatusAlphav1(maincontext, runtimeService, nstocheck, containerid)
+	}
+	if err != nil {
+		log.WithField("err", err).Info("cannot find container namespace from its status, trying ExecSync")
+		nsinod, err = getNSAlphav1(maincontext, runtimeService, nstocheck, containerid)
+		if err != nil {
+			log.WithField("err", err).Warn("getNSAlphav1 error")
+			return nil, err
+		}
 	}
 
-	potentialpids, err := FindPidsInNS(nsinod, nstocheck)
+	potentialpids, err := processwatcher.FindPidsInNS(nsinod, nstocheck)
 	if err != nil {
 		log.WithField("err", err).Warn("FindPidsInNS error")
 		return nil, err
 	}
 
 	log.WithField("potentialpids", potentialpids).Info("found some pids")
-	return &platforms.ContainerInfo{Pids: potentialpids, Name: fmt.Sprintf("%s.%s", ka.Pod, ka.Namespace)}, nil
+	return &platforms.ContainerInfo{
+		Pids:         potentialpids,
+		Name:         fmt.Sprintf("%s.%s", ka.Pod, ka.Namespace),
+		MntNamespace: nsinod,
+	}, nil
 }
 
-func FindPidsInNS(inod uint64, ns string) ([]int, error) {
-	var res []int
-	files, err := ioutil.ReadDir("/proc")
+func getNSFromStatusAlphav1(origctx context.Context, cli kubeapi.RuntimeServiceClient, ns string, containerid string) (uint64, error) {
+	ctx, cancel := context.WithTimeout(origctx, time.Second)
+	status, err := cli.ContainerStatus(ctx, &kubeapi.ContainerStatusRequest{ContainerId: containerid, Verbose: true})
+	cancel()
 	if err != nil {
-		return nil, err
-	}
-
-	for _, f := range files {
-		if !f.IsDir() {
-			continue
-		}
-		pid, err := strconv.Atoi(f.Name())
-		if err != nil {
-			continue
-		}
-
-		p := filepath.Join("/proc", f.Name(), "ns", ns)
-		if inod2, err := processwatcher.PathToInode(p); err != nil {
-			continue
-		} else if inod == inod2 {
-			res = append(res, pid)
-		}
+		return 0, err
 	}
-
-	return res, nil
+	return getNSFromStatus(status.Info, ns)
 }
 
 func getNSAlphav1(origctx context.Context, cli kubeapi.RuntimeServiceClient, ns string, containerid string) (uint64, error) {
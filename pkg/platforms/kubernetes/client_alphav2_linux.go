@@ -12,9 +12,12 @@ import (
 
 	v1 "github.com/solo-io/squash/pkg/api/v1"
 	"github.com/solo-io/squash/pkg/platforms"
+	"github.com/solo-io/squash/pkg/utils/processwatcher"
 
 	log "github.com/sirupsen/logrus"
 
+	"google.golang.org/grpc"
+
 	k8models "github.com/solo-io/squash/pkg/platforms/kubernetes/models"
 	criapi "k8s.io/kubernetes/pkg/kubelet/apis/cri"
 	kubeapi "k8s.io/kubernetes/pkg/kubelet/apis/cri/runtime/v1alpha2"
@@ -26,13 +29,16 @@ const (
 	defaultTimeout = 10 * time.Second
 )
 
-type CRIContainerProcess struct{}
+type CRIContainerProcess struct {
+	// path of the CRI socket in this container
+	socket string
+}
 
 var _ platforms.ContainerProcess = &CRIContainerProcess{}
 
-func NewContainerProcess() (*CRIContainerProcess, error) {
+func NewContainerProcess(socket string) (*CRIContainerProcess, error) {
 	// test that we have access to the runtime service
-	r, err := remote.NewRemoteRuntimeService(CriRuntime, defaultTimeout)
+	r, err := remote.NewRemoteRuntimeService(socket, defaultTimeout)
 	if err != nil {
 		return nil, err
 	}
@@ -42,7 +48,23 @@ func NewContainerProcess() (*CRIContainerProcess, error) {
 		return nil, err
 	}
 
-	return &CRIContainerProcess{}, nil
+	return &CRIContainerProcess{socket: socket}, nil
+}
+
+// Runtime asks the runtime that serves the CRI socket for its name and version
+func (c *CRIContainerProcess) Runtime() (*v1.ContainerRuntime, error) {
+	r, err := remote.NewRemoteRuntimeService(c.socket, defaultTimeout)
+	if err != nil {
+		return nil, err
+	}
+	version, err := r.Version(kubeRuntimeAPIVersion)
+	if err != nil {
+		return nil, err
+	}
+	return &v1.ContainerRuntime{
+		Name:    version.RuntimeName,
+		Version: version.RuntimeVersion,
+	}, nil
 }
 
 func (c *CRIContainerProcess) GetContainerInfo(maincontext context.Context, attachment *v1.DebugAttachment) (*platforms.ContainerInfo, error) {
@@ -65,7 +87,7 @@ func (c *CRIContainerProcess) GetContainerInfoKube(maincontext context.Context,
 	}
 
 	// contact the local CRI and get the container
-	runtimeService, err := remote.NewRemoteRuntimeService("unix://"+CriRuntime, defaultTimeout)
+	runtimeService, err := remote.NewRemoteRuntimeService("unix://"+c.socket, defaultTimeout)
 	if err != nil {
 		return nil, err
 	}
@@ -125,21 +147,50 @@ func (c *CRIContainerProcess) GetContainerInfoKube(maincontext context.Context,
 
 	// we check the mnt namespace cause this is the one that cannot be shared with the host...
 	nstocheck := "mnt"
-	// get pids
-	nsinod, err := getNS(maincontext, runtimeService, nstocheck, containerid)
+	// get pids, exec'ing into the container only if its processes cannot be found from the host
+	nsinod, err := getNSFromHost(containerid, nstocheck)
 	if err != nil {
-		log.WithField("err", err).Warn("getNS error")
-		return nil, err
+		log.WithField("err", err).Info("cannot find container namespace from the host, asking the runtime for its pid")
+		nsinod, err = c.getNSFromStatus(maincontext, nstocheck, containerid)
+	}
+	if err != nil {
+		log.WithField("err", err).Info("cannot find container namespace from its status, trying ExecSync")
+		nsinod, err = getNS(maincontext, runtimeService, nstocheck, containerid)
+		if err != nil {
+			log.WithField("err", err).Warn("getNS error")
+			return nil, err
+		}
 	}
 
-	potentialpids, err := FindPidsInNS(nsinod, nstocheck)
+	potentialpids, err := processwatcher.FindPidsInNS(nsinod, nstocheck)
 	if err != nil {
 		log.WithField("err", err).Warn("FindPidsInNS error")
 		return nil, err
 	}
 
 	log.WithField("potentialpids", potentialpids).Info("found some pids")
-	return &platforms.ContainerInfo{Pids: potentialpids, Name: fmt.Sprintf("%s.%s", ka.Pod, ka.Namespace)}, nil
+	return &platforms.ContainerInfo{
+		Pids:         potentialpids,
+		Name:         fmt.Sprintf("%s.%s", ka.Pod, ka.Namespace),
+		MntNamespace: nsinod,
+	}, nil
+}
+
+// getNSFromStatus asks for the verbose container status, which the runtime service of the kubelet does not request
+func (c *CRIContainerProcess) getNSFromStatus(origctx context.Context, ns string, containerid string) (uint64, error) {
+	cc, err := grpc.Dial(c.socket, grpc.WithInsecure(), grpc.WithDialer(getDialer))
+	if err != nil {
+		return 0, err
+	}
+	defer cc.Close()
+
+	ctx, cancel := context.WithTimeout(origctx, defaultTimeout)
+	status, err := kubeapi.NewRuntimeServiceClient(cc).ContainerStatus(ctx, &kubeapi.ContainerStatusRequest{ContainerId: containerid, Verbose: true})
+	cancel()
+	if err != nil {
+		return 0, err
+	}
+	return getNSFromStatus(status.Info, ns)
 }
 
 func getNS(origctx context.Context, cli criapi.RuntimeService, ns string, containerid string) (uint64, error) {
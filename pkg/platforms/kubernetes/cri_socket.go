@@ -0,0 +1,72 @@
+package kubernetes
+
+import (
+	"path"
+
+	sqOpts "github.com/solo-io/squash/pkg/options"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// the node's run directory is mounted below this path, so that the CRI socket can be discovered
+const hostRunMountRoot = "/host"
+
+// the node directory that holds the CRI sockets squash discovers. The runtimes keep theirs in directories of
+// their own below it, while dockershim puts its socket directly into it, so the whole directory is mounted, read-only.
+const criSocketDir = "/var/run"
+
+// knownCRISockets are the paths of the CRI sockets on a node, in the order they are tried.
+// Dockershim comes first: its socket only exists when the kubelet uses docker, while docker nodes
+// often run containerd as well.
+var knownCRISockets = []string{
+	"/var/run/dockershim.sock",
+	"/var/run/crio/crio.sock",
+	"/var/run/containerd/containerd.sock",
+}
+
+// CRISocketVolumes returns what a squash or plank container needs to reach the node's CRI socket.
+// If hostSocket is set, only that socket is mounted. Otherwise the directory of the known sockets is mounted,
+// so that the socket can be discovered.
+func CRISocketVolumes(hostSocket string) ([]corev1.Volume, []corev1.VolumeMount, []corev1.EnvVar) {
+	if hostSocket != "" {
+		const volumeName = "crisock"
+		// the pod fails to start, rather than plank failing later, if the socket is not there
+		socketType := corev1.HostPathSocket
+		volumes := []corev1.Volume{{
+			Name: volumeName,
+			VolumeSource: corev1.VolumeSource{
+				HostPath: &corev1.HostPathVolumeSource{
+					Path: hostSocket,
+					Type: &socketType,
+				},
+			},
+		}}
+		mounts := []corev1.VolumeMount{{
+			Name:      volumeName,
+			MountPath: CriRuntime,
+		}}
+		env := []corev1.EnvVar{{
+			Name:  sqOpts.EnvCRISocket,
+			Value: hostSocket,
+		}}
+		return volumes, mounts, env
+	}
+
+	const volumeName = "cri-sockets"
+	// every node has the directory, whichever runtime it uses
+	dirType := corev1.HostPathDirectory
+	volumes := []corev1.Volume{{
+		Name: volumeName,
+		VolumeSource: corev1.VolumeSource{
+			HostPath: &corev1.HostPathVolumeSource{
+				Path: criSocketDir,
+				Type: &dirType,
+			},
+		},
+	}}
+	mounts := []corev1.VolumeMount{{
+		Name:      volumeName,
+		MountPath: path.Join(hostRunMountRoot, criSocketDir),
+		ReadOnly:  true,
+	}}
+	return volumes, mounts, nil
+}
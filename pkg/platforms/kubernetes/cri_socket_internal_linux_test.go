@@ -0,0 +1,35 @@
+package kubernetes
+
+import (
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	sqOpts "github.com/solo-io/squash/pkg/options"
+)
+
+var _ = Describe("criSocketCandidates", func() {
+	var configured string
+	BeforeEach(func() {
+		configured = os.Getenv(sqOpts.EnvCRISocket)
+	})
+	AfterEach(func() {
+		os.Setenv(sqOpts.EnvCRISocket, configured)
+	})
+
+	It("should try dockershim, CRI-O and containerd below the mounted run directory", func() {
+		os.Unsetenv(sqOpts.EnvCRISocket)
+		Expect(criSocketCandidates()).To(Equal([]criSocket{
+			{hostPath: "/var/run/dockershim.sock", path: "/host/var/run/dockershim.sock"},
+			{hostPath: "/var/run/crio/crio.sock", path: "/host/var/run/crio/crio.sock"},
+			{hostPath: "/var/run/containerd/containerd.sock", path: "/host/var/run/containerd/containerd.sock"},
+		}))
+	})
+
+	It("should only try the configured socket", func() {
+		os.Setenv(sqOpts.EnvCRISocket, "/run/k3s/containerd/containerd.sock")
+		Expect(criSocketCandidates()).To(Equal([]criSocket{
+			{hostPath: "/run/k3s/containerd/containerd.sock", path: CriRuntime},
+		}))
+	})
+})
@@ -0,0 +1,87 @@
+package kubernetes
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	v1 "github.com/solo-io/squash/pkg/api/v1"
+	sqOpts "github.com/solo-io/squash/pkg/options"
+	"github.com/solo-io/squash/pkg/platforms"
+)
+
+// the runtime API version squash asks for, only needed to query the runtime's name and version
+const kubeRuntimeAPIVersion = "0.1.0"
+
+// criSocket is a CRI socket as seen from the node, and from this container
+type criSocket struct {
+	hostPath string
+	path     string
+}
+
+// NewNodeContainerProcess connects to the container runtime of the node this container runs on.
+// It uses the configured CRI socket if there is one, otherwise it tries the known sockets in turn.
+// The runtime it connected to is returned, so that it can be recorded on the debug attachment.
+func NewNodeContainerProcess() (platforms.ContainerProcess, *v1.ContainerRuntime, error) {
+	var failures []string
+	for _, socket := range criSocketCandidates() {
+		if _, err := os.Stat(socket.path); err != nil {
+			continue
+		}
+		containerProcess, runtime, err := connectCRI(socket.path)
+		if err != nil {
+			log.WithFields(log.Fields{"socket": socket.hostPath, "err": err}).Debug("cannot use CRI socket")
+			failures = append(failures, fmt.Sprintf("%v: %v", socket.hostPath, err))
+			continue
+		}
+		runtime.Socket = socket.hostPath
+		log.WithFields(log.Fields{"runtime": runtime.Name, "version": runtime.Version, "socket": runtime.Socket}).Info("found container runtime")
+		return containerProcess, runtime, nil
+	}
+	if len(failures) == 0 {
+		return nil, nil, fmt.Errorf("no CRI socket found on the node, looked for %v, configure the CRI socket if the node uses another one", strings.Join(criSocketHostPaths(), ", "))
+	}
+	return nil, nil, fmt.Errorf("cannot connect to a CRI socket: %v", strings.Join(failures, "; "))
+}
+
+// connectCRI connects to a CRI socket with the newest runtime API it supports
+func connectCRI(socket string) (platforms.ContainerProcess, *v1.ContainerRuntime, error) {
+	containerProcess, err := NewContainerProcess(socket)
+	if err == nil {
+		runtime, err := containerProcess.Runtime()
+		if err != nil {
+			return nil, nil, err
+		}
+		return containerProcess, runtime, nil
+	}
+	containerProcessAlphaV1, err := NewCRIContainerProcessAlphaV1(socket)
+	if err != nil {
+		return nil, nil, err
+	}
+	runtime, err := containerProcessAlphaV1.Runtime()
+	if err != nil {
+		return nil, nil, err
+	}
+	return containerProcessAlphaV1, runtime, nil
+}
+
+func criSocketCandidates() []criSocket {
+	if hostSocket := os.Getenv(sqOpts.EnvCRISocket); hostSocket != "" {
+		return []criSocket{{hostPath: hostSocket, path: CriRuntime}}
+	}
+	var candidates []criSocket
+	for _, hostPath := range knownCRISockets {
+		candidates = append(candidates, criSocket{hostPath: hostPath, path: path.Join(hostRunMountRoot, hostPath)})
+	}
+	return candidates
+}
+
+func criSocketHostPaths() []string {
+	var paths []string
+	for _, socket := range criSocketCandidates() {
+		paths = append(paths, socket.hostPath)
+	}
+	return paths
+}
@@ -0,0 +1,41 @@
+package kubernetes_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	sqOpts "github.com/solo-io/squash/pkg/options"
+	squashkube "github.com/solo-io/squash/pkg/platforms/kubernetes"
+	corev1 "k8s.io/api/core/v1"
+)
+
+var _ = Describe("CRISocketVolumes", func() {
+	It("should mount the node's run directory read-only to discover the socket", func() {
+		volumes, mounts, env := squashkube.CRISocketVolumes("")
+		dirType := corev1.HostPathDirectory
+		Expect(volumes).To(Equal([]corev1.Volume{{
+			Name: "cri-sockets",
+			VolumeSource: corev1.VolumeSource{
+				HostPath: &corev1.HostPathVolumeSource{Path: "/var/run", Type: &dirType},
+			},
+		}}))
+		Expect(mounts).To(Equal([]corev1.VolumeMount{{
+			Name:      "cri-sockets",
+			MountPath: "/host/var/run",
+			ReadOnly:  true,
+		}}))
+		Expect(env).To(BeEmpty())
+	})
+
+	It("should only mount a configured socket", func() {
+		volumes, mounts, env := squashkube.CRISocketVolumes("/run/k3s/containerd/containerd.sock")
+		socketType := corev1.HostPathSocket
+		Expect(volumes).To(Equal([]corev1.Volume{{
+			Name: "crisock",
+			VolumeSource: corev1.VolumeSource{
+				HostPath: &corev1.HostPathVolumeSource{Path: "/run/k3s/containerd/containerd.sock", Type: &socketType},
+			},
+		}}))
+		Expect(mounts).To(Equal([]corev1.VolumeMount{{Name: "crisock", MountPath: squashkube.CriRuntime}}))
+		Expect(env).To(Equal([]corev1.EnvVar{{Name: sqOpts.EnvCRISocket, Value: "/run/k3s/containerd/containerd.sock"}}))
+	})
+})
@@ -29,9 +29,9 @@ func GenericToKubeAttachment(attachment interface{}) (*KubeAttachment, error) {
 // TODO - refactor this because it should never error
 func DebugAttachmentToKubeAttachment(da *v1.DebugAttachment) (*KubeAttachment, error) {
 	ka := &KubeAttachment{
-		Namespace: da.DebugNamespace,
-		Pod:       da.Pod,
-		Container: da.Container,
+		Namespace: da.Intent.GetPod().GetNamespace(),
+		Pod:       da.Intent.GetPod().GetName(),
+		Container: da.Intent.GetContainerName(),
 	}
 	return ka, nil
 
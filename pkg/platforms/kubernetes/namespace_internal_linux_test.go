@@ -0,0 +1,42 @@
+package kubernetes
+
+import (
+	"fmt"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/solo-io/squash/pkg/utils/processwatcher"
+)
+
+var _ = Describe("getNSFromHost", func() {
+	It("should fail for a container without processes", func() {
+		_, err := getNSFromHost("0f5e4d3c2b1a09f8e7d6c5b4a3928170f5e4d3c2b1a09f8e7d6c5b4a3928170f", "mnt")
+		Expect(err).To(MatchError(ContainSubstring("no process found in the cgroup of container")))
+	})
+})
+
+var _ = Describe("getNSFromStatus", func() {
+	It("should read the namespace of the pid that the runtime reports", func() {
+		expected, err := processwatcher.PathToInode("/proc/self/ns/mnt")
+		Expect(err).NotTo(HaveOccurred())
+		// containerd and CRI-O report more than the pid
+		info := map[string]string{"info": fmt.Sprintf(`{"sandboxID":"4c1b","pid":%d,"runtimeSpec":{}}`, os.Getpid())}
+		Expect(getNSFromStatus(info, "mnt")).To(Equal(expected))
+	})
+
+	It("should report why the status has no pid", func() {
+		for _, c := range []struct {
+			info map[string]string
+			err  string
+		}{
+			{info: nil, err: "the runtime reports no verbose container info"},
+			{info: map[string]string{"info": "{"}, err: "cannot parse the verbose container info"},
+			{info: map[string]string{"info": `{"sandboxID":"4c1b"}`}, err: "the verbose container info has no pid"},
+			{info: map[string]string{"info": `{"pid":0}`}, err: "the verbose container info has no pid"},
+		} {
+			_, err := getNSFromStatus(c.info, "mnt")
+			Expect(err).To(MatchError(ContainSubstring(c.err)), fmt.Sprint(c.info))
+		}
+	})
+})
@@ -0,0 +1,55 @@
+package kubernetes
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strconv"
+
+	"github.com/solo-io/squash/pkg/utils/processwatcher"
+)
+
+// getNSFromHost returns the inode of a container's namespace, ns is the namespace type, as in "mnt".
+// It inspects the container's processes from the host, so unlike ExecSync it works for images
+// without a shell or coreutils, such as distroless and scratch images.
+func getNSFromHost(containerid, ns string) (uint64, error) {
+	pids, err := processwatcher.FindPidsInCgroup(containerid)
+	if err != nil {
+		return 0, err
+	}
+	if len(pids) == 0 {
+		return 0, fmt.Errorf("no process found in the cgroup of container %v", containerid)
+	}
+	return processwatcher.PathToInode(filepath.Join("/proc", strconv.Itoa(pids[0]), "ns", ns))
+}
+
+// verboseContainerInfo is the part of the verbose container status in which containerd and CRI-O report the pid
+// of the container's first process
+type verboseContainerInfo struct {
+	Pid int `json:"pid"`
+}
+
+// getNSFromStatus returns the inode of a container's namespace, ns is the namespace type, as in "mnt".
+// It asks the runtime for the container's pid, which it reports in the info of the verbose container status.
+func getNSFromStatus(info map[string]string, ns string) (uint64, error) {
+	pid, err := pidFromStatusInfo(info)
+	if err != nil {
+		return 0, err
+	}
+	return processwatcher.PathToInode(filepath.Join("/proc", strconv.Itoa(pid), "ns", ns))
+}
+
+func pidFromStatusInfo(info map[string]string) (int, error) {
+	raw, ok := info["info"]
+	if !ok {
+		return 0, fmt.Errorf("the runtime reports no verbose container info")
+	}
+	var containerInfo verboseContainerInfo
+	if err := json.Unmarshal([]byte(raw), &containerInfo); err != nil {
+		return 0, fmt.Errorf("cannot parse the verbose container info: %v", err)
+	}
+	if containerInfo.Pid <= 0 {
+		return 0, fmt.Errorf("the verbose container info has no pid")
+	}
+	return containerInfo.Pid, nil
+}
@@ -4,14 +4,22 @@ import (
 	"context"
 	"os"
 	"sync"
+	"time"
 
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"github.com/solo-io/solo-kit/pkg/api/v1/clients"
+	"github.com/solo-io/solo-kit/pkg/api/v1/resources/core"
 	v1 "github.com/solo-io/squash/pkg/api/v1"
 	"github.com/solo-io/squash/pkg/config"
 	"github.com/solo-io/squash/pkg/debuggers/remote"
 	sqOpts "github.com/solo-io/squash/pkg/options"
 	"github.com/solo-io/squash/pkg/version"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
 )
 
 type DebugController struct {
@@ -19,11 +27,20 @@ type DebugController struct {
 	pidLock  sync.Mutex
 	pidMap   map[int]bool
 
-	daClient v1.DebugAttachmentClient
-	ctx      context.Context
+	daClient   v1.DebugAttachmentClient
+	kubeClient kubernetes.Interface
+	ctx        context.Context
 
 	debugattachmentsLock sync.Mutex
 	debugattachments     map[string]debugAttachmentData
+	// debug attachments whose delete handler is running, keyed by namespace.name
+	deletesInProgress map[string]bool
+	// stops the pod watch of each active match request, keyed by namespace.name
+	matchRequests map[string]context.CancelFunc
+	// stops the target pod watch of each debug attachment that re-attaches on restart, keyed by namespace.name
+	restartWatches map[string]context.CancelFunc
+	// reports whether an ephemeral plank still runs, which needs a newer api than the kube client has
+	ephemeralContainerRunning func(namespace, pod, container string) (bool, error)
 }
 
 type debugAttachmentData struct {
@@ -33,21 +50,29 @@ type debugAttachmentData struct {
 
 func NewDebugController(ctx context.Context,
 	debugger func(string) remote.Remote,
-	daClient v1.DebugAttachmentClient) *DebugController {
+	daClient v1.DebugAttachmentClient,
+	kubeClient kubernetes.Interface) *DebugController {
 	return &DebugController{
 		debugger: debugger,
 
-		daClient: daClient,
+		daClient:   daClient,
+		kubeClient: kubeClient,
+		ctx:        ctx,
 
 		pidMap: make(map[int]bool),
 
-		debugattachments: make(map[string]debugAttachmentData),
+		debugattachments:  make(map[string]debugAttachmentData),
+		deletesInProgress: make(map[string]bool),
+		matchRequests:     make(map[string]context.CancelFunc),
+		restartWatches:    make(map[string]context.CancelFunc),
+		ephemeralContainerRunning: func(namespace, pod, container string) (bool, error) {
+			return config.EphemeralContainerRunning(kubeClient, namespace, pod, container)
+		},
 	}
 }
 
 func (d *DebugController) removeAttachment(namespace, name string) {
 	d.debugattachmentsLock.Lock()
-	d.markForDeletion(namespace, name)
 	data, ok := d.debugattachments[name]
 	delete(d.debugattachments, name)
 	d.debugattachmentsLock.Unlock()
@@ -83,7 +108,8 @@ func (d *DebugController) setState(namespace, name string, state v1.DebugAttachm
 	da, err := d.daClient.Read(namespace, name, clients.ReadOpts{Ctx: d.ctx})
 	if err != nil {
 		// should not happen, but if it does, the CRD was probably already deleted
-		log.WithFields(log.Fields{"da.Name": da.Metadata.Name, "da.Namespace": da.Metadata.Namespace}).Warn("Failed to read attachment.")
+		log.WithFields(log.Fields{"name": name, "namespace": namespace}).Warn("Failed to read attachment.")
+		return
 	}
 
 	da.State = state
@@ -96,24 +122,113 @@ func (d *DebugController) setState(namespace, name string, state v1.DebugAttachm
 		log.WithFields(log.Fields{"da.Name": da.Metadata.Name, "da.Namespace": da.Metadata.Namespace}).Warn("Failed to set attachment state.")
 	}
 }
+
+// markForDeletion requests that the debug attachment be torn down by the delete handler
 func (d *DebugController) markForDeletion(namespace, name string) {
-	log.Debug("called mark for deletion from squash - skipping for now")
-	return
 	log.WithFields(log.Fields{"namespace": namespace, "name": name}).Debug("marking for deletion")
-	da, err := d.daClient.Read(namespace, name, clients.ReadOpts{Ctx: d.ctx})
+	d.setState(namespace, name, v1.DebugAttachment_RequestingDelete)
+}
+
+// handleDeleteRequest detaches the debugger, deletes the plank pod, and finally deletes the debug attachment.
+// Each step is written back to the debug attachment so that clients can follow the progress.
+func (d *DebugController) handleDeleteRequest(da *v1.DebugAttachment) {
+	namespace, name := da.Metadata.Namespace, da.Metadata.Name
+	key := namespace + "." + name
+	d.debugattachmentsLock.Lock()
+	if d.deletesInProgress[key] {
+		d.debugattachmentsLock.Unlock()
+		return
+	}
+	d.deletesInProgress[key] = true
+	d.debugattachmentsLock.Unlock()
+	defer func() {
+		d.debugattachmentsLock.Lock()
+		delete(d.deletesInProgress, key)
+		d.debugattachmentsLock.Unlock()
+	}()
+	d.stopRestartWatch(key)
+
+	logger := log.WithFields(log.Fields{"da.Name": name, "da.Namespace": namespace})
+
+	// Mark deletion as in progress, plank detaches its debugger when it sees this state
+	if da.State != v1.DebugAttachment_PendingDelete {
+		d.setState(namespace, name, v1.DebugAttachment_PendingDelete)
+	}
+	d.removeAttachment(namespace, name)
+
+	planks, err := d.listPlankPods(da)
 	if err != nil {
-		// should not happen, but if it does, the CRD was probably already deleted
-		log.WithFields(log.Fields{"da.Name": da.Metadata.Name, "da.Namespace": da.Metadata.Namespace}).Warn("Failed to read attachment prior to delete.")
+		logger.WithField("error", err).Warn("Failed to list plank pods, deleting debug attachment anyway.")
+	}
+	for _, plank := range planks {
+		if err := d.waitForPlankToDetach(plank); err != nil {
+			logger.WithFields(log.Fields{"plank": plank.Name, "error": err}).Warn("Plank did not detach in time, deleting it anyway.")
+		}
+		if err := d.kubeClient.CoreV1().Pods(plank.Namespace).Delete(plank.Name, &metav1.DeleteOptions{}); err != nil && !kerrors.IsNotFound(err) {
+			logger.WithFields(log.Fields{"plank": plank.Name, "error": err}).Warn("Failed to delete plank pod.")
+		}
+	}
+	if len(planks) > 0 {
+		d.clearPlank(namespace, name)
 	}
 
-	da.State = v1.DebugAttachment_PendingDelete
+	d.deleteResource(namespace, name)
+	if err := config.RevokeEphemeralPlankAccess(d.kubeClient, da); err != nil {
+		logger.WithField("error", err).Warn("Failed to delete the service account of the ephemeral plank.")
+	}
+}
 
-	_, err = d.daClient.Write(da, clients.WriteOpts{
-		Ctx:               d.ctx,
-		OverwriteExisting: true,
-	})
+// listPlankPods finds the plank pods that serve a debug attachment by the labels that were applied during plank creation.
+// Other debug attachments of the same target pod have planks of their own, which are not listed.
+func (d *DebugController) listPlankPods(da *v1.DebugAttachment) ([]corev1.Pod, error) {
+	plankNamespace := da.GetPlank().GetPod().GetNamespace()
+	if plankNamespace == "" {
+		plankNamespace = os.Getenv(sqOpts.PlankEnvDebugSquashNamespace)
+	}
+	selector := labels.SelectorFromSet(sqOpts.GeneratePlankLabels(&core.ResourceRef{
+		Name:      da.Metadata.Name,
+		Namespace: da.Metadata.Namespace,
+	})).String()
+	planks, err := d.kubeClient.CoreV1().Pods(plankNamespace).List(metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing plank pods in namespace %v", plankNamespace)
+	}
+	return planks.Items, nil
+}
+
+// plank exits once it has detached its debugger
+func (d *DebugController) waitForPlankToDetach(plank corev1.Pod) error {
+	ctx, cancel := context.WithTimeout(d.ctx, sqOpts.PlankDetachTimeout)
+	defer cancel()
+	for {
+		pod, err := d.kubeClient.CoreV1().Pods(plank.Namespace).Get(plank.Name, metav1.GetOptions{})
+		if err != nil {
+			if kerrors.IsNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		if pod.Status.Phase != corev1.PodRunning && pod.Status.Phase != corev1.PodPending {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func (d *DebugController) clearPlank(namespace, name string) {
+	da, err := d.daClient.Read(namespace, name, clients.ReadOpts{Ctx: d.ctx})
 	if err != nil {
-		log.WithFields(log.Fields{"da.Name": da.Metadata.Name, "da.Namespace": da.Metadata.Namespace}).Warn("Failed to mark attachment for deletion.")
+		log.WithFields(log.Fields{"name": name, "namespace": namespace, "error": err}).Warn("Failed to read attachment after deleting its plank.")
+		return
+	}
+	da.Plank = nil
+	da.PortSpec = nil
+	if _, err := d.daClient.Write(da, clients.WriteOpts{Ctx: d.ctx, OverwriteExisting: true}); err != nil {
+		log.WithFields(log.Fields{"name": name, "namespace": namespace, "error": err}).Warn("Failed to record plank deletion.")
 	}
 }
 
@@ -127,8 +242,9 @@ func (d *DebugController) deleteResource(namespace, name string) {
 func (d *DebugController) markAsAttached(namespace, name string) {
 	da, err := d.daClient.Read(namespace, name, clients.ReadOpts{Ctx: d.ctx})
 	if err != nil {
-		log.WithFields(log.Fields{"da.Name": da.Metadata.Name, "da.Namespace": da.Metadata.Namespace}).Warn("Failed to read attachment prior to marking as attached.")
+		log.WithFields(log.Fields{"da.Name": name, "da.Namespace": namespace}).Warn("Failed to read attachment prior to marking as attached.")
 		d.markForDeletion(namespace, name)
+		return
 	}
 
 	da.State = v1.DebugAttachment_Attached
@@ -152,21 +268,27 @@ func (d *DebugController) tryToAttachPod(da *v1.DebugAttachment) error {
 	s.DebugContainerVersion = version.ImageVersion
 	s.DebugContainerRepo = version.ImageRepo
 
-	s.CRISock = "/var/run/dockershim.sock"
+	// set when the squash deployment was installed with a particular CRI socket, plank discovers it otherwise
+	s.CRISock = os.Getenv(sqOpts.EnvCRISocket)
+	plankTemplate, err := config.PlankPodTemplateFromEnv()
+	if err != nil {
+		return err
+	}
+	s.PlankTemplate = plankTemplate
 
-	s.Debugger = da.Debugger
-	s.Namespace = da.Metadata.Namespace
-	s.Pod = da.Pod
-	s.Container = da.Image
+	s.Debugger = da.Intent.GetDebugger()
+	s.Namespace = da.Intent.GetPod().GetNamespace()
+	s.Pod = da.Intent.GetPod().GetName()
+	s.Container = da.Intent.GetContainerName()
+	s.ProcessName = da.Intent.GetProcessMatcher()
+	s.MatchAllProcesses = da.Intent.GetMatchAllProcesses()
+	s.AdditionalContainers = da.Intent.GetAdditionalContainerNames()
+	s.FollowProcesses = da.Intent.GetFollowProcesses()
+	s.PlankBackend = da.Intent.GetPlankBackend()
 
 	s.SquashNamespace = os.Getenv(sqOpts.PlankEnvDebugSquashNamespace)
 
-	dbt := config.DebugTarget{}
-	// if err := s.ExpectToGetUniqueDebugTargetFromSpec(&dbt); err != nil {
-	// 	return err
-	// }
-	_, err := config.StartDebugContainer(s, dbt)
-	if err != nil {
+	if _, err := config.StartPlank(s); err != nil {
 		return err
 	}
 	d.markAsAttached(da.Metadata.Namespace, da.Metadata.Name)
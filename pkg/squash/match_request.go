@@ -0,0 +1,339 @@
+package squash
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/solo-io/solo-kit/pkg/api/v1/clients"
+	"github.com/solo-io/solo-kit/pkg/api/v1/resources/core"
+	v1 "github.com/solo-io/squash/pkg/api/v1"
+	sqOpts "github.com/solo-io/squash/pkg/options"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// how long to wait before watching matching pods again after an error
+const matchRequestRetryInterval = 5 * time.Second
+
+// handleMatchRequest starts watching for the pods that a match request selects, unless it is already doing so
+func (d *DebugController) handleMatchRequest(da *v1.DebugAttachment) {
+	namespace, name := da.Metadata.Namespace, da.Metadata.Name
+	key := namespace + "." + name
+	logger := log.WithFields(log.Fields{"da.Name": name, "da.Namespace": namespace})
+
+	d.debugattachmentsLock.Lock()
+	if _, ok := d.matchRequests[key]; ok {
+		d.debugattachmentsLock.Unlock()
+		return
+	}
+	ctx, cancel := context.WithCancel(d.ctx)
+	d.matchRequests[key] = cancel
+	d.debugattachmentsLock.Unlock()
+
+	selector, state, err := d.activateMatchRequest(da)
+	if err != nil {
+		logger.WithField("error", err).Warn("Invalid match request, deleting request.")
+		d.stopMatchRequest(key)
+		d.markForDeletion(namespace, name)
+		return
+	}
+	logger.WithField("selector", selector.String()).Info("Debugging new pods that match the selector")
+	go d.watchMatchingPods(ctx, da, selector, state)
+}
+
+// matchState is what a match request knows about the pods it selects
+type matchState struct {
+	// pods created earlier are not debugged
+	since time.Time
+	// pods that need no more attention, because they got a debug attachment or started before the match request
+	handled map[types.UID]bool
+	// pods that got a debug attachment, as recorded on the match request
+	matched map[types.UID]bool
+}
+
+func newMatchState(since time.Time, matched map[types.UID]bool) *matchState {
+	state := &matchState{
+		since:   since,
+		handled: make(map[types.UID]bool),
+		matched: matched,
+	}
+	for uid := range matched {
+		state.handled[uid] = true
+	}
+	return state
+}
+
+// activateMatchRequest validates a match request and records when it began matching pods
+func (d *DebugController) activateMatchRequest(da *v1.DebugAttachment) (labels.Selector, *matchState, error) {
+	if da.Intent.GetPod().GetNamespace() == "" {
+		return nil, nil, fmt.Errorf("match request %v does not specify a namespace", da.Metadata.Name)
+	}
+	selector, err := labels.Parse(da.Intent.GetPodSelector())
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "parsing pod selector")
+	}
+	if selector.Empty() {
+		return nil, nil, fmt.Errorf("match request %v does not specify a pod selector", da.Metadata.Name)
+	}
+
+	current, err := d.daClient.Read(da.Metadata.Namespace, da.Metadata.Name, clients.ReadOpts{Ctx: d.ctx})
+	if err != nil {
+		return nil, nil, err
+	}
+	since, err := time.Parse(time.RFC3339, current.Metadata.Annotations[sqOpts.MatchRequestSinceAnnotation])
+	if err == nil && current.State == v1.DebugAttachment_Attached {
+		// resumed after a squash restart, the pods that were matched before keep whatever happened to their attachments
+		return selector, newMatchState(since, matchedPodsOf(current)), nil
+	}
+	since = time.Now().UTC()
+	if current.Metadata.Annotations == nil {
+		current.Metadata.Annotations = make(map[string]string)
+	}
+	current.Metadata.Annotations[sqOpts.MatchRequestSinceAnnotation] = since.Format(time.RFC3339)
+	// a match request stays attached for as long as it is debugging new pods
+	current.State = v1.DebugAttachment_Attached
+	if _, err := d.daClient.Write(current, clients.WriteOpts{Ctx: d.ctx, OverwriteExisting: true}); err != nil {
+		return nil, nil, err
+	}
+	return selector, newMatchState(since, make(map[types.UID]bool)), nil
+}
+
+// watchMatchingPods creates a debug attachment for each matching pod, once the pod is ready to be debugged
+func (d *DebugController) watchMatchingPods(ctx context.Context, matchRequest *v1.DebugAttachment, selector labels.Selector, state *matchState) {
+	pods := d.kubeClient.CoreV1().Pods(matchRequest.Intent.Pod.Namespace)
+	for ctx.Err() == nil {
+		list, err := pods.List(metav1.ListOptions{LabelSelector: selector.String()})
+		if err != nil {
+			log.WithField("error", err).Warn("Failed to list pods for match request.")
+			sleep(ctx, matchRequestRetryInterval)
+			continue
+		}
+		d.forgetMissingPods(matchRequest, state, list.Items)
+		for i := range list.Items {
+			d.matchPod(matchRequest, &list.Items[i], state)
+		}
+
+		w, err := pods.Watch(metav1.ListOptions{LabelSelector: selector.String(), ResourceVersion: list.ResourceVersion})
+		if err != nil {
+			log.WithField("error", err).Warn("Failed to watch pods for match request.")
+			sleep(ctx, matchRequestRetryInterval)
+			continue
+		}
+		d.matchPodEvents(ctx, w, matchRequest, state)
+		w.Stop()
+	}
+}
+
+// matchPodEvents handles pod events until the watch ends
+func (d *DebugController) matchPodEvents(ctx context.Context, w watch.Interface, matchRequest *v1.DebugAttachment, state *matchState) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-w.ResultChan():
+			if !ok || event.Type == watch.Error {
+				return
+			}
+			pod, ok := event.Object.(*corev1.Pod)
+			if !ok {
+				continue
+			}
+			if event.Type == watch.Deleted {
+				d.forgetPod(matchRequest, state, pod.UID)
+				continue
+			}
+			d.matchPod(matchRequest, pod, state)
+		}
+	}
+}
+
+func (d *DebugController) matchPod(matchRequest *v1.DebugAttachment, pod *corev1.Pod, state *matchState) {
+	if state.handled[pod.UID] {
+		return
+	}
+	if pod.CreationTimestamp.Time.Before(state.since) {
+		// only pods that start after the match request are debugged
+		state.handled[pod.UID] = true
+		return
+	}
+	containerName := matchRequest.Intent.GetContainerName()
+	if containerName == "" && len(pod.Spec.Containers) > 0 {
+		containerName = pod.Spec.Containers[0].Name
+	}
+	if !readyToAttach(pod, containerName, matchRequest.Intent.GetPlankBackend()) {
+		return
+	}
+	if err := d.createMatchedAttachment(matchRequest, pod, containerName); err != nil {
+		log.WithFields(log.Fields{"pod": pod.Name, "error": err}).Warn("Failed to create debug attachment for matching pod.")
+		return
+	}
+	state.handled[pod.UID] = true
+	state.matched[pod.UID] = true
+	d.recordMatchedPods(matchRequest, state)
+}
+
+// readyToAttach tells whether a matched pod can get its debug attachment. A plank pod is started as soon as the
+// target pod is scheduled, so that it is ready when the target container starts and can debug its startup.
+// An ephemeral plank can only join a container that is already running.
+func readyToAttach(pod *corev1.Pod, containerName, plankBackend string) bool {
+	if plankBackend == sqOpts.PlankBackendEphemeral {
+		return containerRunning(pod, containerName)
+	}
+	if pod.Spec.NodeName == "" {
+		// plank runs on the target's node
+		return false
+	}
+	return pod.Status.Phase == corev1.PodPending || pod.Status.Phase == corev1.PodRunning
+}
+
+// forgetPod stops tracking a pod that was deleted
+func (d *DebugController) forgetPod(matchRequest *v1.DebugAttachment, state *matchState, uid types.UID) {
+	delete(state.handled, uid)
+	if state.matched[uid] {
+		delete(state.matched, uid)
+		d.recordMatchedPods(matchRequest, state)
+	}
+}
+
+// forgetMissingPods stops tracking the pods that were deleted while they were not watched
+func (d *DebugController) forgetMissingPods(matchRequest *v1.DebugAttachment, state *matchState, pods []corev1.Pod) {
+	existing := make(map[types.UID]bool)
+	for _, pod := range pods {
+		existing[pod.UID] = true
+	}
+	changed := false
+	for uid := range state.handled {
+		if existing[uid] {
+			continue
+		}
+		delete(state.handled, uid)
+		if state.matched[uid] {
+			delete(state.matched, uid)
+			changed = true
+		}
+	}
+	if changed {
+		d.recordMatchedPods(matchRequest, state)
+	}
+}
+
+// recordMatchedPods writes the pods that got a debug attachment to the match request
+func (d *DebugController) recordMatchedPods(matchRequest *v1.DebugAttachment, state *matchState) {
+	namespace, name := matchRequest.Metadata.Namespace, matchRequest.Metadata.Name
+	current, err := d.daClient.Read(namespace, name, clients.ReadOpts{Ctx: d.ctx})
+	if err != nil {
+		log.WithFields(log.Fields{"da.Name": name, "da.Namespace": namespace, "error": err}).Warn("Failed to read match request.")
+		return
+	}
+	var uids []string
+	for uid := range state.matched {
+		uids = append(uids, string(uid))
+	}
+	sort.Strings(uids)
+	if current.Metadata.Annotations == nil {
+		current.Metadata.Annotations = make(map[string]string)
+	}
+	current.Metadata.Annotations[sqOpts.MatchRequestMatchedPodsAnnotation] = strings.Join(uids, ",")
+	if _, err := d.daClient.Write(current, clients.WriteOpts{Ctx: d.ctx, OverwriteExisting: true}); err != nil {
+		log.WithFields(log.Fields{"da.Name": name, "da.Namespace": namespace, "error": err}).Warn("Failed to record matched pods.")
+	}
+}
+
+// matchedPodsOf reads the pods that got a debug attachment from a match request
+func matchedPodsOf(matchRequest *v1.DebugAttachment) map[types.UID]bool {
+	matched := make(map[types.UID]bool)
+	for _, uid := range strings.Split(matchRequest.Metadata.Annotations[sqOpts.MatchRequestMatchedPodsAnnotation], ",") {
+		if uid != "" {
+			matched[types.UID(uid)] = true
+		}
+	}
+	return matched
+}
+
+// createMatchedAttachment requests a debug session for a pod that a match request selected
+func (d *DebugController) createMatchedAttachment(matchRequest *v1.DebugAttachment, pod *corev1.Pod, containerName string) error {
+	intent := *matchRequest.Intent
+	intent.Pod = &core.ResourceRef{
+		Name:      pod.Name,
+		Namespace: pod.Namespace,
+	}
+	intent.ContainerName = containerName
+	intent.PodSelector = ""
+
+	name := fmt.Sprintf("%v-%v", matchRequest.Metadata.Name, pod.Name)
+	if _, err := d.daClient.Read(pod.Namespace, name, clients.ReadOpts{Ctx: d.ctx}); err == nil {
+		// already created, before a squash restart
+		return nil
+	}
+	daLabels := intent.GenerateLabels()
+	daLabels[sqOpts.MatchRequestLabelKey] = matchRequest.Metadata.Name
+	da := &v1.DebugAttachment{
+		Metadata: core.Metadata{
+			Name:      name,
+			Namespace: pod.Namespace,
+			Labels:    daLabels,
+			Annotations: map[string]string{
+				sqOpts.CreatedAtAnnotation: time.Now().UTC().Format(time.RFC3339),
+			},
+		},
+		Intent: &intent,
+		State:  v1.DebugAttachment_RequestingAttachment,
+	}
+	if _, err := d.daClient.Write(da, clients.WriteOpts{Ctx: d.ctx}); err != nil {
+		return err
+	}
+	log.WithFields(log.Fields{"pod": pod.Name, "da.Name": name, "matchRequest": matchRequest.Metadata.Name}).Info("Requested debug attachment for matching pod")
+	return nil
+}
+
+// handleMatchRequestDelete stops matching new pods, requests deletion of the debug attachments that were created
+// for matched pods, then deletes the match request
+func (d *DebugController) handleMatchRequestDelete(da *v1.DebugAttachment) {
+	namespace, name := da.Metadata.Namespace, da.Metadata.Name
+	key := namespace + "." + name
+	d.debugattachmentsLock.Lock()
+	if d.deletesInProgress[key] {
+		d.debugattachmentsLock.Unlock()
+		return
+	}
+	d.deletesInProgress[key] = true
+	d.debugattachmentsLock.Unlock()
+	defer func() {
+		d.debugattachmentsLock.Lock()
+		delete(d.deletesInProgress, key)
+		d.debugattachmentsLock.Unlock()
+	}()
+
+	d.stopMatchRequest(key)
+
+	matched, err := d.daClient.List(namespace, clients.ListOpts{
+		Ctx:      d.ctx,
+		Selector: map[string]string{sqOpts.MatchRequestLabelKey: name},
+	})
+	if err != nil {
+		log.WithFields(log.Fields{"da.Name": name, "da.Namespace": namespace, "error": err}).Warn("Failed to list debug attachments of match request.")
+	}
+	for _, m := range matched {
+		if m.State != v1.DebugAttachment_RequestingDelete && m.State != v1.DebugAttachment_PendingDelete {
+			d.markForDeletion(m.Metadata.Namespace, m.Metadata.Name)
+		}
+	}
+	d.deleteResource(namespace, name)
+}
+
+func (d *DebugController) stopMatchRequest(key string) {
+	d.debugattachmentsLock.Lock()
+	defer d.debugattachmentsLock.Unlock()
+	if cancel, ok := d.matchRequests[key]; ok {
+		cancel()
+		delete(d.matchRequests, key)
+	}
+}
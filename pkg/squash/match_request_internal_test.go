@@ -0,0 +1,252 @@
+package squash
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/solo-io/solo-kit/pkg/api/v1/clients"
+	"github.com/solo-io/solo-kit/pkg/api/v1/clients/factory"
+	"github.com/solo-io/solo-kit/pkg/api/v1/clients/memory"
+	"github.com/solo-io/solo-kit/pkg/api/v1/resources/core"
+	v1 "github.com/solo-io/squash/pkg/api/v1"
+	sqOpts "github.com/solo-io/squash/pkg/options"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("match requests", func() {
+	const namespace = "target"
+	var (
+		d            *DebugController
+		matchRequest *v1.DebugAttachment
+		since        time.Time
+	)
+
+	newPod := func(name string, created time.Time) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              name,
+				Namespace:         namespace,
+				UID:               types.UID(name + "-uid"),
+				CreationTimestamp: metav1.NewTime(created),
+				Labels:            map[string]string{"app": "web"},
+			},
+			Spec: corev1.PodSpec{
+				NodeName:   "node-1",
+				Containers: []corev1.Container{{Name: "web"}, {Name: "sidecar"}},
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodPending},
+		}
+	}
+
+	read := func(name string) (*v1.DebugAttachment, error) {
+		return d.daClient.Read(namespace, name, clients.ReadOpts{})
+	}
+
+	BeforeEach(func() {
+		daClient, err := v1.NewDebugAttachmentClient(&factory.MemoryResourceClientFactory{
+			Cache: memory.NewInMemoryResourceCache(),
+		})
+		Expect(err).NotTo(HaveOccurred())
+		d = NewDebugController(context.Background(), nil, daClient, fake.NewSimpleClientset())
+
+		matchRequest, err = daClient.Write(&v1.DebugAttachment{
+			Metadata: core.Metadata{Name: "mr", Namespace: namespace},
+			Intent: &v1.Intent{
+				Pod:            &core.ResourceRef{Namespace: namespace},
+				PodSelector:    "app=web",
+				Debugger:       "dlv",
+				ProcessMatcher: "server",
+			},
+			MatchRequest: true,
+			State:        v1.DebugAttachment_RequestingAttachment,
+		}, clients.WriteOpts{})
+		Expect(err).NotTo(HaveOccurred())
+		var state *matchState
+		_, state, err = d.activateMatchRequest(matchRequest)
+		Expect(err).NotTo(HaveOccurred())
+		since = state.since
+	})
+
+	Describe("matchPod", func() {
+		var state *matchState
+
+		BeforeEach(func() {
+			state = newMatchState(since, make(map[types.UID]bool))
+		})
+
+		It("should attach to a pod as soon as it is scheduled, before its containers run", func() {
+			pod := newPod("web-1", since.Add(time.Minute))
+			d.matchPod(matchRequest, pod, state)
+
+			da, err := read("mr-web-1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(da.State).To(Equal(v1.DebugAttachment_RequestingAttachment))
+			Expect(state.handled[pod.UID]).To(BeTrue())
+
+			current, err := read("mr")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(current.Metadata.Annotations[sqOpts.MatchRequestMatchedPodsAnnotation]).To(Equal("web-1-uid"))
+		})
+
+		It("should wait for a pod to be scheduled", func() {
+			pod := newPod("web-1", since.Add(time.Minute))
+			pod.Spec.NodeName = ""
+			d.matchPod(matchRequest, pod, state)
+
+			_, err := read("mr-web-1")
+			Expect(err).To(HaveOccurred())
+			Expect(state.handled[pod.UID]).To(BeFalse())
+
+			pod.Spec.NodeName = "node-1"
+			d.matchPod(matchRequest, pod, state)
+			_, err = read("mr-web-1")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should wait for the target container to run with the ephemeral backend", func() {
+			matchRequest.Intent.PlankBackend = sqOpts.PlankBackendEphemeral
+			pod := newPod("web-1", since.Add(time.Minute))
+			d.matchPod(matchRequest, pod, state)
+			_, err := read("mr-web-1")
+			Expect(err).To(HaveOccurred())
+
+			pod.Status.Phase = corev1.PodRunning
+			pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+				Name:  "web",
+				State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+			}}
+			d.matchPod(matchRequest, pod, state)
+			_, err = read("mr-web-1")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should not debug pods that started before the match request", func() {
+			pod := newPod("web-0", since.Add(-time.Minute))
+			d.matchPod(matchRequest, pod, state)
+
+			_, err := read("mr-web-0")
+			Expect(err).To(HaveOccurred())
+			Expect(state.handled[pod.UID]).To(BeTrue())
+			Expect(state.matched).To(BeEmpty())
+		})
+
+		It("should not attach to finished pods", func() {
+			pod := newPod("web-1", since.Add(time.Minute))
+			pod.Status.Phase = corev1.PodSucceeded
+			d.matchPod(matchRequest, pod, state)
+			_, err := read("mr-web-1")
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should not recreate a deleted attachment after a squash restart", func() {
+			pod := newPod("web-1", since.Add(time.Minute))
+			d.matchPod(matchRequest, pod, state)
+			Expect(d.daClient.Delete(namespace, "mr-web-1", clients.DeleteOpts{})).To(Succeed())
+
+			// squash restarts, and resumes the match request from what it recorded
+			d = NewDebugController(context.Background(), nil, d.daClient, fake.NewSimpleClientset())
+			current, err := read("mr")
+			Expect(err).NotTo(HaveOccurred())
+			_, resumed, err := d.activateMatchRequest(current)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resumed.since.Unix()).To(Equal(since.Unix()))
+			d.matchPod(current, pod, resumed)
+
+			_, err = read("mr-web-1")
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should forget deleted pods", func() {
+			kept := newPod("web-1", since.Add(time.Minute))
+			deleted := newPod("web-2", since.Add(time.Minute))
+			missing := newPod("web-3", since.Add(time.Minute))
+			for _, pod := range []*corev1.Pod{kept, deleted, missing} {
+				d.matchPod(matchRequest, pod, state)
+			}
+
+			d.forgetPod(matchRequest, state, deleted.UID)
+			d.forgetMissingPods(matchRequest, state, []corev1.Pod{*kept})
+
+			Expect(state.handled).To(Equal(map[types.UID]bool{kept.UID: true}))
+			Expect(state.matched).To(Equal(map[types.UID]bool{kept.UID: true}))
+			current, err := read("mr")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(matchedPodsOf(current)).To(Equal(map[types.UID]bool{kept.UID: true}))
+		})
+	})
+
+	Describe("createMatchedAttachment", func() {
+		It("should debug the matched pod with the match request's intent", func() {
+			pod := newPod("web-1", since.Add(time.Minute))
+			Expect(d.createMatchedAttachment(matchRequest, pod, "web")).To(Succeed())
+
+			da, err := read("mr-web-1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(da.MatchRequest).To(BeFalse())
+			Expect(da.Intent.Pod.Name).To(Equal("web-1"))
+			Expect(da.Intent.Pod.Namespace).To(Equal(namespace))
+			Expect(da.Intent.ContainerName).To(Equal("web"))
+			Expect(da.Intent.PodSelector).To(BeEmpty())
+			Expect(da.Intent.Debugger).To(Equal("dlv"))
+			Expect(da.Intent.ProcessMatcher).To(Equal("server"))
+			Expect(da.Metadata.Labels[sqOpts.MatchRequestLabelKey]).To(Equal("mr"))
+			Expect(da.Metadata.Annotations).To(HaveKey(sqOpts.CreatedAtAnnotation))
+
+			// the match request itself is left as it was
+			Expect(matchRequest.Intent.Pod.Name).To(BeEmpty())
+			Expect(matchRequest.Intent.PodSelector).To(Equal("app=web"))
+		})
+
+		It("should keep an attachment that already exists", func() {
+			pod := newPod("web-1", since.Add(time.Minute))
+			Expect(d.createMatchedAttachment(matchRequest, pod, "web")).To(Succeed())
+			d.setState(namespace, "mr-web-1", v1.DebugAttachment_Attached)
+
+			Expect(d.createMatchedAttachment(matchRequest, pod, "web")).To(Succeed())
+			da, err := read("mr-web-1")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(da.State).To(Equal(v1.DebugAttachment_Attached))
+		})
+	})
+
+	Describe("handleMatchRequestDelete", func() {
+		It("should request deletion of the matched attachments, then delete the match request", func() {
+			state := newMatchState(since, make(map[types.UID]bool))
+			d.matchPod(matchRequest, newPod("web-1", since.Add(time.Minute)), state)
+			d.matchPod(matchRequest, newPod("web-2", since.Add(time.Minute)), state)
+			d.setState(namespace, "mr-web-2", v1.DebugAttachment_PendingDelete)
+			_, err := d.daClient.Write(&v1.DebugAttachment{
+				Metadata: core.Metadata{Name: "unrelated", Namespace: namespace},
+				Intent:   &v1.Intent{Pod: &core.ResourceRef{Name: "web-1", Namespace: namespace}},
+				State:    v1.DebugAttachment_Attached,
+			}, clients.WriteOpts{})
+			Expect(err).NotTo(HaveOccurred())
+
+			d.handleMatchRequestDelete(matchRequest)
+
+			_, err = read("mr")
+			Expect(err).To(HaveOccurred())
+			for name, state := range map[string]v1.DebugAttachment_State{
+				"mr-web-1":  v1.DebugAttachment_RequestingDelete,
+				"mr-web-2":  v1.DebugAttachment_PendingDelete,
+				"unrelated": v1.DebugAttachment_Attached,
+			} {
+				da, err := read(name)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(da.State).To(Equal(state), name)
+			}
+		})
+
+		It("should stop matching new pods", func() {
+			d.handleMatchRequest(matchRequest)
+			Expect(d.matchRequests).To(HaveLen(1))
+			d.handleMatchRequestDelete(matchRequest)
+			Expect(d.matchRequests).To(BeEmpty())
+		})
+	})
+})
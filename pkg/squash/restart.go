@@ -0,0 +1,284 @@
+package squash
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/solo-io/solo-kit/pkg/api/v1/clients"
+	"github.com/solo-io/solo-kit/pkg/api/v1/resources/core"
+	v1 "github.com/solo-io/squash/pkg/api/v1"
+	sqOpts "github.com/solo-io/squash/pkg/options"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// how long to wait before watching a target pod again after an error
+const restartWatchRetryInterval = 5 * time.Second
+
+// handleReattachOnRestart starts watching the target pod of an attached debug attachment for container restarts,
+// unless it is already doing so. The watch outlives the planks it replaces, it stops when the attachment is deleted.
+func (d *DebugController) handleReattachOnRestart(da *v1.DebugAttachment) {
+	namespace, name := da.Metadata.Namespace, da.Metadata.Name
+	key := namespace + "." + name
+
+	d.debugattachmentsLock.Lock()
+	if _, ok := d.restartWatches[key]; ok || d.deletesInProgress[key] {
+		d.debugattachmentsLock.Unlock()
+		return
+	}
+	ctx, cancel := context.WithCancel(d.ctx)
+	d.restartWatches[key] = cancel
+	d.debugattachmentsLock.Unlock()
+
+	log.WithFields(log.Fields{"da.Name": name, "da.Namespace": namespace}).Info("Watching target pod for container restarts")
+	go d.watchForRestarts(ctx, da)
+}
+
+func (d *DebugController) stopRestartWatch(key string) {
+	d.debugattachmentsLock.Lock()
+	defer d.debugattachmentsLock.Unlock()
+	if cancel, ok := d.restartWatches[key]; ok {
+		cancel()
+		delete(d.restartWatches, key)
+	}
+}
+
+// watchForRestarts re-attaches the debug attachment whenever one of its target containers restarts
+func (d *DebugController) watchForRestarts(ctx context.Context, da *v1.DebugAttachment) {
+	target := da.Intent.GetPod()
+	logger := log.WithFields(log.Fields{"da.Name": da.Metadata.Name, "da.Namespace": da.Metadata.Namespace, "pod": target.GetName()})
+	pods := d.kubeClient.CoreV1().Pods(target.GetNamespace())
+
+	var restartCounts map[string]int32
+	for ctx.Err() == nil {
+		current, err := pods.Get(target.GetName(), metav1.GetOptions{})
+		if err != nil {
+			if kerrors.IsNotFound(err) {
+				logger.Warn("Target pod was deleted, no longer watching for restarts.")
+				return
+			}
+			logger.WithField("error", err).Warn("Failed to read target pod.")
+			sleep(ctx, restartWatchRetryInterval)
+			continue
+		}
+		if restartCounts == nil {
+			// the restarts that happened before the session began are not re-attached
+			restartCounts = restartCountsOf(current, targetContainers(da))
+		}
+
+		w, err := pods.Watch(metav1.ListOptions{
+			FieldSelector:   fields.OneTermEqualSelector("metadata.name", target.GetName()).String(),
+			ResourceVersion: current.ResourceVersion,
+		})
+		if err != nil {
+			logger.WithField("error", err).Warn("Failed to watch target pod.")
+			sleep(ctx, restartWatchRetryInterval)
+			continue
+		}
+		// the current pod resynchronizes after the watch ended
+		if deleted := d.restartEvents(ctx, w, da, current, restartCounts); deleted {
+			w.Stop()
+			logger.Warn("Target pod was deleted, no longer watching for restarts.")
+			return
+		}
+		w.Stop()
+	}
+}
+
+// restartEvents handles target pod events, starting with the given pod, until the watch ends.
+// A restart that cannot be re-attached yet is checked again later. It returns true if the pod was deleted.
+func (d *DebugController) restartEvents(ctx context.Context, w watch.Interface, da *v1.DebugAttachment, pod *corev1.Pod, restartCounts map[string]int32) bool {
+	var retry <-chan time.Time
+	check := func() {
+		retry = nil
+		if !d.checkRestarts(da, pod, restartCounts) {
+			retry = time.After(restartWatchRetryInterval)
+		}
+	}
+	check()
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-retry:
+			check()
+		case event, ok := <-w.ResultChan():
+			if !ok || event.Type == watch.Error {
+				return false
+			}
+			if event.Type == watch.Deleted {
+				return true
+			}
+			current, ok := event.Object.(*corev1.Pod)
+			if !ok {
+				continue
+			}
+			pod = current
+			check()
+		}
+	}
+}
+
+// checkRestarts re-attaches the debug attachment once, however many of its containers restarted.
+// The restart counts are only recorded once the re-attachment is requested, so it returns false if it should be
+// checked again later.
+func (d *DebugController) checkRestarts(da *v1.DebugAttachment, pod *corev1.Pod, restartCounts map[string]int32) bool {
+	restarted := restartedContainers(pod, restartCounts)
+	if len(restarted) == 0 {
+		return true
+	}
+	logger := log.WithFields(log.Fields{"da.Name": da.Metadata.Name, "da.Namespace": da.Metadata.Namespace, "containers": restarted})
+	logger.Info("Target containers restarted, re-attaching debugger")
+	done, err := d.reattach(da.Metadata.Namespace, da.Metadata.Name)
+	if err != nil {
+		logger.WithField("error", err).Warn("Failed to re-attach debugger, will retry.")
+		return false
+	}
+	if !done {
+		logger.Info("Debug attachment is not attached yet, will re-attach once it is.")
+		return false
+	}
+	for _, name := range restarted {
+		restartCounts[name] = restartCount(pod, name)
+	}
+	return true
+}
+
+// reattach replaces the plank of a debug attachment whose target restarted.
+// The old plank is stopped first, so that it cannot publish its processes over the new ones,
+// then the attachment is requested again, as if it had just been created.
+// It returns false if the attachment is not attached yet, it is then re-attached once it is.
+func (d *DebugController) reattach(namespace, name string) (bool, error) {
+	da, err := d.daClient.Read(namespace, name, clients.ReadOpts{Ctx: d.ctx})
+	if err != nil {
+		return false, err
+	}
+	if isBeingDeleted(da) {
+		return true, nil
+	}
+	if da.State != v1.DebugAttachment_Attached {
+		// a plank that is still attaching may have found the process that is gone by now
+		return false, nil
+	}
+
+	recorded := false
+	if container := da.GetPlank().GetEphemeralContainer(); container != "" {
+		// ephemeral containers cannot be deleted, the plank in one stops once it sees that it was replaced
+		recordReattachment(da, time.Now())
+		if _, err := d.daClient.Write(da, clients.WriteOpts{Ctx: d.ctx, OverwriteExisting: true}); err != nil {
+			return false, err
+		}
+		recorded = true
+		if err := d.waitForEphemeralPlankToDetach(da.GetPlank().GetPod(), namespace, name, container); err != nil {
+			log.WithFields(log.Fields{"plank": container, "error": err}).Warn("Ephemeral plank did not stop in time.")
+		}
+	} else {
+		planks, err := d.listPlankPods(da)
+		if err != nil {
+			return false, err
+		}
+		for _, plank := range planks {
+			if err := d.kubeClient.CoreV1().Pods(plank.Namespace).Delete(plank.Name, &metav1.DeleteOptions{}); err != nil && !kerrors.IsNotFound(err) {
+				return false, err
+			}
+			if err := d.waitForPlankToDetach(plank); err != nil {
+				log.WithFields(log.Fields{"plank": plank.Name, "error": err}).Warn("Plank was not deleted in time.")
+			}
+		}
+	}
+
+	// read again, the old plank may have written to it before it stopped
+	da, err = d.daClient.Read(namespace, name, clients.ReadOpts{Ctx: d.ctx})
+	if err != nil {
+		return false, err
+	}
+	if isBeingDeleted(da) {
+		return true, nil
+	}
+	if !recorded {
+		recordReattachment(da, time.Now())
+	}
+	da.Plank = nil
+	da.PortSpec = nil
+	da.AttachedProcesses = nil
+	da.DebugServerAddress = ""
+	da.State = v1.DebugAttachment_RequestingAttachment
+	if _, err := d.daClient.Write(da, clients.WriteOpts{Ctx: d.ctx, OverwriteExisting: true}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// waitForEphemeralPlankToDetach waits until the ephemeral plank has detached, which it records on the debug attachment,
+// or until its container stopped, as it does when the target restarted along with the whole pod
+func (d *DebugController) waitForEphemeralPlankToDetach(pod *core.ResourceRef, namespace, name, container string) error {
+	ctx, cancel := context.WithTimeout(d.ctx, sqOpts.PlankDetachTimeout)
+	defer cancel()
+	for {
+		da, err := d.daClient.Read(namespace, name, clients.ReadOpts{Ctx: ctx})
+		if err != nil {
+			return err
+		}
+		if da.GetPlank().GetEphemeralContainer() != container || !da.GetPlank().GetReadyForConnect() {
+			return nil
+		}
+		running, err := d.ephemeralContainerRunning(pod.GetNamespace(), pod.GetName(), container)
+		if err != nil {
+			return err
+		}
+		if !running {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func isBeingDeleted(da *v1.DebugAttachment) bool {
+	return da.State == v1.DebugAttachment_RequestingDelete || da.State == v1.DebugAttachment_PendingDelete
+}
+
+// recordReattachment counts a re-attachment of the debug attachment, made at the given time
+func recordReattachment(da *v1.DebugAttachment, now time.Time) {
+	if da.Reattachments == nil {
+		da.Reattachments = &v1.Reattachments{}
+	}
+	da.Reattachments.Count++
+	da.Reattachments.LastTime = now.UTC().Format(time.RFC3339)
+}
+
+// targetContainers lists the containers of the target pod that a debug attachment debugs
+func targetContainers(da *v1.DebugAttachment) []string {
+	return append([]string{da.Intent.GetContainerName()}, da.Intent.GetAdditionalContainerNames()...)
+}
+
+func restartCountsOf(pod *corev1.Pod, containerNames []string) map[string]int32 {
+	counts := make(map[string]int32)
+	for _, name := range containerNames {
+		counts[name] = restartCount(pod, name)
+	}
+	return counts
+}
+
+// restartedContainers returns the containers that restarted since their counts were recorded and are running again.
+// A container that is still starting is returned by a later call, once it runs.
+func restartedContainers(pod *corev1.Pod, restartCounts map[string]int32) []string {
+	var restarted []string
+	for name, seen := range restartCounts {
+		count := restartCount(pod, name)
+		if count <= seen || !containerRunning(pod, name) {
+			continue
+		}
+		restarted = append(restarted, name)
+	}
+	sort.Strings(restarted)
+	return restarted
+}
@@ -0,0 +1,281 @@
+package squash
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/solo-io/solo-kit/pkg/api/v1/clients"
+	"github.com/solo-io/solo-kit/pkg/api/v1/clients/factory"
+	"github.com/solo-io/solo-kit/pkg/api/v1/clients/memory"
+	"github.com/solo-io/solo-kit/pkg/api/v1/resources/core"
+	v1 "github.com/solo-io/squash/pkg/api/v1"
+	sqOpts "github.com/solo-io/squash/pkg/options"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// podWithStatuses describes a target pod by the restart count of each container, negative counts are not running
+func podWithStatuses(counts map[string]int32) *corev1.Pod {
+	pod := &corev1.Pod{}
+	for name, count := range counts {
+		status := corev1.ContainerStatus{Name: name}
+		if count < 0 {
+			status.RestartCount = -count
+			status.State.Waiting = &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}
+		} else {
+			status.RestartCount = count
+			status.State.Running = &corev1.ContainerStateRunning{}
+		}
+		pod.Status.ContainerStatuses = append(pod.Status.ContainerStatuses, status)
+	}
+	return pod
+}
+
+var _ = Describe("restart detection", func() {
+	It("should only report containers that restarted and run again", func() {
+		for _, c := range []struct {
+			description string
+			seen        map[string]int32
+			pod         map[string]int32
+			restarted   []string
+		}{
+			{
+				description: "no restarts",
+				seen:        map[string]int32{"app": 0},
+				pod:         map[string]int32{"app": 0},
+			},
+			{
+				description: "restarted and running",
+				seen:        map[string]int32{"app": 0},
+				pod:         map[string]int32{"app": 1},
+				restarted:   []string{"app"},
+			},
+			{
+				description: "restarted but still starting",
+				seen:        map[string]int32{"app": 0},
+				pod:         map[string]int32{"app": -1},
+			},
+			{
+				description: "several restarts between events",
+				seen:        map[string]int32{"app": 1},
+				pod:         map[string]int32{"app": 3},
+				restarted:   []string{"app"},
+			},
+			{
+				description: "only the watched containers",
+				seen:        map[string]int32{"app": 0, "worker": 2},
+				pod:         map[string]int32{"app": 0, "worker": 3, "sidecar": 5},
+				restarted:   []string{"worker"},
+			},
+			{
+				description: "several containers",
+				seen:        map[string]int32{"worker": 0, "app": 0},
+				pod:         map[string]int32{"app": 1, "worker": 1},
+				restarted:   []string{"app", "worker"},
+			},
+			{
+				description: "container missing from the status",
+				seen:        map[string]int32{"app": 0},
+				pod:         map[string]int32{},
+			},
+		} {
+			seen := make(map[string]int32)
+			for name, count := range c.seen {
+				seen[name] = count
+			}
+			restarted := restartedContainers(podWithStatuses(c.pod), c.seen)
+			Expect(restarted).To(Equal(c.restarted), c.description)
+			Expect(c.seen).To(Equal(seen), c.description)
+		}
+	})
+
+	It("should report a container once it runs after restarting", func() {
+		seen := restartCountsOf(podWithStatuses(map[string]int32{"app": 0}), []string{"app"})
+		Expect(restartedContainers(podWithStatuses(map[string]int32{"app": -1}), seen)).To(BeEmpty())
+		Expect(restartedContainers(podWithStatuses(map[string]int32{"app": 1}), seen)).To(Equal([]string{"app"}))
+		Expect(restartedContainers(podWithStatuses(map[string]int32{"app": 1}), seen)).To(Equal([]string{"app"}))
+	})
+
+	It("should watch the target container and the additional containers", func() {
+		da := &v1.DebugAttachment{Intent: &v1.Intent{ContainerName: "app", AdditionalContainerNames: []string{"worker"}}}
+		Expect(targetContainers(da)).To(Equal([]string{"app", "worker"}))
+	})
+})
+
+var _ = Describe("recordReattachment", func() {
+	It("should count re-attachments and record the last one", func() {
+		da := &v1.DebugAttachment{}
+		first := time.Date(2019, 3, 4, 5, 6, 7, 0, time.FixedZone("UTC+2", 2*60*60))
+		recordReattachment(da, first)
+		Expect(da.Reattachments).To(Equal(&v1.Reattachments{Count: 1, LastTime: "2019-03-04T03:06:07Z"}))
+
+		recordReattachment(da, first.Add(90*time.Second))
+		Expect(da.Reattachments.Count).To(BeEquivalentTo(2))
+		Expect(da.Reattachments.LastTime).To(Equal("2019-03-04T03:07:37Z"))
+	})
+})
+
+var _ = Describe("reattach", func() {
+	const (
+		namespace       = "target"
+		squashNamespace = "squash-debugger"
+		name            = "session"
+	)
+	var (
+		d          *DebugController
+		kubeClient *fake.Clientset
+	)
+
+	plankPod := func(podName string, da *core.ResourceRef) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:      podName,
+			Namespace: squashNamespace,
+			Labels:    sqOpts.GeneratePlankLabels(da),
+		}}
+	}
+
+	BeforeEach(func() {
+		daClient, err := v1.NewDebugAttachmentClient(&factory.MemoryResourceClientFactory{
+			Cache: memory.NewInMemoryResourceCache(),
+		})
+		Expect(err).NotTo(HaveOccurred())
+		kubeClient = fake.NewSimpleClientset(
+			plankPod("plank-session", &core.ResourceRef{Name: name, Namespace: namespace}),
+			plankPod("plank-other", &core.ResourceRef{Name: "other", Namespace: namespace}),
+		)
+		d = NewDebugController(context.Background(), nil, daClient, kubeClient)
+	})
+
+	write := func(state v1.DebugAttachment_State) {
+		da := &v1.DebugAttachment{
+			Metadata: core.Metadata{Name: name, Namespace: namespace},
+			Intent: &v1.Intent{
+				Pod:               &core.ResourceRef{Name: "app-1234", Namespace: namespace},
+				ContainerName:     "app",
+				ReattachOnRestart: true,
+			},
+			Plank: &v1.Plank{
+				Pod:             &core.ResourceRef{Name: "plank-session", Namespace: squashNamespace},
+				ReadyForConnect: true,
+			},
+			PortSpec:          v1.NewPlankPortSpec(2345),
+			AttachedProcesses: []*v1.AttachedProcess{{ContainerName: "app", Pid: 7, PortSpec: v1.NewPlankPortSpec(2345)}},
+			State:             state,
+		}
+		_, err := d.daClient.Write(da, clients.WriteOpts{})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	reattach := func() bool {
+		done, err := d.reattach(namespace, name)
+		Expect(err).NotTo(HaveOccurred())
+		return done
+	}
+
+	It("should replace the plank and request the attachment again", func() {
+		write(v1.DebugAttachment_Attached)
+		Expect(reattach()).To(BeTrue())
+
+		_, err := kubeClient.CoreV1().Pods(squashNamespace).Get("plank-session", metav1.GetOptions{})
+		Expect(kerrors.IsNotFound(err)).To(BeTrue())
+		_, err = kubeClient.CoreV1().Pods(squashNamespace).Get("plank-other", metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+
+		da, err := d.daClient.Read(namespace, name, clients.ReadOpts{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(da.State).To(Equal(v1.DebugAttachment_RequestingAttachment))
+		Expect(da.Plank).To(BeNil())
+		Expect(da.PortSpec).To(BeNil())
+		Expect(da.AttachedProcesses).To(BeEmpty())
+		Expect(da.Reattachments.Count).To(BeEquivalentTo(1))
+		Expect(da.Intent.GetReattachOnRestart()).To(BeTrue())
+
+		// the next restart is counted on top
+		da.State = v1.DebugAttachment_Attached
+		_, err = d.daClient.Write(da, clients.WriteOpts{OverwriteExisting: true})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(reattach()).To(BeTrue())
+		da, err = d.daClient.Read(namespace, name, clients.ReadOpts{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(da.Reattachments.Count).To(BeEquivalentTo(2))
+	})
+
+	It("should leave attachments that are being deleted alone", func() {
+		write(v1.DebugAttachment_PendingDelete)
+		Expect(reattach()).To(BeTrue())
+
+		_, err := kubeClient.CoreV1().Pods(squashNamespace).Get("plank-session", metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		da, err := d.daClient.Read(namespace, name, clients.ReadOpts{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(da.State).To(Equal(v1.DebugAttachment_PendingDelete))
+		Expect(da.Reattachments).To(BeNil())
+	})
+
+	It("should re-attach later when the attachment is not attached yet", func() {
+		write(v1.DebugAttachment_PendingAttachment)
+		target := &v1.DebugAttachment{
+			Metadata: core.Metadata{Name: name, Namespace: namespace},
+			Intent:   &v1.Intent{ContainerName: "app"},
+		}
+		counts := map[string]int32{"app": 0}
+		restarted := podWithStatuses(map[string]int32{"app": 1})
+
+		Expect(d.checkRestarts(target, restarted, counts)).To(BeFalse())
+		Expect(counts).To(Equal(map[string]int32{"app": 0}))
+		_, err := kubeClient.CoreV1().Pods(squashNamespace).Get("plank-session", metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+
+		da, err := d.daClient.Read(namespace, name, clients.ReadOpts{})
+		Expect(err).NotTo(HaveOccurred())
+		da.State = v1.DebugAttachment_Attached
+		_, err = d.daClient.Write(da, clients.WriteOpts{OverwriteExisting: true})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(d.checkRestarts(target, restarted, counts)).To(BeTrue())
+		Expect(counts).To(Equal(map[string]int32{"app": 1}))
+		da, err = d.daClient.Read(namespace, name, clients.ReadOpts{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(da.State).To(Equal(v1.DebugAttachment_RequestingAttachment))
+		Expect(da.Reattachments.Count).To(BeEquivalentTo(1))
+	})
+
+	It("should stop an ephemeral plank before requesting the attachment again", func() {
+		write(v1.DebugAttachment_Attached)
+		da, err := d.daClient.Read(namespace, name, clients.ReadOpts{})
+		Expect(err).NotTo(HaveOccurred())
+		da.Plank = &v1.Plank{
+			Pod:                &core.ResourceRef{Name: "app-1234", Namespace: namespace},
+			EphemeralContainer: "plank-session",
+			ReadyForConnect:    true,
+		}
+		_, err = d.daClient.Write(da, clients.WriteOpts{OverwriteExisting: true})
+		Expect(err).NotTo(HaveOccurred())
+
+		// the old plank runs until it sees the re-attachment
+		var checked []string
+		d.ephemeralContainerRunning = func(namespace, pod, container string) (bool, error) {
+			checked = append(checked, namespace+"/"+pod+"/"+container)
+			current, err := d.daClient.Read(namespace, name, clients.ReadOpts{})
+			if err != nil {
+				return false, err
+			}
+			return current.Reattachments == nil, nil
+		}
+		Expect(reattach()).To(BeTrue())
+		Expect(checked).To(Equal([]string{namespace + "/app-1234/plank-session"}))
+
+		// plank pods are left alone
+		_, err = kubeClient.CoreV1().Pods(squashNamespace).Get("plank-session", metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		da, err = d.daClient.Read(namespace, name, clients.ReadOpts{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(da.State).To(Equal(v1.DebugAttachment_RequestingAttachment))
+		Expect(da.Plank).To(BeNil())
+		Expect(da.Reattachments.Count).To(BeEquivalentTo(1))
+	})
+})
@@ -46,7 +46,7 @@ func RunSquash(debugger func(string) remote.Remote) error {
 		return err
 	}
 
-	return NewDebugHandler(ctx, watchNamespaces, daClient, debugger).handleAttachments()
+	return NewDebugHandler(ctx, watchNamespaces, daClient, kubeResClient, debugger).handleAttachments()
 }
 
 type DebugHandler struct {
@@ -62,7 +62,7 @@ type DebugHandler struct {
 	attachments []*v1.DebugAttachment
 }
 
-func NewDebugHandler(ctx context.Context, watchNamespaces []string, daClient v1.DebugAttachmentClient, debugger func(string) remote.Remote) *DebugHandler {
+func NewDebugHandler(ctx context.Context, watchNamespaces []string, daClient v1.DebugAttachmentClient, kubeClient kubernetes.Interface, debugger func(string) remote.Remote) *DebugHandler {
 	dbghandler := &DebugHandler{
 		ctx:             ctx,
 		daClient:        daClient,
@@ -70,7 +70,7 @@ func NewDebugHandler(ctx context.Context, watchNamespaces []string, daClient v1.
 		watchNamespaces: watchNamespaces,
 	}
 
-	dbghandler.debugController = NewDebugController(ctx, debugger, daClient)
+	dbghandler.debugController = NewDebugController(ctx, debugger, daClient, kubeClient)
 	return dbghandler
 }
 
@@ -96,6 +96,7 @@ func (d *DebugHandler) handleAttachments() error {
 func (d *DebugHandler) Sync(ctx context.Context, snapshot *v1.ApiSnapshot) error {
 	log.Debug("running sync")
 	daList := snapshot.Debugattachments
+	daList.ConvertDeprecatedFields()
 	for _, da := range daList {
 		if err := d.syncOne(da); err != nil {
 			return err
@@ -105,6 +106,9 @@ func (d *DebugHandler) Sync(ctx context.Context, snapshot *v1.ApiSnapshot) error
 }
 
 func (d *DebugHandler) syncOne(da *v1.DebugAttachment) error {
+	if da.MatchRequest {
+		return d.syncMatchRequest(da)
+	}
 	switch da.State {
 	case v1.DebugAttachment_RequestingAttachment:
 		log.Debugf("handling requesting attachment %v", da)
@@ -116,22 +120,40 @@ func (d *DebugHandler) syncOne(da *v1.DebugAttachment) error {
 		return nil
 	case v1.DebugAttachment_Attached:
 		log.Debug("handling attached")
-		// do nothing, this is "steady state"
+		// this is "steady state", unless the target containers are watched for restarts
+		if da.Intent.GetReattachOnRestart() {
+			d.debugController.handleReattachOnRestart(da)
+		}
 		return nil
 	case v1.DebugAttachment_RequestingDelete:
-		log.Debug("handling requesting delete")
-		// DO NOTHING - Will refactor this
-		// log.WithFields(log.Fields{"attachment.Name": da.Metadata.Name}).Debug("Removing attachment")
-		// go func() { d.debugController.removeAttachment(da.Metadata.Namespace, da.Metadata.Name) }()
+		log.WithFields(log.Fields{"attachment.Name": da.Metadata.Name}).Debug("handling requesting delete")
+		go d.debugController.handleDeleteRequest(da)
 		return nil
 	case v1.DebugAttachment_PendingDelete:
 		log.Debug("handling pending delete")
-		// DO NOTHING - Will refactor this
-		// d.debugController.deleteResource(da.Metadata.Namespace, da.Metadata.Name)
-		// do nothing, will transition out of this state according to the result of the RequestingDelete handler
+		// normally a no-op since the RequestingDelete handler is already running,
+		// but this resumes deletions that were interrupted by a squash restart
+		go d.debugController.handleDeleteRequest(da)
 		return nil
 	default:
 		return fmt.Errorf("DebugAttachment state not recognized: %v", da.State)
 	}
 	return nil
 }
+
+// match requests do not debug a pod themselves, they create debug attachments for the pods they match
+func (d *DebugHandler) syncMatchRequest(da *v1.DebugAttachment) error {
+	switch da.State {
+	case v1.DebugAttachment_RequestingAttachment, v1.DebugAttachment_PendingAttachment, v1.DebugAttachment_Attached:
+		log.WithFields(log.Fields{"attachment.Name": da.Metadata.Name}).Debug("handling match request")
+		// also resumes match requests after a squash restart
+		d.debugController.handleMatchRequest(da)
+		return nil
+	case v1.DebugAttachment_RequestingDelete, v1.DebugAttachment_PendingDelete:
+		log.WithFields(log.Fields{"attachment.Name": da.Metadata.Name}).Debug("handling match request delete")
+		go d.debugController.handleMatchRequestDelete(da)
+		return nil
+	default:
+		return fmt.Errorf("DebugAttachment state not recognized: %v", da.State)
+	}
+}
@@ -0,0 +1,13 @@
+package squash_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"testing"
+)
+
+func TestSquash(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Squash Suite")
+}
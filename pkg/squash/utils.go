@@ -1,10 +1,13 @@
 package squash
 
 import (
+	"context"
 	"errors"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/solo-io/squash/pkg/utils/socket"
+	corev1 "k8s.io/api/core/v1"
 )
 
 func GetPort(pid int) (int, error) {
@@ -32,3 +35,29 @@ func GetPort(pid int) (int, error) {
 
 	return port, nil
 }
+
+func containerRunning(pod *corev1.Pod, containerName string) bool {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == containerName {
+			return status.State.Running != nil
+		}
+	}
+	return false
+}
+
+func restartCount(pod *corev1.Pod, containerName string) int32 {
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == containerName {
+			return status.RestartCount
+		}
+	}
+	return 0
+}
+
+// sleep waits for d, or until the context is done
+func sleep(ctx context.Context, d time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}
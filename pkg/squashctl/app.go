@@ -4,7 +4,6 @@ import (
 	"context"
 	"fmt"
 	"strings"
-	"time"
 
 	"k8s.io/client-go/kubernetes"
 
@@ -14,6 +13,7 @@ import (
 	"github.com/solo-io/squash/pkg/actions"
 	v1 "github.com/solo-io/squash/pkg/api/v1"
 	"github.com/solo-io/squash/pkg/config"
+	"github.com/solo-io/squash/pkg/debuggers/detect"
 	"github.com/solo-io/squash/pkg/options"
 	sqOpts "github.com/solo-io/squash/pkg/options"
 	"github.com/solo-io/squash/pkg/utils"
@@ -66,9 +66,16 @@ func App(version string) (*cobra.Command, error) {
 		Short:   "debug microservices with squash",
 		Long:    descriptionUsage,
 		Version: version,
-		PersistentPreRun: func(cmd *cobra.Command, args []string) {
-			opts.readConfigValues(&opts.Config)
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := opts.readConfigValues(&opts.Config); err != nil {
+				return err
+			}
+			// machine mode output is the json document, and json output must not be interleaved with prompts
+			opts.Json = opts.Json || opts.Squash.Machine
+			opts.Squash.Machine = opts.Json
+			opts.ensureInteractivity()
 			opts.logCmd(cmd, args)
+			return nil
 		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			// when no sub commands are specified, run w/wo RBAC according to settings
@@ -78,18 +85,33 @@ func App(version string) (*cobra.Command, error) {
 	}
 
 	initializeOptions(opts)
+	cobra.OnInitialize(func() {
+		// with --json, ReportError prints failures as json documents, so cobra must not print them as text
+		if jsonRequested(app) {
+			app.SilenceErrors = true
+			app.SilenceUsage = true
+		}
+	})
 
 	app.SuggestionsMinimumDistance = 1
 	app.AddCommand(
 		opts.DeployCmd(),
 		opts.SquashCmd(),
+		opts.SessionsCmd(),
+		opts.ConfigCmd(),
 		opts.UtilsCmd(),
 		completionCmd(),
 	)
 
-	app.PersistentFlags().BoolVar(&opts.Json, "json", false, "output json format")
+	app.PersistentFlags().BoolVar(&opts.Json, "json", false, fmt.Sprintf("print the result, or the error, as a json document of version %v. Implies --machine", JsonVersion))
 	app.PersistentFlags().StringVar(&opts.ConfigFilename, "config", "", "optional, path to squash config (defaults to ~/.squash/config.yaml)")
+	app.PersistentFlags().BoolVar(&opts.NonInteractive, "non-interactive", false, "never prompt: fail, listing the candidates, when a value is missing. Enabled when stdin is not a terminal")
+	app.PersistentFlags().BoolVar(&opts.Yes, "yes", false, "answer yes to confirmation prompts")
+	app.PersistentFlags().StringVar(&opts.Profile, "profile", "", "optional, the profile of the squash config whose values override the config's top level values. Defaults to the config's profile key")
 	applySquashFlags(&opts.Squash, app.PersistentFlags())
+	if err := opts.bindSettings(app.PersistentFlags()); err != nil {
+		return nil, err
+	}
 
 	return app, nil
 }
@@ -98,26 +120,38 @@ func applySquashFlags(cfg *config.Squash, f *pflag.FlagSet) {
 	f.BoolVar(&cfg.NoClean, "no-clean", false, "don't clean temporary pod when existing")
 	f.BoolVar(&cfg.ChooseDebugger, "no-guess-debugger", false, "don't auto detect debugger to use")
 	f.BoolVar(&cfg.ChoosePod, "no-guess-pod", false, "don't auto detect pod to use")
-	f.IntVar(&cfg.TimeoutSeconds, "timeout", 300, "timeout in seconds to wait for debug pod to be ready")
+	f.IntVar(&cfg.TimeoutSeconds, "timeout", 300, "timeout in seconds for each stage of session setup: plank pod ready, debugger attached, port-forward listening")
 	f.StringVar(&cfg.DebugContainerVersion, "container-version", version.ImageVersion, "debug container version to use")
 	f.StringVar(&cfg.DebugContainerRepo, "container-repo", version.ImageRepo, "debug container repo to use")
 
 	f.IntVar(&cfg.LocalPort, "localport", 0, "local port to use to connect to debugger (defaults to random free port)")
 
-	f.BoolVar(&cfg.Machine, "machine", false, "machine mode input and output")
+	f.BoolVar(&cfg.Machine, "machine", false, "machine mode input and output: never prompt, and print the same json documents as --json")
 	f.StringVar(&cfg.Debugger, "debugger", "", "Debugger to use")
 	f.StringVar(&cfg.Namespace, "namespace", "", "Namespace to debug")
 	f.StringVar(&cfg.Pod, "pod", "", "Pod to debug")
 	f.StringVar(&cfg.Container, "container", "", "Container to debug")
-	f.StringVar(&cfg.CRISock, "crisock", "/var/run/dockershim.sock", "The path to the CRI socket")
+	f.StringVar(&cfg.Selector, "selector", "", "optional, choose the pod to debug among the pods that match this label selector, for example app=example-service1")
+	f.StringVar(&cfg.Deployment, "deployment", "", "optional, choose the pod to debug among the pods of this deployment")
+	f.StringVar(&cfg.StatefulSet, "statefulset", "", "optional, choose the pod to debug among the pods of this stateful set")
+	f.StringVar(&cfg.Service, "service", "", "optional, choose the pod to debug among the pods that this service selects")
+	f.StringVar(&cfg.PlankBackend, "plank-backend", "", "optional, how to run plank: \"pod\" runs a privileged pod on the target's node, \"ephemeral\" adds an ephemeral container to the target pod, which needs a namespace that allows the SYS_PTRACE capability (not Pod Security baseline or restricted). Overrides plank_backend in the squash config, defaults to \"pod\"")
+	f.StringVar(&cfg.CRISock, "crisock", "", "optional, path of the CRI socket on the node. By default, Squash looks for the dockershim, CRI-O and containerd sockets")
+	f.StringVar(&cfg.KubeConfig.Path, "kubeconfig", "", "optional, path to the kubeconfig file. Defaults to $KUBECONFIG or ~/.kube/config")
+	f.StringVar(&cfg.KubeConfig.Context, "context", "", "optional, the kubeconfig context to use. Defaults to the current context")
 	f.StringVar(&cfg.SquashNamespace, "squash-namespace", sqOpts.SquashNamespace, fmt.Sprintf("the namespace where squash resources will be deployed (default: %v)", options.SquashNamespace))
 	f.StringVar(&cfg.ProcessName, "process-match", "", "optional, if passed, Squash will try to find a process in the target container that matches (regex, case-insensitive) this string. Otherwise Squash chooses the first process.")
+	f.BoolVar(&cfg.MatchAllProcesses, "all-processes", false, "optional, if passed, Squash attaches to every process that matches --process-match (every process in the container, if no matcher is given)")
+	f.BoolVar(&cfg.FollowProcesses, "follow", false, "optional, if passed, Squash keeps watching the target containers and attaches to processes that match --process-match and start later, such as restarted workers or forked children. Requires --process-match")
+	f.BoolVar(&cfg.ReattachOnRestart, "reattach", false, "optional, if passed, Squash keeps the session when a target container restarts by re-attaching the debugger to the restarted process. Requires secure mode")
+	f.StringSliceVar(&cfg.AdditionalContainers, "additional-containers", nil, "optional, other containers of the target pod to debug in the same session")
 }
 
 func initializeOptions(o *Options) {
 	o.ctx = context.Background()
 
 	o.Squash = config.NewSquashConfig()
+	o.Squash.PrintEditorData = printEditorData
 
 	o.DeployOptions = defaultDeployOptions()
 }
@@ -126,12 +160,12 @@ func (o *Options) getDAClient() (v1.DebugAttachmentClient, error) {
 	if o.daClient == nil {
 		var err error
 		if o.Config.secureMode {
-			o.daClient, err = utils.GetBasicDebugAttachmentClient(o.ctx)
+			o.daClient, err = utils.GetBasicDebugAttachmentClientFor(o.ctx, o.Squash.KubeConfig)
 			if err != nil {
 				return nil, err
 			}
 		} else {
-			o.daClient, err = utils.GetDebugAttachmentClientWithRegistration(o.ctx)
+			o.daClient, err = utils.GetDebugAttachmentClientWithRegistrationFor(o.ctx, o.Squash.KubeConfig)
 			if err != nil {
 				return nil, err
 			}
@@ -142,7 +176,7 @@ func (o *Options) getDAClient() (v1.DebugAttachmentClient, error) {
 
 func (o *Options) getKubeClient() (*kubernetes.Clientset, error) {
 	if o.kubeClient == nil {
-		kubeClient, err := squashkubeutils.GetKubeClient()
+		kubeClient, err := squashkubeutils.GetKubeClientFor(o.Squash.KubeConfig)
 		if err != nil {
 			return &kubernetes.Clientset{}, err
 		}
@@ -154,6 +188,12 @@ func (o *Options) getKubeClient() (*kubernetes.Clientset, error) {
 func (o *Options) runBaseCommand() error {
 	o.printVerbose("Attaching debugger")
 
+	if err := o.validateFollow(); err != nil {
+		return err
+	}
+	if err := o.validateReattach(); err != nil {
+		return err
+	}
 	if err := o.ensureMinimumSquashConfig(); err != nil {
 		return err
 	}
@@ -173,12 +213,9 @@ func (o *Options) runBaseCommand() error {
 		if err := o.createPlankPermissions(); err != nil {
 			return err
 		}
-		// TODO - replace sleeps with watches on CRD
-		time.Sleep(200 * time.Millisecond)
 		if err := o.writeDebugAttachment(); err != nil {
 			return err
 		}
-		time.Sleep(200 * time.Millisecond)
 		_, err := config.StartDebugContainer(o.Squash, o.DebugTarget)
 		o.cleanupPostRun()
 		return err
@@ -188,6 +225,22 @@ func (o *Options) runBaseCommand() error {
 	return nil
 }
 
+// validateFollow rejects --follow without --process-match, which would attach to every process that starts in the target containers
+func (o *Options) validateFollow() error {
+	if o.Squash.FollowProcesses && o.Squash.ProcessName == "" {
+		return fmt.Errorf("Please specify the processes to follow with --process-match, --follow does not attach to every new process")
+	}
+	return nil
+}
+
+// validateReattach rejects --reattach outside of secure mode, it is the squash server that watches the target for restarts
+func (o *Options) validateReattach() error {
+	if o.Squash.ReattachOnRestart && !o.Config.secureMode {
+		return fmt.Errorf("--reattach requires secure mode, Squash re-attaches from the squash server deployed to the cluster")
+	}
+	return nil
+}
+
 func (o *Options) runBaseCommandWithRbac() error {
 	if err := o.ensureSquashIsInCluster(); err != nil {
 		return err
@@ -197,45 +250,28 @@ func (o *Options) runBaseCommandWithRbac() error {
 		return err
 	}
 
-	// wait until pod is created, print its name so the extension can connect
-
-	// TODO(mitchdraft) - add this to the configuration file
-	// 1 second was not long enough, status still pending, could not port-forward
-	// 3 seconds might be overkill
-	// TODO(mitchdraft) - replace with watch on cmd stream
-	time.Sleep(3 * time.Second)
-
+	// waits until squash has attached the debugger, then prints its location so the extension can connect
 	return o.Squash.ReportOrConnectToCreatedDebuggerPod()
 }
 
 func (o *Options) writeDebugAttachment() error {
-	so := o.Squash
-	dbge := o.DebugTarget
-
-	uc, err := actions.NewUserController()
+	uc, err := actions.NewUserController(o.Squash.KubeConfig)
 	if err != nil {
 		return err
 	}
 	daName := cliutils.RandKubeNameBytes(10)
 	// this works in the form: `squash  --namespace mk6 --pod example-service1-74bbc5dcd-rvrtq`
-	_, err = uc.Attach(
-		daName,
-		so.Namespace,
-		dbge.Container.Image,
-		dbge.Pod.Name,
-		so.Container,
-		so.ProcessName,
-		so.Debugger)
-
-	return nil
+	_, err = uc.Attach(daName, o.Squash.GetIntent())
+	return err
 }
 
 func (o *Options) ensureMinimumSquashConfig() error {
 
-	if err := o.chooseDebugger(); err != nil {
+	if err := o.getMissing(); err != nil {
 		return err
 	}
-	if err := o.getMissing(); err != nil {
+	// detection inspects the target container, so choose the debugger once the target is known
+	if err := o.chooseDebugger(); err != nil {
 		return err
 	}
 	if err := o.ensureLocalPort(&o.Squash.LocalPort); err != nil {
@@ -243,14 +279,8 @@ func (o *Options) ensureMinimumSquashConfig() error {
 	}
 
 	if !o.Squash.Machine {
-		confirmed := false
-		prompt := &survey.Confirm{
-			Message: "Going to attach " + o.Squash.Debugger + " to pod " + o.DebugTarget.Pod.ObjectMeta.Name + ". continue?",
-			Default: true,
-		}
-		survey.AskOne(prompt, &confirmed, nil)
-		if !confirmed {
-			return errors.New("user aborted")
+		if err := o.confirm("Going to attach " + o.Squash.Debugger + " to pod " + o.DebugTarget.Pod.ObjectMeta.Name + ". continue?"); err != nil {
+			return err
 		}
 	}
 	return nil
@@ -260,21 +290,35 @@ func (o *Options) chooseDebugger() error {
 	if o.Squash.Debugger != "" {
 		return nil
 	}
+	if debugger := o.Config.namespaceDebuggers[o.Squash.Namespace]; debugger != "" {
+		o.printVerbose(fmt.Sprintf("Using debugger %v, the configured debugger of namespace %v", debugger, o.Squash.Namespace))
+		o.Squash.Debugger = debugger
+		return nil
+	}
 
 	debugger := o.detectLang()
+	if debugger != "" && o.NonInteractive {
+		// nobody can correct the guess
+		o.printVerbose(fmt.Sprintf("Detected debugger %v, pass --debugger to use a different one", debugger))
+		o.Squash.Debugger = debugger
+		return nil
+	}
 
-	if debugger == "" {
-		question := &survey.Select{
-			Message: "Select a debugger",
-			Options: sqOpts.AvailableDebuggers,
-		}
-		var choice string
-		if err := survey.AskOne(question, &choice, survey.Required); err != nil {
-			return err
-		}
-		debugger = choice
+	question := &survey.Select{
+		Message: "Select a debugger",
+		Options: sqOpts.AvailableDebuggers,
+	}
+	if debugger != "" {
+		// the guess is preselected, so that the user can correct it
+		question.Message = fmt.Sprintf("Select a debugger (detected %v)", debugger)
+		question.Default = debugger
 	}
-	o.Squash.Debugger = debugger
+	var choice string
+	missing := &MissingValueError{Value: "debugger", Flag: "debugger", Candidates: sqOpts.AvailableDebuggers}
+	if err := o.askOne(missing, question, &choice, survey.Required); err != nil {
+		return err
+	}
+	o.Squash.Debugger = choice
 	return nil
 }
 
@@ -283,22 +327,67 @@ func (o *Options) detectLang() string {
 		// manual mode
 		return ""
 	}
-	// TODO: find some decent huristics to make this work
+	if container := o.DebugTarget.Container; container != nil {
+		spec := detect.ContainerSpec{
+			Image:   container.Image,
+			Command: append(append([]string{}, container.Command...), container.Args...),
+			Env:     make(map[string]string),
+		}
+		for _, env := range container.Env {
+			spec.Env[env.Name] = env.Value
+		}
+		if debugger := availableDebugger(detect.FromContainerSpec(spec)); debugger != "" {
+			return debugger
+		}
+	}
+	// the pod spec was not conclusive, inspect the process itself if planks can be run
+	// probes run in plank pods, which the ephemeral backend is meant to avoid
+	if o.Squash.PlankBackend == sqOpts.PlankBackendEphemeral || !o.plankPermissionsExist() {
+		return ""
+	}
+	debugger, err := o.Squash.ProbeDebugger()
+	if err != nil {
+		o.printVerbose(fmt.Sprintf("Could not detect debugger: %v", err))
+		return ""
+	}
+	return availableDebugger(debugger)
+}
+
+func availableDebugger(debugger string) string {
+	for _, available := range sqOpts.AvailableDebuggers {
+		if debugger == available {
+			return debugger
+		}
+	}
 	return ""
 }
 
+// plankPermissionsExist is true when squash has been set up to run planks in the squash namespace
+func (o *Options) plankPermissionsExist() bool {
+	cs, err := o.getKubeClient()
+	if err != nil {
+		return false
+	}
+	_, err = cs.CoreV1().ServiceAccounts(o.Squash.SquashNamespace).Get(sqOpts.PlankServiceAccountName, meta_v1.GetOptions{})
+	return err == nil
+}
+
 func (o *Options) getMissing() error {
 
 	//	clientset.CoreV1().Namespace().
 	// see if namespace exist, and if not prompt for one.
 	if o.Squash.Namespace == "" {
-		if err := o.chooseAllowedNamespace(&(o.Squash.Namespace), "Select a namespace to debug"); err != nil {
+		if err := o.chooseAllowedNamespace(&(o.Squash.Namespace), "Select a namespace to debug", "namespace"); err != nil {
 			return errors.Wrap(err, "choosing namespace")
 		}
 	}
 
+	workload, err := o.resolveWorkload()
+	if err != nil {
+		return err
+	}
 	if o.Squash.Pod == "" {
-		if err := o.choosePod(); err != nil {
+		if err := o.choosePod(workload); err != nil {
 			return errors.Wrap(err, "choosing pod")
 		}
 	} else {
@@ -342,7 +431,8 @@ func chooseContainer(o *Options) error {
 		Options: containerNames,
 	}
 	var choice string
-	if err := survey.AskOne(question, &choice, survey.Required); err != nil {
+	missing := &MissingValueError{Value: "container", Flag: "container", Candidates: containerNames}
+	if err := o.askOne(missing, question, &choice, survey.Required); err != nil {
 		return err
 	}
 
@@ -357,7 +447,8 @@ func chooseContainer(o *Options) error {
 	return errors.New("selected container not found")
 }
 
-func (o *Options) chooseAllowedNamespace(target *string, question string) error {
+// chooseAllowedNamespace prompts for a namespace, flag is the flag that passes it in non-interactive mode
+func (o *Options) chooseAllowedNamespace(target *string, question, flag string) error {
 	cs, err := o.getKubeClient()
 	if err != nil {
 		return err
@@ -390,24 +481,36 @@ func (o *Options) chooseAllowedNamespace(target *string, question string) error
 		Message: question,
 		Options: namespaceNames,
 	}
-	if err := survey.AskOne(prompt, target, survey.Required); err != nil {
+	missing := &MissingValueError{Value: "namespace", Flag: flag, Candidates: namespaceNames}
+	if err := o.askOne(missing, prompt, target, survey.Required); err != nil {
 		return err
 	}
 	return nil
 }
 
-func (o *Options) choosePod() error {
+// choosePod chooses the pod to debug, among the pods of the workload if there is one
+func (o *Options) choosePod(workload *v1.Workload) error {
 	cs, err := o.getKubeClient()
 	if err != nil {
 		return err
 	}
-	pods, err := cs.CoreV1().Pods(o.Squash.Namespace).List(meta_v1.ListOptions{})
+	listOpts := meta_v1.ListOptions{}
+	if workload != nil {
+		listOpts.LabelSelector = workload.Selector
+	}
+	pods, err := cs.CoreV1().Pods(o.Squash.Namespace).List(listOpts)
 	if err != nil {
 		return errors.Wrap(err, "reading namesapces")
 	}
+	if workload != nil {
+		pods.Items = preferReady(pods.Items)
+		if len(pods.Items) == 0 {
+			return fmt.Errorf("%v has no pods in namespace %v", describeWorkload(workload), o.Squash.Namespace)
+		}
+	}
 	podName := make([]string, 0, len(pods.Items))
 	for _, pod := range pods.Items {
-		if o.Squash.ChoosePod || o.Squash.Container == "" {
+		if workload != nil || o.Squash.ChoosePod || o.Squash.Container == "" {
 			podName = append(podName, pod.ObjectMeta.Name)
 		} else {
 			for _, podContainer := range pod.Spec.Containers {
@@ -427,7 +530,8 @@ func (o *Options) choosePod() error {
 			Message: "Select a pod",
 			Options: podName,
 		}
-		if err := survey.AskOne(question, &choice, survey.Required); err != nil {
+		missing := &MissingValueError{Value: "pod", Flag: "pod", Candidates: podName}
+		if err := o.askOne(missing, question, &choice, survey.Required); err != nil {
 			return err
 		}
 	}
@@ -435,6 +539,7 @@ func (o *Options) choosePod() error {
 		if choice == pod.ObjectMeta.Name {
 			o.DebugTarget.Pod = &pod
 			o.Squash.Pod = pod.ObjectMeta.Name
+			o.Squash.Workload = workload
 			return nil
 		}
 	}
@@ -499,15 +604,17 @@ func (o *Options) cleanupPreRun() error {
 		return err
 	}
 	for _, priorDa := range priorDas {
-		// delete the prior plank pod
-		if err := cs.
-			CoreV1().
-			Pods(o.Squash.SquashNamespace).
-			Delete(priorDa.PlankName, &meta_v1.DeleteOptions{}); err != nil {
-			// do not exit on error, it does not matter if the pod was already deleted
-			// TODO(mitchdraft) - first check if the pod exists before deleting
-			if !o.Squash.Machine {
-				fmt.Println(err)
+		// delete the prior plank pod, an ephemeral plank has none and exits once its debug attachment is deleted
+		if priorDa.GetPlank().GetEphemeralContainer() == "" {
+			if err := cs.
+				CoreV1().
+				Pods(o.Squash.SquashNamespace).
+				Delete(priorDa.GetPlank().GetPod().GetName(), &meta_v1.DeleteOptions{}); err != nil {
+				// do not exit on error, it does not matter if the pod was already deleted
+				// TODO(mitchdraft) - first check if the pod exists before deleting
+				if !o.Squash.Machine {
+					fmt.Println(err)
+				}
 			}
 		}
 		if err := daClient.Delete(
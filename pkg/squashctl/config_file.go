@@ -1,13 +1,21 @@
 package squashctl
 
 import (
+	"bytes"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
 
 	homedir "github.com/mitchellh/go-homedir"
+	"github.com/pkg/errors"
+	"github.com/solo-io/squash/pkg/config"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
+	"gopkg.in/yaml.v2"
 )
 
 var (
@@ -17,26 +25,158 @@ var (
 
 var defaultConfigYaml = []byte(`# Squash configuration file
 # The specification can be found at https://squash.solo.io
+# Each value can be overridden by the environment variable SQUASH_<KEY>, such as SQUASH_SECURE_MODE,
+# and by the flag of the same name. Run squashctl config view to see the effective values.
 secure_mode: false
+# how to run plank, the process that attaches the debugger: pod or ephemeral
+plank_backend: pod
 verbose: true
 log_commands: false
 createdby: squash-initialization
+# the debug target and debugger, usually chosen per session with flags
+# debugger: dlv
+# namespace: default
+# pod: example-service1-74bbc5dcd-rvrtq
+# container: example-service1
+# instead of pod, choose the pod among the pods of a selector, deployment, statefulset or service
+# deployment: example-service1
+# additional_containers: [sidecar]
+# process_match: server
+# all_processes: false
+# follow: false
+# reattach: false
+# local_port: 0
+# session setup
+# timeout: 300
+# no_clean: false
+# no_guess_debugger: false
+# no_guess_pod: false
+# machine: false
+# never prompt, squashctl does not prompt either when stdin is not a terminal
+# non_interactive: false
+# plank image
+# container_repo: soloio
+# container_version defaults to the version of squashctl
+# cluster, the kubeconfig defaults to $KUBECONFIG or ~/.kube/config, and the context to its current context
+# kubeconfig: /home/me/.kube/staging
+# context: staging
+# squash_namespace: squash-debugger
+# cri_socket: /run/containerd/containerd.sock
+# merged into the plank pods that squashctl creates, and, once squash is deployed with it, that squash creates.
+# Keys are read in lower case.
+# plank_template:
+#   resources:
+#     requests: {cpu: 100m, memory: 128Mi}
+#     limits: {cpu: 500m, memory: 512Mi}
+#   tolerations:
+#   - {key: nvidia.com/gpu, operator: Exists, effect: NoSchedule}
+#   priorityClassName: debug
+#   labels: {team: payments}
+#   annotations: {seccomp.security.alpha.kubernetes.io/pod: runtime/default}
+#   imagePullSecrets:
+#   - name: team-registry
+# the debugger to use in each namespace, unless --debugger is passed
+# namespace_debuggers:
+#   payments: java
+#   web: nodejs
+# profiles hold named sets of the values above, the profile's values override the ones at the top of this file.
+# Select a profile with --profile or SQUASH_PROFILE, or make it the default with squashctl config use-profile.
+# profile: team-a
+# profiles:
+#   team-a:
+#     container_repo: gcr.io/team-a
+#     squash_namespace: team-a-squash
+#     secure_mode: true
+#     cri_socket: /run/containerd/containerd.sock
+#     namespace_debuggers:
+#       payments: java
 `)
 
-func writeDefaultConfigFile(fp string) error {
-	fmt.Printf("Squash config file not found. Writing default config to %v.\n", fp)
-	if err := ioutil.WriteFile(fp, defaultConfigYaml, 0644); err != nil {
-		return err
+// envPrefix prefixes the environment variables that override config file values
+const envPrefix = "SQUASH"
+
+// profilesKey holds the named profiles in the config file
+const profilesKey = "profiles"
+
+// setting is one value of the layered squashctl configuration. From lowest to highest precedence,
+// it is set by its default, the config file, the selected profile, its environment variable, and its flag.
+type setting struct {
+	// key names the setting in the config file
+	key string
+	// flag overrides the setting, if it has one. Its default is the setting's default
+	flag string
+	// value points at the option that holds the setting: a *bool, *int, *string, *[]string, *map[string]string
+	// or *config.PlankPodTemplate
+	value interface{}
+}
+
+// settings lists every configurable option
+func (o *Options) settings() []setting {
+	return []setting{
+		{key: "profile", flag: "profile", value: &o.Profile},
+		{key: "secure_mode", value: &o.Config.secureMode},
+		{key: "verbose", value: &o.Config.verbose},
+		{key: "log_commands", value: &o.Config.logCmds},
+		{key: "namespace_debuggers", value: &o.Config.namespaceDebuggers},
+
+		{key: "no_clean", flag: "no-clean", value: &o.Squash.NoClean},
+		{key: "no_guess_debugger", flag: "no-guess-debugger", value: &o.Squash.ChooseDebugger},
+		{key: "no_guess_pod", flag: "no-guess-pod", value: &o.Squash.ChoosePod},
+		{key: "timeout", flag: "timeout", value: &o.Squash.TimeoutSeconds},
+		{key: "container_version", flag: "container-version", value: &o.Squash.DebugContainerVersion},
+		{key: "container_repo", flag: "container-repo", value: &o.Squash.DebugContainerRepo},
+		{key: "local_port", flag: "localport", value: &o.Squash.LocalPort},
+		{key: "machine", flag: "machine", value: &o.Squash.Machine},
+		{key: "non_interactive", flag: "non-interactive", value: &o.NonInteractive},
+		{key: "debugger", flag: "debugger", value: &o.Squash.Debugger},
+		{key: "namespace", flag: "namespace", value: &o.Squash.Namespace},
+		{key: "pod", flag: "pod", value: &o.Squash.Pod},
+		{key: "container", flag: "container", value: &o.Squash.Container},
+		{key: "selector", flag: "selector", value: &o.Squash.Selector},
+		{key: "deployment", flag: "deployment", value: &o.Squash.Deployment},
+		{key: "statefulset", flag: "statefulset", value: &o.Squash.StatefulSet},
+		{key: "service", flag: "service", value: &o.Squash.Service},
+		{key: "additional_containers", flag: "additional-containers", value: &o.Squash.AdditionalContainers},
+		{key: "process_match", flag: "process-match", value: &o.Squash.ProcessName},
+		{key: "all_processes", flag: "all-processes", value: &o.Squash.MatchAllProcesses},
+		{key: "follow", flag: "follow", value: &o.Squash.FollowProcesses},
+		{key: "reattach", flag: "reattach", value: &o.Squash.ReattachOnRestart},
+		{key: "plank_backend", flag: "plank-backend", value: &o.Squash.PlankBackend},
+		{key: "cri_socket", flag: "crisock", value: &o.Squash.CRISock},
+		{key: "plank_template", value: &o.Squash.PlankTemplate},
+		{key: "squash_namespace", flag: "squash-namespace", value: &o.Squash.SquashNamespace},
+		{key: "kubeconfig", flag: "kubeconfig", value: &o.Squash.KubeConfig.Path},
+		{key: "context", flag: "context", value: &o.Squash.KubeConfig.Context},
+	}
+}
+
+func (s setting) envVar() string {
+	return envPrefix + "_" + strings.ToUpper(s.key)
+}
+
+// bindSettings lets the flags override the environment and the config file, it is called once the flags are defined
+func (o *Options) bindSettings(flags *pflag.FlagSet) error {
+	viper.SetEnvPrefix(envPrefix)
+	viper.AutomaticEnv()
+	for _, s := range o.settings() {
+		if s.flag == "" {
+			continue
+		}
+		f := flags.Lookup(s.flag)
+		if f == nil {
+			return fmt.Errorf("setting %v is bound to the unknown flag --%v", s.key, s.flag)
+		}
+		if err := viper.BindPFlag(s.key, f); err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
-// Note on use of config file:
-// - should implement a consistent way of reading all values
-// and harmonizing them with flags
+// readConfigValues sets every option from its layers: defaults, the config file, the environment, then flags
 func (o *Options) readConfigValues(c *Config) error {
 	// Only read the config once
-	if o.Internal.ConfigLoaded {
+	if o.Internal.ConfigRead {
 		return nil
 	}
 
@@ -44,15 +184,91 @@ func (o *Options) readConfigValues(c *Config) error {
 		return err
 	}
 
-	// TODO(mitchdraft) - allow config to be overriden by flags
-	c.verbose = viper.GetBool("verbose")
-	c.secureMode = viper.GetBool("secure_mode")
-	c.logCmds = viper.GetBool("log_commands")
+	for _, s := range o.settings() {
+		switch value := s.value.(type) {
+		case *bool:
+			*value = viper.GetBool(s.key)
+		case *int:
+			*value = viper.GetInt(s.key)
+		case *string:
+			*value = viper.GetString(s.key)
+		case *[]string:
+			*value = getStringList(s.key)
+		case *map[string]string:
+			*value = viper.GetStringMapString(s.key)
+		case *config.PlankPodTemplate:
+			template, err := getPlankPodTemplate(s.key)
+			if err != nil {
+				return err
+			}
+			*value = template
+		default:
+			return fmt.Errorf("setting %v has unsupported type %T", s.key, s.value)
+		}
+	}
 
 	o.Internal.ConfigRead = true
 	return nil
 }
 
+// getStringList reads a list setting, which is a comma separated list in the environment and in flags
+func getStringList(key string) []string {
+	str, ok := viper.Get(key).(string)
+	if !ok {
+		return viper.GetStringSlice(key)
+	}
+	var list []string
+	for _, item := range strings.Split(str, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// getPlankPodTemplate reads the plank pod template, which is yaml or json in the environment
+func getPlankPodTemplate(key string) (config.PlankPodTemplate, error) {
+	raw := viper.Get(key)
+	if raw == nil {
+		return config.PlankPodTemplate{}, nil
+	}
+	if str, ok := raw.(string); ok {
+		return config.ParsePlankPodTemplate([]byte(str))
+	}
+	data, err := yaml.Marshal(raw)
+	if err != nil {
+		return config.PlankPodTemplate{}, errors.Wrapf(err, "reading %v", key)
+	}
+	return config.ParsePlankPodTemplate(data)
+}
+
+// settingSource describes the layer that the effective value of a setting came from
+func (o *Options) settingSource(s setting, flags *pflag.FlagSet) string {
+	if s.flag != "" {
+		if f := flags.Lookup(s.flag); f != nil && f.Changed {
+			return "flag --" + s.flag
+		}
+	}
+	if _, ok := os.LookupEnv(s.envVar()); ok {
+		return "environment " + s.envVar()
+	}
+	if _, ok := o.Internal.ProfileSettings[s.key]; ok {
+		return fmt.Sprintf("profile %v in %v", o.Profile, viper.ConfigFileUsed())
+	}
+	if viper.InConfig(s.key) {
+		return "config file " + viper.ConfigFileUsed()
+	}
+	return "default"
+}
+
+func (o *Options) writeDefaultConfigFile(fp string) error {
+	fmt.Fprintf(o.progressOutput(), "Squash config file not found. Writing default config to %v.\n", fp)
+	if err := ioutil.WriteFile(fp, defaultConfigYaml, 0644); err != nil {
+		return err
+	}
+	return nil
+}
+
 // This needs to be called before viper can read any config values
 func (o *Options) prepareViperConfig() error {
 	// only load the config once
@@ -79,7 +295,7 @@ func (o *Options) prepareViperConfig() error {
 			// path exists
 			o.printVerbosef("Reading squash config from %v\n", squashConfigFile)
 		} else {
-			if err := writeDefaultConfigFile(squashConfigFile); err != nil {
+			if err := o.writeDefaultConfigFile(squashConfigFile); err != nil {
 				return err
 			}
 		}
@@ -90,10 +306,70 @@ func (o *Options) prepareViperConfig() error {
 	if err := viper.ReadInConfig(); err != nil {
 		return fmt.Errorf("Can't read config: %v", err)
 	}
+	if err := o.applyProfile(); err != nil {
+		return err
+	}
 	o.Internal.ConfigLoaded = true
 	return nil
 }
 
+// applyProfile layers the selected profile over the top level of the config file.
+// The profile is chosen by --profile, then SQUASH_PROFILE, then the profile key of the config file.
+func (o *Options) applyProfile() error {
+	name := viper.GetString("profile")
+	if name == "" {
+		return nil
+	}
+	profile, err := configProfile(name)
+	if err != nil {
+		return err
+	}
+	if err := viper.MergeConfigMap(profile); err != nil {
+		return errors.Wrapf(err, "applying profile %v", name)
+	}
+	o.Internal.ProfileSettings = profile
+	return nil
+}
+
+// configProfile returns the settings of a profile of the loaded config file
+func configProfile(name string) (map[string]interface{}, error) {
+	profiles := viper.GetStringMap(profilesKey)
+	if _, ok := profiles[name]; !ok {
+		return nil, errors.Errorf("profile %v is not defined in %v, the defined profiles are: %v", name, viper.ConfigFileUsed(), strings.Join(profileNames(), ", "))
+	}
+	return viper.GetStringMap(profilesKey + "." + name), nil
+}
+
+// profileNames lists the profiles of the loaded config file
+func profileNames() []string {
+	var names []string
+	for name := range viper.GetStringMap(profilesKey) {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+var profileLine = regexp.MustCompile(`(?m)^profile:.*$`)
+
+// setDefaultProfile rewrites the profile key of the config file, leaving the rest of the file, and its comments, as they are
+func setDefaultProfile(configFile, name string) error {
+	contents, err := ioutil.ReadFile(configFile)
+	if err != nil {
+		return err
+	}
+	line := []byte("profile: " + name)
+	if profileLine.Match(contents) {
+		contents = profileLine.ReplaceAllLiteral(contents, line)
+	} else {
+		if len(contents) > 0 && !bytes.HasSuffix(contents, []byte("\n")) {
+			contents = append(contents, '\n')
+		}
+		contents = append(append(contents, line...), '\n')
+	}
+	return ioutil.WriteFile(configFile, contents, 0644)
+}
+
 func squashDir() (string, error) {
 	// Find home directory.
 	home, err := homedir.Dir()
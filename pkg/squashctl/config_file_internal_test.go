@@ -0,0 +1,292 @@
+package squashctl
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/solo-io/squash/pkg/version"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+var _ = Describe("config layering", func() {
+	var (
+		dir        string
+		configFile string
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "squashctl")
+		Expect(err).NotTo(HaveOccurred())
+		configFile = filepath.Join(dir, "config.yaml")
+		viper.Reset()
+	})
+
+	AfterEach(func() {
+		os.Unsetenv("SQUASH_CONTAINER_REPO")
+		os.Unsetenv("SQUASH_SECURE_MODE")
+		os.Unsetenv("SQUASH_PROFILE")
+		os.RemoveAll(dir)
+		viper.Reset()
+	})
+
+	// load reads the config file, environment and flags the way the squashctl command does
+	load := func(configYaml string, env map[string]string, args ...string) (*Options, *pflag.FlagSet) {
+		Expect(ioutil.WriteFile(configFile, []byte(configYaml), 0644)).To(Succeed())
+		for key, value := range env {
+			os.Setenv(key, value)
+		}
+		o := NewOptions()
+		o.ConfigFilename = configFile
+		flags := pflag.NewFlagSet("squashctl", pflag.ContinueOnError)
+		flags.BoolVar(&o.NonInteractive, "non-interactive", false, "")
+		flags.StringVar(&o.Profile, "profile", "", "")
+		applySquashFlags(&o.Squash, flags)
+		Expect(o.bindSettings(flags)).To(Succeed())
+		Expect(flags.Parse(args)).To(Succeed())
+		Expect(o.readConfigValues(&o.Config)).To(Succeed())
+		return o, flags
+	}
+
+	source := func(o *Options, flags *pflag.FlagSet, key string) string {
+		for _, s := range o.settings() {
+			if s.key == key {
+				return o.settingSource(s, flags)
+			}
+		}
+		Fail("no setting " + key)
+		return ""
+	}
+
+	const (
+		topLevel    = "container_repo: from-config\nsecure_mode: false\n"
+		withProfile = topLevel + "profile: team-a\nprofiles:\n  team-a:\n    container_repo: from-profile\n    secure_mode: true\n"
+	)
+
+	It("should take each value from its highest layer and report that layer", func() {
+		for _, c := range []struct {
+			description string
+			configYaml  string
+			env         map[string]string
+			args        []string
+			repo        string
+			repoSource  string
+		}{
+			{
+				description: "default",
+				configYaml:  "verbose: false\n",
+				repo:        version.ImageRepo,
+				repoSource:  "default",
+			},
+			{
+				description: "config file over default",
+				configYaml:  topLevel,
+				repo:        "from-config",
+				repoSource:  "config file " + configFile,
+			},
+			{
+				description: "profile over config file",
+				configYaml:  withProfile,
+				repo:        "from-profile",
+				repoSource:  "profile team-a in " + configFile,
+			},
+			{
+				description: "environment over profile",
+				configYaml:  withProfile,
+				env:         map[string]string{"SQUASH_CONTAINER_REPO": "from-env"},
+				repo:        "from-env",
+				repoSource:  "environment SQUASH_CONTAINER_REPO",
+			},
+			{
+				description: "flag over environment",
+				configYaml:  withProfile,
+				env:         map[string]string{"SQUASH_CONTAINER_REPO": "from-env"},
+				args:        []string{"--container-repo", "from-flag"},
+				repo:        "from-flag",
+				repoSource:  "flag --container-repo",
+			},
+		} {
+			viper.Reset()
+			os.Unsetenv("SQUASH_CONTAINER_REPO")
+			o, flags := load(c.configYaml, c.env, c.args...)
+			Expect(o.Squash.DebugContainerRepo).To(Equal(c.repo), c.description)
+			Expect(source(o, flags, "container_repo")).To(Equal(c.repoSource), c.description)
+		}
+	})
+
+	It("should layer the settings that have no flag", func() {
+		o, flags := load(withProfile, nil)
+		Expect(o.Config.secureMode).To(BeTrue())
+		Expect(source(o, flags, "secure_mode")).To(Equal("profile team-a in " + configFile))
+
+		viper.Reset()
+		o, flags = load(withProfile, map[string]string{"SQUASH_SECURE_MODE": "false"})
+		Expect(o.Config.secureMode).To(BeFalse())
+		Expect(source(o, flags, "secure_mode")).To(Equal("environment SQUASH_SECURE_MODE"))
+	})
+
+	It("should select the profile by flag over the environment and the config file", func() {
+		config := withProfile + "  team-b:\n    container_repo: team-b-repo\n  team-c:\n    container_repo: team-c-repo\n"
+
+		o, flags := load(config, map[string]string{"SQUASH_PROFILE": "team-b"})
+		Expect(o.Profile).To(Equal("team-b"))
+		Expect(o.Squash.DebugContainerRepo).To(Equal("team-b-repo"))
+		Expect(source(o, flags, "profile")).To(Equal("environment SQUASH_PROFILE"))
+
+		viper.Reset()
+		o, flags = load(config, map[string]string{"SQUASH_PROFILE": "team-b"}, "--profile", "team-c")
+		Expect(o.Profile).To(Equal("team-c"))
+		Expect(o.Squash.DebugContainerRepo).To(Equal("team-c-repo"))
+		Expect(source(o, flags, "profile")).To(Equal("flag --profile"))
+	})
+})
+
+var _ = Describe("profiles", func() {
+	var (
+		dir        string
+		configFile string
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "squashctl")
+		Expect(err).NotTo(HaveOccurred())
+		configFile = filepath.Join(dir, "config.yaml")
+		viper.Reset()
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(dir)
+		viper.Reset()
+	})
+
+	const profiles = `container_repo: soloio
+namespace_debuggers:
+  payments: java
+  web: nodejs
+profiles:
+  team-a:
+    container_repo: gcr.io/team-a
+    namespace_debuggers:
+      web: python
+  team-b:
+    secure_mode: true
+`
+
+	// readConfig loads the config file the way prepareViperConfig does, selecting the given profile
+	readConfig := func(configYaml, profile string) *Options {
+		Expect(ioutil.WriteFile(configFile, []byte(configYaml), 0644)).To(Succeed())
+		viper.SetConfigFile(configFile)
+		viper.SetConfigType("yaml")
+		Expect(viper.ReadInConfig()).To(Succeed())
+		if profile != "" {
+			viper.Set("profile", profile)
+		}
+		return NewOptions()
+	}
+
+	Describe("applyProfile", func() {
+		It("should leave the top level values without a profile", func() {
+			o := readConfig(profiles, "")
+			Expect(o.applyProfile()).To(Succeed())
+			Expect(o.Internal.ProfileSettings).To(BeNil())
+			Expect(viper.GetString("container_repo")).To(Equal("soloio"))
+		})
+
+		It("should override the top level values with the profile's", func() {
+			o := readConfig(profiles, "team-a")
+			Expect(o.applyProfile()).To(Succeed())
+			Expect(o.Internal.ProfileSettings).To(HaveKey("container_repo"))
+			Expect(o.Internal.ProfileSettings).NotTo(HaveKey("secure_mode"))
+			Expect(viper.GetString("container_repo")).To(Equal("gcr.io/team-a"))
+			// maps are merged key by key
+			Expect(viper.GetStringMapString("namespace_debuggers")).To(Equal(map[string]string{"payments": "java", "web": "python"}))
+			Expect(viper.GetBool("secure_mode")).To(BeFalse())
+		})
+
+		It("should list the defined profiles when the profile is unknown", func() {
+			o := readConfig(profiles, "team-z")
+			err := o.applyProfile()
+			Expect(err).To(MatchError("profile team-z is not defined in " + configFile + ", the defined profiles are: team-a, team-b"))
+			Expect(o.Internal.ProfileSettings).To(BeNil())
+			Expect(viper.GetString("container_repo")).To(Equal("soloio"))
+		})
+
+		It("should fail when the config file defines no profiles", func() {
+			o := readConfig("container_repo: soloio\n", "team-a")
+			Expect(o.applyProfile()).To(MatchError(ContainSubstring("profile team-a is not defined")))
+		})
+	})
+
+	Describe("setDefaultProfile", func() {
+		It("should only rewrite the top level profile key", func() {
+			for _, c := range []struct {
+				description string
+				before      string
+				after       string
+			}{
+				{
+					description: "existing key",
+					before:      "# Squash configuration file\nprofile: team-a\nverbose: true\n",
+					after:       "# Squash configuration file\nprofile: team-b\nverbose: true\n",
+				},
+				{
+					description: "existing key with a comment",
+					before:      "profile: team-a # the default\nverbose: true\n",
+					after:       "profile: team-b\nverbose: true\n",
+				},
+				{
+					description: "missing key",
+					before:      "verbose: true\n",
+					after:       "verbose: true\nprofile: team-b\n",
+				},
+				{
+					description: "missing key and final newline",
+					before:      "verbose: true",
+					after:       "verbose: true\nprofile: team-b\n",
+				},
+				{
+					description: "commented out key",
+					before:      "# profile: team-a\nverbose: true\n",
+					after:       "# profile: team-a\nverbose: true\nprofile: team-b\n",
+				},
+				{
+					description: "nested keys",
+					before:      "profiles:\n  team-b:\n    profile: nested\n    container_repo: gcr.io/team-b\n",
+					after:       "profiles:\n  team-b:\n    profile: nested\n    container_repo: gcr.io/team-b\nprofile: team-b\n",
+				},
+				{
+					description: "empty file",
+					before:      "",
+					after:       "profile: team-b\n",
+				},
+			} {
+				Expect(ioutil.WriteFile(configFile, []byte(c.before), 0644)).To(Succeed())
+				Expect(setDefaultProfile(configFile, "team-b")).To(Succeed(), c.description)
+				after, err := ioutil.ReadFile(configFile)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(string(after)).To(Equal(c.after), c.description)
+			}
+		})
+
+		It("should keep the default config file valid", func() {
+			Expect(ioutil.WriteFile(configFile, defaultConfigYaml, 0644)).To(Succeed())
+			Expect(setDefaultProfile(configFile, "team-a")).To(Succeed())
+			viper.SetConfigFile(configFile)
+			viper.SetConfigType("yaml")
+			Expect(viper.ReadInConfig()).To(Succeed())
+			Expect(viper.GetString("profile")).To(Equal("team-a"))
+			Expect(viper.GetString("plank_backend")).To(Equal("pod"))
+		})
+
+		It("should not create a missing config file", func() {
+			Expect(setDefaultProfile(filepath.Join(dir, "missing.yaml"), "team-b")).NotTo(Succeed())
+			_, err := os.Stat(filepath.Join(dir, "missing.yaml"))
+			Expect(os.IsNotExist(err)).To(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,145 @@
+package squashctl
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/solo-io/squash/pkg/config"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// settingView reports the effective value of a setting, and the layer it came from
+type settingView struct {
+	Key    string      `json:"key"`
+	Value  interface{} `json:"value"`
+	Source string      `json:"source"`
+	Env    string      `json:"env"`
+	Flag   string      `json:"flag,omitempty"`
+}
+
+func (o *Options) ConfigCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "inspect the squashctl configuration",
+		Long: `Each setting is taken from, in increasing order of precedence: its default, the squash config file
+(~/.squash/config.yaml unless --config is passed), the selected profile of the config file, its SQUASH_<KEY>
+environment variable, and its flag. The profile is selected by --profile, SQUASH_PROFILE, or the config's profile key.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return nil
+		},
+	}
+
+	cmd.AddCommand(
+		o.configViewCmd(),
+		o.configUseProfileCmd(),
+	)
+
+	return cmd
+}
+
+func (o *Options) configViewCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "view",
+		Short: "show the effective value of each setting and where it came from",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			views := []settingView{}
+			for _, s := range o.settings() {
+				views = append(views, settingView{
+					Key:    s.key,
+					Value:  settingValue(s),
+					Source: o.settingSource(s, cmd.Flags()),
+					Env:    s.envVar(),
+					Flag:   s.flag,
+				})
+			}
+			if o.Json {
+				return printJson(ConfigViewKind, views)
+			}
+			w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+			fmt.Fprintln(w, "KEY\tVALUE\tSOURCE")
+			for _, v := range views {
+				fmt.Fprintf(w, "%v\t%v\t%v\n", v.Key, formatSettingValue(v.Value), v.Source)
+			}
+			return w.Flush()
+		},
+	}
+	return cmd
+}
+
+// profileSelection reports the new default profile
+type profileSelection struct {
+	Profile    string `json:"profile"`
+	ConfigFile string `json:"configFile"`
+}
+
+func (o *Options) configUseProfileCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "use-profile <name>",
+		Short: "make a profile of the squash config the default one",
+		Long: `use-profile sets the profile key of the squash config file. --profile and SQUASH_PROFILE still take precedence
+over the default profile.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+			if _, err := configProfile(name); err != nil {
+				return err
+			}
+			configFile := viper.ConfigFileUsed()
+			if err := setDefaultProfile(configFile, name); err != nil {
+				return err
+			}
+			if o.Json {
+				return printJson(ProfileSelectedKind, profileSelection{Profile: name, ConfigFile: configFile})
+			}
+			fmt.Printf("Default profile is now %v\n", name)
+			return nil
+		},
+	}
+	return cmd
+}
+
+func settingValue(s setting) interface{} {
+	switch value := s.value.(type) {
+	case *bool:
+		return *value
+	case *int:
+		return *value
+	case *string:
+		return *value
+	case *[]string:
+		return *value
+	case *map[string]string:
+		return *value
+	case *config.PlankPodTemplate:
+		return *value
+	}
+	return nil
+}
+
+func formatSettingValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return fmt.Sprintf("%q", v)
+	case []string:
+		return fmt.Sprintf("[%v]", strings.Join(v, ","))
+	case map[string]string:
+		var pairs []string
+		for key, value := range v {
+			pairs = append(pairs, key+"="+value)
+		}
+		sort.Strings(pairs)
+		return fmt.Sprintf("{%v}", strings.Join(pairs, ","))
+	case config.PlankPodTemplate:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err.Error()
+		}
+		return string(data)
+	}
+	return fmt.Sprintf("%v", value)
+}
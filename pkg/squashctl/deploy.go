@@ -1,7 +1,9 @@
 package squashctl
 
 import (
+	"bytes"
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/solo-io/squash/pkg/demo"
@@ -12,6 +14,20 @@ import (
 
 var defaultDemoNamespace = "default"
 
+// demoDeployed reports a deployed demo
+type demoDeployed struct {
+	DemoId     string   `json:"demoId"`
+	Namespaces []string `json:"namespaces"`
+}
+
+// squashInstallation reports the installation of squash, or its preview
+type squashInstallation struct {
+	Namespace string `json:"namespace"`
+	Preview   bool   `json:"preview"`
+	// Manifest is the yaml of a preview
+	Manifest string `json:"manifest,omitempty"`
+}
+
 func (o *Options) DeployCmd() *cobra.Command {
 	dOpts := &o.DeployOptions
 	cmd := &cobra.Command{
@@ -41,12 +57,21 @@ func (o *Options) deployDemoCmd(demoOpts *DemoOptions) *cobra.Command {
 			}
 			switch demoOpts.DemoId {
 			case demo.DemoGoGo:
-				return demo.DeployGoGo(cs, demoOpts.Namespace1, demoOpts.Namespace2)
+				err = demo.DeployGoGo(cs, o.progressOutput(), demoOpts.Namespace1, demoOpts.Namespace2)
 			case demo.DemoGoJava:
-				return demo.DeployGoJava(cs, demoOpts.Namespace1, demoOpts.Namespace2)
+				err = demo.DeployGoJava(cs, o.progressOutput(), demoOpts.Namespace1, demoOpts.Namespace2)
 			default:
 				return fmt.Errorf("Please choose a valid demo option: %v", strings.Join(demo.DemoIds, ", "))
 			}
+			if err != nil {
+				return err
+			}
+			if o.Json {
+				return printJson(DemoDeployedKind, demoDeployed{
+					DemoId:     demoOpts.DemoId,
+					Namespaces: []string{demoOpts.Namespace1, demoOpts.Namespace2},
+				})
+			}
 			return nil
 		},
 	}
@@ -62,21 +87,27 @@ func (o *Options) ensureDemoDeployOpts(dOpts *DemoOptions) error {
 		if o.Squash.Machine {
 			dOpts.Namespace1 = defaultDemoNamespace
 		} else {
-			o.chooseAllowedNamespace(&dOpts.Namespace1, "Select a namespace for service 1.")
+			if err := o.chooseAllowedNamespace(&dOpts.Namespace1, "Select a namespace for service 1.", "demo-namespace1"); err != nil {
+				return err
+			}
 		}
 	}
 	if dOpts.Namespace2 == "" {
 		if o.Squash.Machine {
 			dOpts.Namespace2 = dOpts.Namespace1
 		} else {
-			o.chooseAllowedNamespace(&dOpts.Namespace2, "Select a namespace for service 2.")
+			if err := o.chooseAllowedNamespace(&dOpts.Namespace2, "Select a namespace for service 2.", "demo-namespace2"); err != nil {
+				return err
+			}
 		}
 	}
 	if dOpts.DemoId == "" {
 		if o.Squash.Machine {
-			dOpts.DemoId = defaultDemoNamespace
+			dOpts.DemoId = demo.DemoGoGo
 		} else {
-			o.chooseString("Choose a demo microservice to deploy", &dOpts.DemoId, demo.DemoIds)
+			if err := o.chooseString("Choose a demo microservice to deploy", &dOpts.DemoId, demo.DemoIds, "demo", "demo-id"); err != nil {
+				return err
+			}
 		}
 	}
 	return nil
@@ -94,7 +125,23 @@ func (o *Options) deploySquashCmd(spOpts *SquashProcessOptions) *cobra.Command {
 			if err != nil {
 				return err
 			}
-			return install.InstallSquash(cs, spOpts.Namespace, o.Squash.DebugContainerRepo, o.Squash.DebugContainerVersion, spOpts.Preview)
+			if !o.Json {
+				return install.InstallSquash(cs, os.Stdout, spOpts.Namespace, o.Squash.DebugContainerRepo, o.Squash.DebugContainerVersion, o.Squash.CRISock, o.Squash.PlankTemplate, spOpts.Preview)
+			}
+			// the preview is the result, rather than progress, so it goes into the json document
+			var out bytes.Buffer
+			progress := o.progressOutput()
+			if spOpts.Preview {
+				progress = &out
+			}
+			if err := install.InstallSquash(cs, progress, spOpts.Namespace, o.Squash.DebugContainerRepo, o.Squash.DebugContainerVersion, o.Squash.CRISock, o.Squash.PlankTemplate, spOpts.Preview); err != nil {
+				return err
+			}
+			return printJson(SquashInstallationKind, squashInstallation{
+				Namespace: spOpts.Namespace,
+				Preview:   spOpts.Preview,
+				Manifest:  out.String(),
+			})
 		},
 	}
 	f := cmd.Flags()
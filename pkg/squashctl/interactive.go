@@ -0,0 +1,66 @@
+package squashctl
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	isatty "github.com/mattn/go-isatty"
+	"gopkg.in/AlecAivazis/survey.v1"
+)
+
+// MissingValueError is returned instead of a prompt in non-interactive mode, when a value was neither passed nor configured
+type MissingValueError struct {
+	// Value describes the missing value, such as "namespace"
+	Value string
+	// Flag passes the value
+	Flag string
+	// Candidates lists the values that squashctl would have offered
+	Candidates []string
+}
+
+func (e *MissingValueError) Error() string {
+	if len(e.Candidates) == 0 {
+		return fmt.Sprintf("no %v given, pass --%v", e.Value, e.Flag)
+	}
+	return fmt.Sprintf("no %v given, pass --%v with one of: %v", e.Value, e.Flag, strings.Join(e.Candidates, ", "))
+}
+
+// stdinIsTerminal is false when squashctl runs in a script or in CI, where nobody can answer a prompt
+func stdinIsTerminal() bool {
+	fd := os.Stdin.Fd()
+	return isatty.IsTerminal(fd) || isatty.IsCygwinTerminal(fd)
+}
+
+// ensureInteractivity decides whether squashctl may prompt: never in machine mode, and never without a terminal
+func (o *Options) ensureInteractivity() {
+	o.NonInteractive = o.NonInteractive || o.Squash.Machine || !stdinIsTerminal()
+}
+
+// askOne prompts for a value, or fails with the missing value in non-interactive mode
+func (o *Options) askOne(missing *MissingValueError, p survey.Prompt, response interface{}, v survey.Validator) error {
+	if o.NonInteractive {
+		return missing
+	}
+	return survey.AskOne(p, response, v)
+}
+
+// confirm asks the user to confirm an action, unless --yes was passed
+func (o *Options) confirm(message string) error {
+	if o.Yes {
+		return nil
+	}
+	confirmed := false
+	prompt := &survey.Confirm{
+		Message: message,
+		Default: true,
+	}
+	missing := &MissingValueError{Value: "confirmation", Flag: "yes"}
+	if err := o.askOne(missing, prompt, &confirmed, nil); err != nil {
+		return err
+	}
+	if !confirmed {
+		return fmt.Errorf("user aborted")
+	}
+	return nil
+}
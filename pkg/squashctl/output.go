@@ -0,0 +1,147 @@
+package squashctl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/solo-io/squash/pkg/config"
+	"github.com/spf13/cobra"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// JsonVersion is the version of the documents that squashctl prints with --json.
+// It changes whenever a document changes in a way that would break its readers.
+const JsonVersion = "v1"
+
+// The kinds of json documents, each names the shape of the document's data
+const (
+	ErrorKind               = "Error"
+	EditorDataKind          = "EditorData"
+	SessionListKind         = "SessionList"
+	SessionDescriptionKind  = "SessionDescription"
+	SessionEndedKind        = "SessionEnded"
+	SquashStatusKind        = "SquashStatus"
+	SquashDeletedKind       = "SquashDeleted"
+	SquashInstallationKind  = "SquashInstallation"
+	MatchRequestKind        = "MatchRequest"
+	DemoDeployedKind        = "DemoDeployed"
+	ResourcesRegisteredKind = "ResourcesRegistered"
+	DeletedAttachmentsKind  = "DeletedAttachments"
+	DeletedPlankPodsKind    = "DeletedPlankPods"
+	DeletedPermissionsKind  = "DeletedPermissions"
+	ConfigViewKind          = "ConfigView"
+	ProfileSelectedKind     = "ProfileSelected"
+)
+
+// errors that are not kubernetes errors have this type
+const defaultErrorType = "Error"
+
+// missing values in non-interactive mode have this type
+const missingValueErrorType = "MissingValue"
+
+// JsonDocument is what every squashctl command prints when --json or --machine is set
+type JsonDocument struct {
+	Version string `json:"version"`
+	Kind    string `json:"kind"`
+	// Data is the command's result, its shape is determined by Kind
+	Data interface{} `json:"data,omitempty"`
+	// Error is set instead of Data when the command failed
+	Error *Error `json:"error,omitempty"`
+}
+
+// printJson prints the result of a command as a json document, on a single line
+func printJson(kind string, data interface{}) error {
+	out, err := json.Marshal(JsonDocument{
+		Version: JsonVersion,
+		Kind:    kind,
+		Data:    data,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(out))
+	return nil
+}
+
+// printEditorData reports a ready debug session to editor extensions
+func printEditorData(ed config.EditorData) error {
+	return printJson(EditorDataKind, ed)
+}
+
+// ReportError prints the error that a squashctl command failed with, as a json document when --json or --machine is set
+func ReportError(app *cobra.Command, err error) {
+	if !jsonRequested(app) {
+		fmt.Println(err)
+		return
+	}
+	jsonErr := &Error{
+		Type: errorType(err),
+		Info: err.Error(),
+	}
+	if missing, ok := errors.Cause(err).(*MissingValueError); ok {
+		jsonErr.Flag = missing.Flag
+		jsonErr.Candidates = missing.Candidates
+	}
+	out, marshalErr := json.Marshal(JsonDocument{
+		Version: JsonVersion,
+		Kind:    ErrorKind,
+		Error:   jsonErr,
+	})
+	if marshalErr != nil {
+		fmt.Println(err)
+		return
+	}
+	fmt.Println(string(out))
+}
+
+func jsonRequested(app *cobra.Command) bool {
+	asJson, _ := app.PersistentFlags().GetBool("json")
+	machine, _ := app.PersistentFlags().GetBool("machine")
+	return asJson || machine
+}
+
+func errorType(err error) string {
+	if _, ok := errors.Cause(err).(*MissingValueError); ok {
+		return missingValueErrorType
+	}
+	if reason := kerrors.ReasonForError(errors.Cause(err)); reason != metav1.StatusReasonUnknown {
+		return string(reason)
+	}
+	return defaultErrorType
+}
+
+// progressOutput is where commands report their progress: stdout, unless stdout is reserved for the json document
+func (o *Options) progressOutput() io.Writer {
+	if o.Json || o.Squash.Machine {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// resourceRef names a kubernetes resource in json documents
+type resourceRef struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// deletion reports the outcome of deleting one resource
+type deletion struct {
+	Kind string `json:"kind"`
+	resourceRef
+	Error string `json:"error,omitempty"`
+}
+
+func newDeletion(kind, namespace, name string, err error) deletion {
+	d := deletion{
+		Kind:        kind,
+		resourceRef: resourceRef{Name: name, Namespace: namespace},
+	}
+	if err != nil {
+		d.Error = err.Error()
+	}
+	return d
+}
@@ -0,0 +1,494 @@
+package squashctl
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/solo-io/solo-kit/pkg/api/v1/clients"
+	"github.com/solo-io/squash/pkg/actions"
+	v1 "github.com/solo-io/squash/pkg/api/v1"
+	"github.com/solo-io/squash/pkg/config"
+	sqOpts "github.com/solo-io/squash/pkg/options"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+)
+
+const sessionsCommandDescription = `A debug session lives for as long as its debug attachment does, which can be
+longer than the squashctl command that started it: sessions started in machine
+mode by editor extensions, sessions created for match requests, and sessions
+whose squashctl was interrupted all keep running in the cluster.
+Use these commands to find those sessions, reconnect to them, and end them.
+Sessions are named after their debug attachments.
+`
+
+// sessionSummary describes a debug session, it is what sessions list and sessions describe report
+type sessionSummary struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Debugger  string `json:"debugger"`
+	Pod       string `json:"pod,omitempty"`
+	Container string `json:"container,omitempty"`
+	// Workload is set when the pod was chosen from a workload, as in "deployment example-service1"
+	Workload string `json:"workload,omitempty"`
+	// PodSelector is only set for match requests, which debug every new pod that it selects
+	PodSelector string           `json:"podSelector,omitempty"`
+	Processes   []sessionProcess `json:"processes,omitempty"`
+	PlankPod    string           `json:"plankPod,omitempty"`
+	State       string           `json:"state"`
+	// Age is empty for debug attachments that did not record their creation time
+	Age string `json:"age,omitempty"`
+}
+
+// sessionProcess describes an attached process of a debug session
+type sessionProcess struct {
+	Container string `json:"container"`
+	Pid       int64  `json:"pid,omitempty"`
+	// DebugPod serves the debugger's port, in namespace/name form
+	DebugPod  string `json:"debugPod,omitempty"`
+	DebugPort int    `json:"debugPort,omitempty"`
+	// DebuggerUrl is the websocket url that the debugger reported, with the host it listens on in the cluster
+	DebuggerUrl string `json:"debuggerUrl,omitempty"`
+}
+
+// sessionDescription is the detailed report of sessions describe
+type sessionDescription struct {
+	sessionSummary
+	ContainerRuntime  *v1.ContainerRuntime `json:"containerRuntime,omitempty"`
+	ReattachmentCount uint32               `json:"reattachmentCount,omitempty"`
+	PlankEvents       []sessionEvent       `json:"plankEvents,omitempty"`
+	PlankLogs         string               `json:"plankLogs,omitempty"`
+	// PlankError explains why the plank's events or logs are missing
+	PlankError string `json:"plankError,omitempty"`
+}
+
+type sessionEvent struct {
+	Type    string `json:"type"`
+	Reason  string `json:"reason"`
+	Age     string `json:"age"`
+	Message string `json:"message"`
+}
+
+func (o *Options) SessionsCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "sessions",
+		Short:   "list, inspect, reconnect to, and end debug sessions",
+		Long:    sessionsCommandDescription,
+		Example: "squashctl sessions list",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return nil
+		},
+	}
+
+	cmd.AddCommand(
+		o.sessionsListCmd(),
+		o.sessionsDescribeCmd(),
+		o.sessionsConnectCmd(),
+		o.sessionsEndCmd(),
+	)
+
+	return cmd
+}
+
+func (o *Options) sessionsListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "list the debug sessions, restricted to one namespace by --namespace",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			das, err := o.listSessions()
+			if err != nil {
+				return err
+			}
+			summaries := []sessionSummary{}
+			for _, da := range das {
+				summaries = append(summaries, summarizeSession(da, time.Now()))
+			}
+			if o.Json {
+				return printJson(SessionListKind, summaries)
+			}
+			if len(summaries) == 0 {
+				fmt.Println("Found no debug sessions")
+				return nil
+			}
+			return printSessionTable(summaries)
+		},
+	}
+	return cmd
+}
+
+func (o *Options) sessionsDescribeCmd() *cobra.Command {
+	var tailLines int64
+	cmd := &cobra.Command{
+		Use:   "describe <name>",
+		Short: "show the details of a debug session, with the events and logs of its plank",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			da, err := o.getSession(args[0])
+			if err != nil {
+				return err
+			}
+			description := o.describeSession(da, tailLines)
+			if o.Json {
+				return printJson(SessionDescriptionKind, description)
+			}
+			printSessionDescription(description)
+			return nil
+		},
+	}
+	cmd.Flags().Int64Var(&tailLines, "tail", 50, "number of plank log lines to show")
+	return cmd
+}
+
+func (o *Options) sessionsConnectCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "connect <name>",
+		Short: "connect the local debugger to an attached debug session",
+		Long: `Forwards local ports to the debuggers of an attached debug session and starts the local
+debugger client. The session keeps running after the debugger client exits, end it with
+squashctl sessions end. With --json or --machine, the forwarded addresses are printed
+for editor extensions instead, and forwarded until squashctl is interrupted.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			da, err := o.getSession(args[0])
+			if err != nil {
+				return err
+			}
+			if da.MatchRequest {
+				return fmt.Errorf("%v is a match request, connect to one of the sessions it created for a matching pod", da.Metadata.Name)
+			}
+			if da.State != v1.DebugAttachment_Attached {
+				return fmt.Errorf("session %v is not attached, its state is %v", da.Metadata.Name, da.State)
+			}
+			daClient, err := o.getDAClient()
+			if err != nil {
+				return err
+			}
+			s := o.Squash
+			s.Debugger = da.GetIntent().GetDebugger()
+			return s.ConnectToDebugAttachment(daClient, da)
+		},
+	}
+	return cmd
+}
+
+func (o *Options) sessionsEndCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "end <name>",
+		Short: "end a debug session: detach its debugger and delete its plank",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			da, err := o.getSession(args[0])
+			if err != nil {
+				return err
+			}
+			if err := o.endSession(da); err != nil {
+				return err
+			}
+			if o.Json {
+				return printJson(SessionEndedKind, resourceRef{
+					Name:      da.Metadata.Name,
+					Namespace: da.Metadata.Namespace,
+				})
+			}
+			fmt.Printf("Ended session %v in namespace %v\n", da.Metadata.Name, da.Metadata.Namespace)
+			return nil
+		},
+	}
+	return cmd
+}
+
+// listSessions returns the debug attachments in the --namespace namespace, or in every namespace
+func (o *Options) listSessions() (v1.DebugAttachmentList, error) {
+	var das v1.DebugAttachmentList
+	if o.Squash.Namespace == "" {
+		all, err := o.getAllDebugAttachments()
+		if err != nil {
+			return nil, err
+		}
+		das = all
+	} else {
+		daClient, err := o.getDAClient()
+		if err != nil {
+			return nil, err
+		}
+		das, err = daClient.List(o.Squash.Namespace, clients.ListOpts{Ctx: o.ctx})
+		if err != nil {
+			return nil, err
+		}
+	}
+	das.ConvertDeprecatedFields()
+	sort.SliceStable(das, func(i, j int) bool {
+		if das[i].Metadata.Namespace != das[j].Metadata.Namespace {
+			return das[i].Metadata.Namespace < das[j].Metadata.Namespace
+		}
+		return das[i].Metadata.Name < das[j].Metadata.Name
+	})
+	return das, nil
+}
+
+// getSession finds a debug attachment by name, in the --namespace namespace if it is given
+func (o *Options) getSession(name string) (*v1.DebugAttachment, error) {
+	var da *v1.DebugAttachment
+	if o.Squash.Namespace == "" {
+		named, err := o.getNamedDebugAttachment(name)
+		if err != nil {
+			return nil, fmt.Errorf("%v, pass --namespace to choose a session by namespace", err)
+		}
+		da = named
+	} else {
+		daClient, err := o.getDAClient()
+		if err != nil {
+			return nil, err
+		}
+		da, err = daClient.Read(o.Squash.Namespace, name, clients.ReadOpts{Ctx: o.ctx})
+		if err != nil {
+			return nil, err
+		}
+	}
+	da.ConvertDeprecatedFields()
+	return da, nil
+}
+
+// endSession detaches the session's debugger and removes its plank.
+// In secure mode, squash does this when the debug attachment requests deletion. Otherwise squashctl deletes
+// the debug attachment, waits for plank to detach and exit, then deletes the plank pod.
+func (o *Options) endSession(da *v1.DebugAttachment) error {
+	if o.Config.secureMode {
+		uc, err := actions.NewUserController(o.Squash.KubeConfig)
+		if err != nil {
+			return err
+		}
+		_, err = uc.RequestDelete(da.Metadata.Namespace, da.Metadata.Name)
+		return err
+	}
+
+	daClient, err := o.getDAClient()
+	if err != nil {
+		return err
+	}
+	if err := daClient.Delete(da.Metadata.Namespace, da.Metadata.Name, clients.DeleteOpts{Ctx: o.ctx}); err != nil {
+		return err
+	}
+	cs, err := o.getKubeClient()
+	if err != nil {
+		return err
+	}
+	if err := config.RevokeEphemeralPlankAccess(cs, da); err != nil {
+		o.info(fmt.Sprintf("Could not delete the service account of the ephemeral plank: %v", err))
+	}
+	plankPod := da.GetPlank().GetPod()
+	if plankPod == nil || da.GetPlank().GetEphemeralContainer() != "" {
+		// ephemeral planks exit once their debug attachment is deleted, and cannot be removed from the target pod
+		return nil
+	}
+	pods := cs.CoreV1().Pods(plankPod.Namespace)
+	deadline := time.Now().Add(sqOpts.PlankDetachTimeout)
+	for time.Now().Before(deadline) {
+		pod, err := pods.Get(plankPod.Name, metav1.GetOptions{})
+		if err != nil || (pod.Status.Phase != corev1.PodPending && pod.Status.Phase != corev1.PodRunning) {
+			break
+		}
+		time.Sleep(time.Second)
+	}
+	if err := pods.Delete(plankPod.Name, &metav1.DeleteOptions{}); err != nil {
+		o.info(fmt.Sprintf("Could not delete plank pod %v: %v", plankPod.Name, err))
+	}
+	return nil
+}
+
+func summarizeSession(da *v1.DebugAttachment, now time.Time) sessionSummary {
+	summary := sessionSummary{
+		Name:        da.Metadata.Name,
+		Namespace:   da.Metadata.Namespace,
+		Debugger:    da.GetIntent().GetDebugger(),
+		Pod:         da.GetIntent().GetPod().GetName(),
+		Container:   da.GetIntent().GetContainerName(),
+		PodSelector: da.GetIntent().GetPodSelector(),
+		State:       da.State.String(),
+	}
+	if da.MatchRequest {
+		summary.Pod = ""
+	}
+	if workload := da.GetIntent().GetWorkload(); workload != nil {
+		summary.Workload = describeWorkload(workload)
+	}
+	if plankPod := da.GetPlank().GetPod(); plankPod != nil {
+		summary.PlankPod = fmt.Sprintf("%v/%v", plankPod.Namespace, plankPod.Name)
+		if ephemeral := da.GetPlank().GetEphemeralContainer(); ephemeral != "" {
+			summary.PlankPod = fmt.Sprintf("%v (ephemeral container %v)", summary.PlankPod, ephemeral)
+		}
+	}
+	if created, err := time.Parse(time.RFC3339, da.Metadata.Annotations[sqOpts.CreatedAtAnnotation]); err == nil {
+		summary.Age = shortAge(now.Sub(created))
+	}
+	for _, p := range da.ListAttachedProcesses() {
+		process := sessionProcess{
+			Container:   p.ContainerName,
+			Pid:         p.Pid,
+			DebuggerUrl: p.DebuggerUrl,
+		}
+		if pod, err := da.GetProcessDebugPod(p); err == nil {
+			process.DebugPod = fmt.Sprintf("%v/%v", pod.Namespace, pod.Name)
+		}
+		if port, err := da.GetProcessDebugPort(p); err == nil {
+			process.DebugPort = port
+		}
+		summary.Processes = append(summary.Processes, process)
+	}
+	return summary
+}
+
+// describeSession gathers the details of a session. Plank's events and logs are best effort,
+// the plank pod may already be gone.
+func (o *Options) describeSession(da *v1.DebugAttachment, tailLines int64) sessionDescription {
+	description := sessionDescription{
+		sessionSummary:    summarizeSession(da, time.Now()),
+		ContainerRuntime:  da.GetContainerRuntime(),
+		ReattachmentCount: da.GetReattachments().GetCount(),
+	}
+	plankPod := da.GetPlank().GetPod()
+	if plankPod == nil {
+		return description
+	}
+	cs, err := o.getKubeClient()
+	if err != nil {
+		description.PlankError = err.Error()
+		return description
+	}
+
+	events, err := cs.CoreV1().Events(plankPod.Namespace).List(metav1.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector("involvedObject.name", plankPod.Name).String(),
+	})
+	if err != nil {
+		description.PlankError = fmt.Sprintf("listing events: %v", err)
+		return description
+	}
+	sort.SliceStable(events.Items, func(i, j int) bool {
+		return events.Items[i].LastTimestamp.Before(&events.Items[j].LastTimestamp)
+	})
+	for _, e := range events.Items {
+		description.PlankEvents = append(description.PlankEvents, sessionEvent{
+			Type:    e.Type,
+			Reason:  e.Reason,
+			Age:     shortAge(time.Since(e.LastTimestamp.Time)),
+			Message: strings.TrimSpace(e.Message),
+		})
+	}
+
+	logOpts := &corev1.PodLogOptions{TailLines: &tailLines}
+	if ephemeral := da.GetPlank().GetEphemeralContainer(); ephemeral != "" {
+		logOpts.Container = ephemeral
+	}
+	logs, err := cs.CoreV1().Pods(plankPod.Namespace).GetLogs(plankPod.Name, logOpts).Do().Raw()
+	if err != nil {
+		description.PlankError = fmt.Sprintf("reading logs: %v", err)
+		return description
+	}
+	description.PlankLogs = string(logs)
+	return description
+}
+
+func printSessionTable(summaries []sessionSummary) error {
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "NAMESPACE\tNAME\tDEBUGGER\tTARGET\tPID\tPLANK\tSTATE\tAGE\tPORT")
+	for _, s := range summaries {
+		target := fmt.Sprintf("%v/%v", s.Pod, s.Container)
+		if s.PodSelector != "" {
+			target = fmt.Sprintf("pods matching %v", s.PodSelector)
+		}
+		var pids, ports []string
+		for _, p := range s.Processes {
+			if p.Pid != 0 {
+				pids = append(pids, strconv.FormatInt(p.Pid, 10))
+			}
+			if p.DebugPort != 0 {
+				ports = append(ports, strconv.Itoa(p.DebugPort))
+			}
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\n",
+			s.Namespace, s.Name, s.Debugger, target, orNone(strings.Join(pids, ",")), orNone(s.PlankPod),
+			s.State, orNone(s.Age), orNone(strings.Join(ports, ",")))
+	}
+	return w.Flush()
+}
+
+func printSessionDescription(d sessionDescription) {
+	fmt.Printf("Name:         %v\n", d.Name)
+	fmt.Printf("Namespace:    %v\n", d.Namespace)
+	fmt.Printf("State:        %v\n", d.State)
+	fmt.Printf("Age:          %v\n", orNone(d.Age))
+	fmt.Printf("Debugger:     %v\n", d.Debugger)
+	if d.PodSelector != "" {
+		fmt.Printf("Pod selector: %v\n", d.PodSelector)
+	} else {
+		fmt.Printf("Target:       pod %v, container %v\n", d.Pod, d.Container)
+	}
+	if d.Workload != "" {
+		fmt.Printf("Workload:     %v\n", d.Workload)
+	}
+	fmt.Printf("Plank:        %v\n", orNone(d.PlankPod))
+	if d.ContainerRuntime != nil {
+		fmt.Printf("Runtime:      %v %v (%v)\n", d.ContainerRuntime.Name, d.ContainerRuntime.Version, d.ContainerRuntime.Socket)
+	}
+	if d.ReattachmentCount > 0 {
+		fmt.Printf("Reattached:   %v times\n", d.ReattachmentCount)
+	}
+	fmt.Println("Processes:")
+	if len(d.Processes) == 0 {
+		fmt.Println("  <none>")
+	}
+	for _, p := range d.Processes {
+		fmt.Printf("  pid %v in container %v, debugger on %v port %v\n", orNone(strconv.FormatInt(p.Pid, 10)), p.Container, orNone(p.DebugPod), p.DebugPort)
+		if p.DebuggerUrl != "" {
+			fmt.Printf("    websocket url %v\n", p.DebuggerUrl)
+		}
+	}
+	if d.PlankError != "" {
+		fmt.Printf("Plank details unavailable: %v\n", d.PlankError)
+	}
+	if len(d.PlankEvents) > 0 {
+		fmt.Println("Plank events:")
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "  TYPE\tREASON\tAGE\tMESSAGE")
+		for _, e := range d.PlankEvents {
+			fmt.Fprintf(w, "  %v\t%v\t%v\t%v\n", e.Type, e.Reason, e.Age, e.Message)
+		}
+		w.Flush()
+	}
+	if d.PlankLogs != "" {
+		fmt.Println("Plank logs:")
+		for _, line := range strings.Split(strings.TrimRight(d.PlankLogs, "\n"), "\n") {
+			fmt.Printf("  %v\n", line)
+		}
+	}
+}
+
+// shortAge formats a duration the way kubectl formats ages: 45s, 12m, 5h, 3d
+func shortAge(d time.Duration) string {
+	if d < 0 {
+		// clock skew between this machine and the one that recorded the time
+		d = 0
+	}
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dd", int(d.Hours()/24))
+}
+
+func orNone(s string) string {
+	if s == "" || s == "0" {
+		return "<none>"
+	}
+	return s
+}
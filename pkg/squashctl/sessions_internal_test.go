@@ -0,0 +1,120 @@
+package squashctl
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/solo-io/solo-kit/pkg/api/v1/resources/core"
+	v1 "github.com/solo-io/squash/pkg/api/v1"
+	sqOpts "github.com/solo-io/squash/pkg/options"
+)
+
+var _ = Describe("summarizeSession", func() {
+	now := time.Date(2019, 3, 4, 5, 6, 7, 0, time.UTC)
+
+	It("should describe a session attached through its plank pod", func() {
+		da := &v1.DebugAttachment{
+			Metadata: core.Metadata{
+				Name:        "session",
+				Namespace:   "default",
+				Annotations: map[string]string{sqOpts.CreatedAtAnnotation: "2019-03-04T04:56:07Z"},
+			},
+			Intent: &v1.Intent{
+				Debugger:      "dlv",
+				Pod:           &core.ResourceRef{Name: "app-1234", Namespace: "default"},
+				ContainerName: "app",
+				Workload:      &v1.Workload{Kind: workloadDeployment, Name: "app", Selector: "app=app"},
+			},
+			Plank: &v1.Plank{Pod: &core.ResourceRef{Name: "plank-xyz", Namespace: "squash-debugger"}},
+			AttachedProcesses: []*v1.AttachedProcess{
+				{ContainerName: "app", Pid: 7, PortSpec: v1.NewPlankPortSpec(2345)},
+				{ContainerName: "sidecar", Pid: 9, PortSpec: v1.NewTargetPortSpec(9229), DebuggerUrl: "ws://10.0.0.4:9229/4b5c"},
+			},
+			State: v1.DebugAttachment_Attached,
+		}
+		Expect(summarizeSession(da, now)).To(Equal(sessionSummary{
+			Name:      "session",
+			Namespace: "default",
+			Debugger:  "dlv",
+			Pod:       "app-1234",
+			Container: "app",
+			Workload:  "deployment app",
+			Processes: []sessionProcess{
+				{Container: "app", Pid: 7, DebugPod: "squash-debugger/plank-xyz", DebugPort: 2345},
+				{Container: "sidecar", Pid: 9, DebugPod: "default/app-1234", DebugPort: 9229, DebuggerUrl: "ws://10.0.0.4:9229/4b5c"},
+			},
+			PlankPod: "squash-debugger/plank-xyz",
+			State:    "Attached",
+			Age:      "10m",
+		}))
+	})
+
+	It("should name the ephemeral container of an ephemeral plank", func() {
+		da := &v1.DebugAttachment{
+			Metadata: core.Metadata{Name: "session", Namespace: "default"},
+			Intent:   &v1.Intent{Debugger: "dlv", Pod: &core.ResourceRef{Name: "app-1234", Namespace: "default"}, ContainerName: "app"},
+			Plank: &v1.Plank{
+				Pod:                &core.ResourceRef{Name: "app-1234", Namespace: "default"},
+				EphemeralContainer: "plank-session",
+			},
+			State: v1.DebugAttachment_PendingAttachment,
+		}
+		summary := summarizeSession(da, now)
+		Expect(summary.PlankPod).To(Equal("default/app-1234 (ephemeral container plank-session)"))
+		Expect(summary.State).To(Equal("PendingAttachment"))
+		Expect(summary.Processes).To(BeEmpty())
+		// the creation time was not recorded
+		Expect(summary.Age).To(BeEmpty())
+	})
+
+	It("should describe a match request by its selector rather than a pod", func() {
+		da := &v1.DebugAttachment{
+			Metadata: core.Metadata{Name: "match", Namespace: "default"},
+			Intent: &v1.Intent{
+				Debugger:      "dlv",
+				Pod:           &core.ResourceRef{Namespace: "default"},
+				ContainerName: "app",
+				PodSelector:   "app=app",
+				Workload:      &v1.Workload{Kind: workloadSelector, Selector: "app=app"},
+			},
+			MatchRequest: true,
+			State:        v1.DebugAttachment_Attached,
+		}
+		summary := summarizeSession(da, now)
+		Expect(summary.Pod).To(BeEmpty())
+		Expect(summary.PodSelector).To(Equal("app=app"))
+		Expect(summary.Workload).To(Equal("selector app=app"))
+		Expect(summary.PlankPod).To(BeEmpty())
+	})
+
+	It("should describe a session written before processes were listed by their port spec", func() {
+		da := &v1.DebugAttachment{
+			Metadata: core.Metadata{Name: "session", Namespace: "default"},
+			Intent:   &v1.Intent{Debugger: "java", Pod: &core.ResourceRef{Name: "app-1234", Namespace: "default"}, ContainerName: "app"},
+			PortSpec: v1.NewTargetPortSpec(5005),
+			State:    v1.DebugAttachment_Attached,
+		}
+		Expect(summarizeSession(da, now).Processes).To(Equal([]sessionProcess{
+			{Container: "app", DebugPod: "default/app-1234", DebugPort: 5005},
+		}))
+	})
+})
+
+var _ = Describe("shortAge", func() {
+	It("should use the largest unit that fits", func() {
+		for _, c := range []struct {
+			age      time.Duration
+			expected string
+		}{
+			{age: -time.Minute, expected: "0s"},
+			{age: 42 * time.Second, expected: "42s"},
+			{age: 90 * time.Minute, expected: "1h"},
+			{age: 59 * time.Minute, expected: "59m"},
+			{age: 47 * time.Hour, expected: "47h"},
+			{age: 72 * time.Hour, expected: "3d"},
+		} {
+			Expect(shortAge(c.age)).To(Equal(c.expected), c.age.String())
+		}
+	})
+})
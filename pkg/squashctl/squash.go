@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/solo-io/go-utils/cliutils"
+	"github.com/solo-io/solo-kit/pkg/api/v1/resources/core"
+	"github.com/solo-io/squash/pkg/actions"
 	squashutils "github.com/solo-io/squash/pkg/utils"
 	"github.com/solo-io/squash/pkg/utils/kubeutils"
 	"github.com/spf13/cobra"
@@ -32,11 +35,19 @@ func (o *Options) SquashCmd() *cobra.Command {
 	cmd.AddCommand(
 		o.squashStatusCmd(),
 		o.squashDeleteCmd(),
+		o.squashMatchRequestCmd(),
 	)
 
 	return cmd
 }
 
+// squashStatus reports where squash is deployed
+type squashStatus struct {
+	// Namespaces that were searched for squash
+	Namespaces  []string      `json:"namespaces"`
+	Deployments []resourceRef `json:"deployments"`
+}
+
 func (o *Options) squashStatusCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "status",
@@ -50,11 +61,18 @@ func (o *Options) squashStatusCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
-			fmt.Printf("looking for Squash process in namespaces %v\n", strings.Join(nsList, ", "))
+			fmt.Fprintf(o.progressOutput(), "looking for Squash process in namespaces %v\n", strings.Join(nsList, ", "))
 			squashDeployments, err := squashutils.ListSquashDeployments(cs, nsList)
 			if err != nil {
 				return err
 			}
+			if o.Json {
+				status := squashStatus{Namespaces: nsList, Deployments: []resourceRef{}}
+				for _, dep := range squashDeployments {
+					status.Deployments = append(status.Deployments, resourceRef{Name: dep.ObjectMeta.Name, Namespace: dep.ObjectMeta.Namespace})
+				}
+				return printJson(SquashStatusKind, status)
+			}
 
 			switch len(squashDeployments) {
 			case 0:
@@ -76,6 +94,12 @@ func (o *Options) squashStatusCmd() *cobra.Command {
 	return cmd
 }
 
+// squashDeleted reports how many squash deployments were deleted from a namespace
+type squashDeleted struct {
+	Namespace          string `json:"namespace"`
+	DeletedDeployments int    `json:"deletedDeployments"`
+}
+
 func (o *Options) squashDeleteCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "delete",
@@ -85,7 +109,7 @@ func (o *Options) squashDeleteCmd() *cobra.Command {
 				return fmt.Errorf("Please specify one namespace")
 			}
 			ns := args[0]
-			fmt.Printf("Looking for Squash process in namespace %v\n", ns)
+			fmt.Fprintf(o.progressOutput(), "Looking for Squash process in namespace %v\n", ns)
 			cs, err := o.getKubeClient()
 			if err != nil {
 				return err
@@ -95,19 +119,79 @@ func (o *Options) squashDeleteCmd() *cobra.Command {
 				return err
 			}
 
-			switch len(squashDeployments) {
-			case 0:
-				fmt.Println("Found no Squash deployments")
-				return nil
-			default:
-				count, err := squashutils.DeleteSquashDeployments(cs, squashDeployments)
+			count := 0
+			if len(squashDeployments) > 0 {
+				count, err = squashutils.DeleteSquashDeployments(cs, squashDeployments)
 				if err != nil {
 					return fmt.Errorf("Deleted %v deployments: %v", count, err)
 				}
-				fmt.Printf("Deleted %v deployments\n", count)
+			}
+			if o.Json {
+				return printJson(SquashDeletedKind, squashDeleted{Namespace: ns, DeletedDeployments: count})
+			}
+			if len(squashDeployments) == 0 {
+				fmt.Println("Found no Squash deployments")
 				return nil
 			}
+			fmt.Printf("Deleted %v deployments\n", count)
+			return nil
+		},
+	}
+	return cmd
+}
+
+// matchRequest reports a created match request
+type matchRequest struct {
+	resourceRef
+	PodSelector string `json:"podSelector"`
+}
+
+func (o *Options) squashMatchRequestCmd() *cobra.Command {
+	var podSelector string
+	cmd := &cobra.Command{
+		Use:   "match-request",
+		Short: "debug each new pod that matches a label selector",
+		Long: `Asks Squash to debug every pod in --namespace that matches --pod-selector and starts after
+the request is made. Squash creates a debug attachment of its own for each matching pod as soon as
+the pod is scheduled, so that the debugger attaches when the target container starts. Delete the
+match request to stop debugging new pods and end the sessions it started.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if podSelector == "" {
+				return fmt.Errorf("Please specify a pod selector with --pod-selector")
+			}
+			if o.Squash.Namespace == "" {
+				return fmt.Errorf("Please specify a namespace with --namespace")
+			}
+			if o.Squash.Debugger == "" {
+				return fmt.Errorf("Please specify a debugger with --debugger")
+			}
+			if err := o.validateFollow(); err != nil {
+				return err
+			}
+			if err := o.ensureSquashIsInCluster(); err != nil {
+				return err
+			}
+			uc, err := actions.NewUserController(o.Squash.KubeConfig)
+			if err != nil {
+				return err
+			}
+			intent := o.Squash.GetIntent()
+			intent.Pod = &core.ResourceRef{Namespace: o.Squash.Namespace}
+			intent.PodSelector = podSelector
+			da, err := uc.MatchRequest(cliutils.RandKubeNameBytes(10), intent)
+			if err != nil {
+				return err
+			}
+			if o.Json {
+				return printJson(MatchRequestKind, matchRequest{
+					resourceRef: resourceRef{Name: da.Metadata.Name, Namespace: da.Metadata.Namespace},
+					PodSelector: podSelector,
+				})
+			}
+			fmt.Printf("Created match request %v in namespace %v\n", da.Metadata.Name, da.Metadata.Namespace)
+			return nil
 		},
 	}
+	cmd.Flags().StringVar(&podSelector, "pod-selector", "", "label selector for the pods to debug, for example app=example-service1")
 	return cmd
 }
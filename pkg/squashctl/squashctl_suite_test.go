@@ -0,0 +1,13 @@
+package squashctl_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestSquashctl(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Squashctl Suite")
+}
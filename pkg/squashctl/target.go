@@ -0,0 +1,140 @@
+package squashctl
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	v1 "github.com/solo-io/squash/pkg/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+)
+
+// the kinds of workload that the pod to debug can be chosen from
+const (
+	workloadSelector    = "Selector"
+	workloadDeployment  = "Deployment"
+	workloadStatefulSet = "StatefulSet"
+	workloadService     = "Service"
+)
+
+// resolveWorkload turns the --selector, --deployment, --statefulset or --service flag into the label selector
+// of the pods to choose from. It returns nil when none of them was passed.
+func (o *Options) resolveWorkload() (*v1.Workload, error) {
+	return o.resolveWorkloadWith(func() (kubernetes.Interface, error) {
+		return o.getKubeClient()
+	})
+}
+
+// resolveWorkloadWith reads the workload with the client that getKubeClient returns, it is only called for
+// the workloads that are read from the cluster
+func (o *Options) resolveWorkloadWith(getKubeClient func() (kubernetes.Interface, error)) (*v1.Workload, error) {
+	var passed []string
+	for _, f := range []struct{ flag, value string }{
+		{"selector", o.Squash.Selector},
+		{"deployment", o.Squash.Deployment},
+		{"statefulset", o.Squash.StatefulSet},
+		{"service", o.Squash.Service},
+	} {
+		if f.value != "" {
+			passed = append(passed, "--"+f.flag)
+		}
+	}
+	switch {
+	case len(passed) == 0:
+		return nil, nil
+	case len(passed) > 1:
+		return nil, fmt.Errorf("%v cannot be combined, pass only one of them", strings.Join(passed, " and "))
+	case o.Squash.Pod != "":
+		return nil, fmt.Errorf("--pod cannot be combined with %v", passed[0])
+	}
+
+	if o.Squash.Selector != "" {
+		selector, err := labels.Parse(o.Squash.Selector)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing selector %v", o.Squash.Selector)
+		}
+		return &v1.Workload{Kind: workloadSelector, Selector: selector.String()}, nil
+	}
+
+	cs, err := getKubeClient()
+	if err != nil {
+		return nil, err
+	}
+	namespace := o.Squash.Namespace
+	switch {
+	case o.Squash.Deployment != "":
+		deployment, err := cs.AppsV1().Deployments(namespace).Get(o.Squash.Deployment, meta_v1.GetOptions{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading deployment %v", o.Squash.Deployment)
+		}
+		return labelSelectorWorkload(workloadDeployment, deployment.Name, deployment.Spec.Selector)
+	case o.Squash.StatefulSet != "":
+		statefulSet, err := cs.AppsV1().StatefulSets(namespace).Get(o.Squash.StatefulSet, meta_v1.GetOptions{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading stateful set %v", o.Squash.StatefulSet)
+		}
+		return labelSelectorWorkload(workloadStatefulSet, statefulSet.Name, statefulSet.Spec.Selector)
+	default:
+		service, err := cs.CoreV1().Services(namespace).Get(o.Squash.Service, meta_v1.GetOptions{})
+		if err != nil {
+			return nil, errors.Wrapf(err, "reading service %v", o.Squash.Service)
+		}
+		if len(service.Spec.Selector) == 0 {
+			return nil, fmt.Errorf("service %v has no selector, its pods cannot be found", service.Name)
+		}
+		return &v1.Workload{
+			Kind:     workloadService,
+			Name:     service.Name,
+			Selector: labels.SelectorFromSet(service.Spec.Selector).String(),
+		}, nil
+	}
+}
+
+func labelSelectorWorkload(kind, name string, labelSelector *meta_v1.LabelSelector) (*v1.Workload, error) {
+	selector, err := meta_v1.LabelSelectorAsSelector(labelSelector)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading the selector of %v %v", kind, name)
+	}
+	if selector.Empty() {
+		return nil, fmt.Errorf("%v %v has no selector, its pods cannot be found", kind, name)
+	}
+	return &v1.Workload{Kind: kind, Name: name, Selector: selector.String()}, nil
+}
+
+// describeWorkload names a workload in messages, as in "deployment example-service1"
+func describeWorkload(workload *v1.Workload) string {
+	if workload.GetKind() == workloadSelector {
+		return "selector " + workload.GetSelector()
+	}
+	return strings.ToLower(workload.GetKind()) + " " + workload.GetName()
+}
+
+// preferReady returns the ready pods, or every pod that is not being deleted if none is ready
+func preferReady(pods []corev1.Pod) []corev1.Pod {
+	var ready, live []corev1.Pod
+	for _, pod := range pods {
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		live = append(live, pod)
+		if podReady(pod) {
+			ready = append(ready, pod)
+		}
+	}
+	if len(ready) > 0 {
+		return ready
+	}
+	return live
+}
+
+func podReady(pod corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
@@ -0,0 +1,213 @@
+package squashctl
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "github.com/solo-io/squash/pkg/api/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	meta_v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+var _ = Describe("resolveWorkload", func() {
+	const namespace = "default"
+	meta := func(name string) meta_v1.ObjectMeta {
+		return meta_v1.ObjectMeta{Name: name, Namespace: namespace}
+	}
+	kubeClient := func() (kubernetes.Interface, error) {
+		return fake.NewSimpleClientset(
+			&appsv1.Deployment{
+				ObjectMeta: meta("web"),
+				Spec: appsv1.DeploymentSpec{Selector: &meta_v1.LabelSelector{
+					MatchLabels: map[string]string{"app": "web"},
+					MatchExpressions: []meta_v1.LabelSelectorRequirement{
+						{Key: "tier", Operator: meta_v1.LabelSelectorOpIn, Values: []string{"frontend"}},
+					},
+				}},
+			},
+			&appsv1.Deployment{ObjectMeta: meta("unselective"), Spec: appsv1.DeploymentSpec{Selector: &meta_v1.LabelSelector{}}},
+			&appsv1.StatefulSet{
+				ObjectMeta: meta("db"),
+				Spec:       appsv1.StatefulSetSpec{Selector: &meta_v1.LabelSelector{MatchLabels: map[string]string{"app": "db"}}},
+			},
+			&corev1.Service{ObjectMeta: meta("api"), Spec: corev1.ServiceSpec{Selector: map[string]string{"app": "api", "tier": "backend"}}},
+			&corev1.Service{ObjectMeta: meta("external")},
+		), nil
+	}
+
+	It("should turn each workload flag into the selector of its pods", func() {
+		for _, c := range []struct {
+			description string
+			squash      func(o *Options)
+			workload    *v1.Workload
+			err         string
+		}{
+			{
+				description: "no workload",
+				squash:      func(o *Options) { o.Squash.Pod = "web-1234" },
+			},
+			{
+				description: "selector",
+				squash:      func(o *Options) { o.Squash.Selector = "tier=frontend,app=web" },
+				workload:    &v1.Workload{Kind: workloadSelector, Selector: "app=web,tier=frontend"},
+			},
+			{
+				description: "invalid selector",
+				squash:      func(o *Options) { o.Squash.Selector = "app in (web" },
+				err:         "parsing selector app in (web",
+			},
+			{
+				description: "deployment",
+				squash:      func(o *Options) { o.Squash.Deployment = "web" },
+				workload:    &v1.Workload{Kind: workloadDeployment, Name: "web", Selector: "app=web,tier in (frontend)"},
+			},
+			{
+				description: "deployment without a selector",
+				squash:      func(o *Options) { o.Squash.Deployment = "unselective" },
+				err:         "Deployment unselective has no selector, its pods cannot be found",
+			},
+			{
+				description: "missing deployment",
+				squash:      func(o *Options) { o.Squash.Deployment = "missing" },
+				err:         "reading deployment missing",
+			},
+			{
+				description: "stateful set",
+				squash:      func(o *Options) { o.Squash.StatefulSet = "db" },
+				workload:    &v1.Workload{Kind: workloadStatefulSet, Name: "db", Selector: "app=db"},
+			},
+			{
+				description: "missing stateful set",
+				squash:      func(o *Options) { o.Squash.StatefulSet = "missing" },
+				err:         "reading stateful set missing",
+			},
+			{
+				description: "service",
+				squash:      func(o *Options) { o.Squash.Service = "api" },
+				workload:    &v1.Workload{Kind: workloadService, Name: "api", Selector: "app=api,tier=backend"},
+			},
+			{
+				description: "service without a selector",
+				squash:      func(o *Options) { o.Squash.Service = "external" },
+				err:         "service external has no selector, its pods cannot be found",
+			},
+			{
+				description: "two workloads",
+				squash: func(o *Options) {
+					o.Squash.Deployment = "web"
+					o.Squash.Service = "api"
+				},
+				err: "--deployment and --service cannot be combined, pass only one of them",
+			},
+			{
+				description: "a pod and a workload",
+				squash: func(o *Options) {
+					o.Squash.Pod = "web-1234"
+					o.Squash.Deployment = "web"
+				},
+				err: "--pod cannot be combined with --deployment",
+			},
+		} {
+			o := NewOptions()
+			o.Squash.Namespace = namespace
+			c.squash(o)
+			workload, err := o.resolveWorkloadWith(kubeClient)
+			if c.err != "" {
+				Expect(err).To(MatchError(ContainSubstring(c.err)), c.description)
+				continue
+			}
+			Expect(err).NotTo(HaveOccurred(), c.description)
+			Expect(workload).To(Equal(c.workload), c.description)
+		}
+	})
+
+	It("should only read the cluster for the workloads that live there", func() {
+		unreachable := func() (kubernetes.Interface, error) {
+			return nil, errors.New("no cluster")
+		}
+		o := NewOptions()
+		o.Squash.Selector = "app=web"
+		Expect(o.resolveWorkloadWith(unreachable)).To(Equal(&v1.Workload{Kind: workloadSelector, Selector: "app=web"}))
+
+		o = NewOptions()
+		o.Squash.Deployment = "web"
+		_, err := o.resolveWorkloadWith(unreachable)
+		Expect(err).To(MatchError("no cluster"))
+	})
+})
+
+var _ = Describe("preferReady", func() {
+	pod := func(name string, ready corev1.ConditionStatus, deleting bool) corev1.Pod {
+		p := corev1.Pod{ObjectMeta: meta_v1.ObjectMeta{Name: name}}
+		if ready != "" {
+			p.Status.Conditions = []corev1.PodCondition{
+				{Type: corev1.PodScheduled, Status: corev1.ConditionTrue},
+				{Type: corev1.PodReady, Status: ready},
+			}
+		}
+		if deleting {
+			now := meta_v1.Now()
+			p.DeletionTimestamp = &now
+		}
+		return p
+	}
+	names := func(pods []corev1.Pod) []string {
+		var names []string
+		for _, p := range pods {
+			names = append(names, p.Name)
+		}
+		return names
+	}
+
+	It("should prefer the ready pods and never choose a pod that is being deleted", func() {
+		for _, c := range []struct {
+			description string
+			pods        []corev1.Pod
+			chosen      []string
+		}{
+			{
+				description: "no pods",
+			},
+			{
+				description: "ready pods only",
+				pods: []corev1.Pod{
+					pod("ready-1", corev1.ConditionTrue, false),
+					pod("starting", corev1.ConditionFalse, false),
+					pod("ready-2", corev1.ConditionTrue, false),
+					pod("unscheduled", "", false),
+				},
+				chosen: []string{"ready-1", "ready-2"},
+			},
+			{
+				description: "no ready pod",
+				pods: []corev1.Pod{
+					pod("starting", corev1.ConditionFalse, false),
+					pod("unknown", corev1.ConditionUnknown, false),
+					pod("unscheduled", "", false),
+				},
+				chosen: []string{"starting", "unknown", "unscheduled"},
+			},
+			{
+				description: "ready but being deleted",
+				pods: []corev1.Pod{
+					pod("terminating", corev1.ConditionTrue, true),
+					pod("starting", corev1.ConditionFalse, false),
+				},
+				chosen: []string{"starting"},
+			},
+			{
+				description: "every pod being deleted",
+				pods: []corev1.Pod{
+					pod("terminating-1", corev1.ConditionTrue, true),
+					pod("terminating-2", corev1.ConditionFalse, true),
+				},
+			},
+		} {
+			Expect(names(preferReady(c.pods))).To(Equal(c.chosen), c.description)
+		}
+	})
+})
@@ -11,8 +11,12 @@ import (
 type Options struct {
 	kubeClient *kubernetes.Clientset
 
-	Url            string
-	Json           bool
+	Url  string
+	Json bool
+	// NonInteractive fails on missing values instead of prompting for them
+	NonInteractive bool
+	// Yes answers confirmation prompts
+	Yes            bool
 	DebugContainer DebugContainer
 	// Debug Container is a superset of DebugRequest so we can use the same struct
 	// TODO(mitchdraft) - refactor
@@ -37,6 +41,8 @@ type Options struct {
 	// Config may be blended into other options
 	Config         Config
 	ConfigFilename string
+	// Profile names the profile of the config file that overrides its top level values
+	Profile string
 }
 
 func NewOptions() *Options {
@@ -58,9 +64,14 @@ type Wait struct {
 	Timeout float64
 }
 
+// Error describes a failed command in the json output
 type Error struct {
-	Type string
-	Info string
+	// Type classifies the error, kubernetes errors keep their reason, such as NotFound or Forbidden
+	Type string `json:"type"`
+	Info string `json:"info"`
+	// Flag and Candidates describe the missing value of a MissingValue error
+	Flag       string   `json:"flag,omitempty"`
+	Candidates []string `json:"candidates,omitempty"`
 }
 
 type DeployOptions struct {
@@ -106,21 +117,14 @@ type Internal struct {
 	ConfigLoaded bool
 	// ConfigRead should be set once the config has been read
 	ConfigRead bool
+	// ProfileSettings holds the values of the selected profile, once the config has been loaded
+	ProfileSettings map[string]interface{}
 }
 
 type Config struct {
 	verbose    bool
 	secureMode bool
 	logCmds    bool
-
-	// values from the kubesquash extension that have not been implemented
-	// path to the preferred kubectl binary
-	kubectlPath string // NOT IMPLEMENTED
-	// arguments to be passed to kubectl via `KUBECONFIG=<kubeEnv> kubectl ...`
-	kubeEnv string // NOT IMPLEMENTED
-	// arguments to be passed to kubectl via ` kubectl --kubeconfig=<kubeConfig> ...`
-	kubeConfig string // NOT IMPLEMENTED
-	// semi-implemented as flags, should move to config file
-	containerRepo string // SEMI-IMPLEMENTED
-
+	// namespaceDebuggers maps namespaces to the debugger to use in them, when none is passed
+	namespaceDebuggers map[string]string
 }
@@ -8,7 +8,6 @@ import (
 	"strings"
 	"time"
 
-	gokubeutils "github.com/solo-io/go-utils/kubeutils"
 	"github.com/solo-io/solo-kit/pkg/api/v1/clients"
 	v1 "github.com/solo-io/squash/pkg/api/v1"
 	sqOpts "github.com/solo-io/squash/pkg/options"
@@ -20,15 +19,10 @@ import (
 	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/client-go/kubernetes"
 )
 
 func (o *Options) getAllDebugAttachments() (v1.DebugAttachmentList, error) {
-	restCfg, err := gokubeutils.GetConfig("", "")
-	if err != nil {
-		return v1.DebugAttachmentList{}, err
-	}
-	kubeResClient, err := kubernetes.NewForConfig(restCfg)
+	kubeResClient, err := o.getKubeClient()
 	if err != nil {
 		return v1.DebugAttachmentList{}, err
 	}
@@ -120,7 +114,7 @@ func (o *Options) printVerbose(msg string) {
 }
 
 func (o *Options) printVerbosef(tmpl string, args ...interface{}) {
-	if o.Config.verbose {
+	if o.Config.verbose && !o.Squash.Machine {
 		fmt.Printf(tmpl, args)
 	}
 }
@@ -184,12 +178,14 @@ func getFlagSpec(cmd *cobra.Command) string {
 	return str
 }
 
-func (o *Options) chooseString(message string, choice *string, options []string) error {
+// chooseString prompts for one of the options, flag is the flag that passes it in non-interactive mode
+func (o *Options) chooseString(message string, choice *string, options []string, value, flag string) error {
 	question := &survey.Select{
 		Message: message,
 		Options: options,
 	}
-	if err := survey.AskOne(question, choice, survey.Required); err != nil {
+	missing := &MissingValueError{Value: value, Flag: flag, Candidates: options}
+	if err := o.askOne(missing, question, choice, survey.Required); err != nil {
 		return err
 	}
 	return nil
@@ -200,7 +196,7 @@ func (o *Options) chooseString(message string, choice *string, options []string)
 // we need them to exist in each namespace
 func (o *Options) createPlankPermissions() error {
 
-	cs, err := getClientSet()
+	cs, err := o.getKubeClient()
 	if err != nil {
 		return err
 	}
@@ -289,14 +285,6 @@ func (o *Options) createPlankPermissions() error {
 	return nil
 }
 
-func getClientSet() (kubernetes.Interface, error) {
-	restCfg, err := gokubeutils.GetConfig("", "")
-	if err != nil {
-		return nil, err
-	}
-	return kubernetes.NewForConfig(restCfg)
-}
-
 // only print info if squashctl is being used by a human
 // machine mode currently expects an exact output
 func (o *Options) info(msg string) {
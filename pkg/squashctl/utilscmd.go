@@ -3,6 +3,7 @@ package squashctl
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/solo-io/solo-kit/pkg/api/v1/clients"
 	v1 "github.com/solo-io/squash/pkg/api/v1"
@@ -51,6 +52,18 @@ func (o *Options) listAttachmentsCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
+			if o.Json {
+				das, err := squashutils.GetAllDebugAttachments(o.ctx, daClient, nsList)
+				if err != nil {
+					return err
+				}
+				das.ConvertDeprecatedFields()
+				summaries := []sessionSummary{}
+				for _, da := range das {
+					summaries = append(summaries, summarizeSession(da, time.Now()))
+				}
+				return printJson(SessionListKind, summaries)
+			}
 			das, err := squashutils.ListDebugAttachments(o.ctx, daClient, nsList)
 			if err != nil {
 				return err
@@ -68,6 +81,12 @@ func (o *Options) listAttachmentsCmd() *cobra.Command {
 	return cmd
 }
 
+// deletedAttachments reports what delete-attachments deleted
+type deletedAttachments struct {
+	Attachments []deletion `json:"attachments"`
+	PlankPods   []deletion `json:"plankPods"`
+}
+
 func (o *Options) deleteAttachmentsCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "delete-attachments",
@@ -90,11 +109,20 @@ func (o *Options) deleteAttachmentsCmd() *cobra.Command {
 				return err
 			}
 
-			fmt.Printf("Found %v debug attachments\n", len(das))
-			if err := o.deleteAttachmentList(das, true); err != nil {
-				fmt.Println(err)
+			fmt.Fprintf(o.progressOutput(), "Found %v debug attachments\n", len(das))
+			result := deletedAttachments{}
+			result.Attachments, err = o.deleteAttachmentList(das, true)
+			if err != nil {
+				fmt.Fprintln(o.progressOutput(), err)
+			}
+			result.PlankPods, err = o.deletePlankPods()
+			if err != nil {
+				return err
 			}
-			return o.deletePlankPods()
+			if o.Json {
+				return printJson(DeletedAttachmentsKind, result)
+			}
+			return nil
 		},
 	}
 	return cmd
@@ -113,7 +141,7 @@ func (o *Options) registerResourcesCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
-			daClient, err := squashutils.GetDebugAttachmentClientWithRegistration(o.ctx)
+			daClient, err := squashutils.GetDebugAttachmentClientWithRegistrationFor(o.ctx, o.Squash.KubeConfig)
 			if err != nil {
 				return err
 			}
@@ -123,6 +151,9 @@ func (o *Options) registerResourcesCmd() *cobra.Command {
 				return err
 			}
 
+			if o.Json {
+				return printJson(ResourcesRegisteredKind, []string{v1.DebugAttachmentCrd.FullName()})
+			}
 			fmt.Println("Registered DebugAttachment CRD")
 			return nil
 		},
@@ -130,21 +161,24 @@ func (o *Options) registerResourcesCmd() *cobra.Command {
 	return cmd
 }
 
-func (o *Options) deleteAttachmentList(das v1.DebugAttachmentList, continueOnError bool) error {
+// deleteAttachmentList deletes the debug attachments, errors are reported in the returned deletions if continueOnError is set
+func (o *Options) deleteAttachmentList(das v1.DebugAttachmentList, continueOnError bool) ([]deletion, error) {
 	daClient, err := o.getDAClient()
 	if err != nil {
-		return err
+		return nil, err
 	}
+	deletions := []deletion{}
 	for _, da := range das {
-		if err := daClient.Delete(da.Metadata.Namespace, da.Metadata.Name, clients.DeleteOpts{}); err != nil {
-			if continueOnError {
-				fmt.Println(err)
-			} else {
-				return err
+		err := daClient.Delete(da.Metadata.Namespace, da.Metadata.Name, clients.DeleteOpts{})
+		if err != nil {
+			if !continueOnError {
+				return deletions, err
 			}
+			fmt.Fprintln(o.progressOutput(), err)
 		}
+		deletions = append(deletions, newDeletion("DebugAttachment", da.Metadata.Namespace, da.Metadata.Name, err))
 	}
-	return nil
+	return deletions, nil
 }
 
 func (o *Options) deletePermissionsCmd() *cobra.Command {
@@ -152,39 +186,47 @@ func (o *Options) deletePermissionsCmd() *cobra.Command {
 		Use:   "delete-permissions",
 		Short: "remove all service accounts, roles, and role bindings created by Squash.",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return o.deleteSquashPermissions()
+			deletions, err := o.deleteSquashPermissions()
+			if err != nil {
+				return err
+			}
+			if o.Json {
+				return printJson(DeletedPermissionsKind, deletions)
+			}
+			for _, d := range deletions {
+				if d.Error != "" {
+					fmt.Println(d.Error)
+				}
+			}
+			return nil
 		},
 	}
 	return cmd
 }
 
-func (o *Options) deleteSquashPermissions() error {
+// deleteSquashPermissions deletes the permission resources of squash and plank, each deletion records its own error
+func (o *Options) deleteSquashPermissions() ([]deletion, error) {
 	cs, err := o.getKubeClient()
 	if err != nil {
-		return err
+		return nil, err
 	}
 	namespace := o.Squash.SquashNamespace
 
-	if err := cs.CoreV1().ServiceAccounts(namespace).Delete(sqOpts.PlankServiceAccountName, &metav1.DeleteOptions{}); err != nil {
-		fmt.Println(err)
-	}
-	if err := cs.Rbac().ClusterRoles().Delete(sqOpts.PlankClusterRoleName, &metav1.DeleteOptions{}); err != nil {
-		fmt.Println(err)
-	}
-	if err := cs.Rbac().ClusterRoleBindings().Delete(sqOpts.PlankClusterRoleBindingName, &metav1.DeleteOptions{}); err != nil {
-		fmt.Println(err)
-	}
+	return []deletion{
+		newDeletion("ServiceAccount", namespace, sqOpts.PlankServiceAccountName,
+			cs.CoreV1().ServiceAccounts(namespace).Delete(sqOpts.PlankServiceAccountName, &metav1.DeleteOptions{})),
+		newDeletion("ClusterRole", "", sqOpts.PlankClusterRoleName,
+			cs.Rbac().ClusterRoles().Delete(sqOpts.PlankClusterRoleName, &metav1.DeleteOptions{})),
+		newDeletion("ClusterRoleBinding", "", sqOpts.PlankClusterRoleBindingName,
+			cs.Rbac().ClusterRoleBindings().Delete(sqOpts.PlankClusterRoleBindingName, &metav1.DeleteOptions{})),
 
-	if err := cs.CoreV1().ServiceAccounts(namespace).Delete(sqOpts.SquashServiceAccountName, &metav1.DeleteOptions{}); err != nil {
-		fmt.Println(err)
-	}
-	if err := cs.Rbac().ClusterRoles().Delete(sqOpts.SquashClusterRoleName, &metav1.DeleteOptions{}); err != nil {
-		fmt.Println(err)
-	}
-	if err := cs.Rbac().ClusterRoleBindings().Delete(sqOpts.SquashClusterRoleBindingName, &metav1.DeleteOptions{}); err != nil {
-		fmt.Println(err)
-	}
-	return nil
+		newDeletion("ServiceAccount", namespace, sqOpts.SquashServiceAccountName,
+			cs.CoreV1().ServiceAccounts(namespace).Delete(sqOpts.SquashServiceAccountName, &metav1.DeleteOptions{})),
+		newDeletion("ClusterRole", "", sqOpts.SquashClusterRoleName,
+			cs.Rbac().ClusterRoles().Delete(sqOpts.SquashClusterRoleName, &metav1.DeleteOptions{})),
+		newDeletion("ClusterRoleBinding", "", sqOpts.SquashClusterRoleBindingName,
+			cs.Rbac().ClusterRoleBindings().Delete(sqOpts.SquashClusterRoleBindingName, &metav1.DeleteOptions{})),
+	}, nil
 }
 
 func (o *Options) deletePlankPodsCmd() *cobra.Command {
@@ -192,31 +234,40 @@ func (o *Options) deletePlankPodsCmd() *cobra.Command {
 		Use:   "delete-planks",
 		Short: "remove all plank debugger pods created by Squash.",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			return o.deletePlankPods()
+			deletions, err := o.deletePlankPods()
+			if err != nil {
+				return err
+			}
+			if o.Json {
+				return printJson(DeletedPlankPodsKind, deletions)
+			}
+			return nil
 		},
 	}
 	return cmd
 }
 
 // TODO(mitchdraft) - should exclude squash pod from this, add labels to squash and plank pods so they can be distinguished
-func (o *Options) deletePlankPods() error {
+func (o *Options) deletePlankPods() ([]deletion, error) {
 	cs, err := o.getKubeClient()
 	if err != nil {
-		return err
+		return nil, err
 	}
 	namespace := o.Squash.SquashNamespace
 	planks, err := cs.CoreV1().Pods(namespace).List(metav1.ListOptions{LabelSelector: sqOpts.PlankLabelSelectorString})
 	if err != nil {
-		return err
+		return nil, err
 	}
-	fmt.Printf("Found %v plank pods in namespace %v\n", len(planks.Items), namespace)
+	fmt.Fprintf(o.progressOutput(), "Found %v plank pods in namespace %v\n", len(planks.Items), namespace)
+	deletions := []deletion{}
 	for _, plank := range planks.Items {
 		name := plank.ObjectMeta.Name
 		if err := cs.CoreV1().Pods(namespace).Delete(name, &metav1.DeleteOptions{}); err != nil {
-			return err
+			return deletions, err
 		}
-		fmt.Printf("Deleted plank pod %v.\n", name)
+		fmt.Fprintf(o.progressOutput(), "Deleted plank pod %v.\n", name)
+		deletions = append(deletions, newDeletion("Pod", namespace, name, nil))
 	}
 
-	return nil
+	return deletions, nil
 }
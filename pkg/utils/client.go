@@ -3,25 +3,46 @@ package utils
 import (
 	"context"
 
-	"github.com/solo-io/go-utils/kubeutils"
 	"github.com/solo-io/solo-kit/pkg/api/v1/clients/factory"
 	"github.com/solo-io/solo-kit/pkg/api/v1/clients/kube"
 	v1 "github.com/solo-io/squash/pkg/api/v1"
+	squashkubeutils "github.com/solo-io/squash/pkg/utils/kubeutils"
+	"k8s.io/client-go/rest"
 )
 
 func GetDebugAttachmentClientWithRegistration(ctx context.Context) (v1.DebugAttachmentClient, error) {
-	return getDebugAttachmentClient(ctx, true)
+	return getDebugAttachmentClient(ctx, squashkubeutils.KubeConfig{}, true)
 }
 
 func GetBasicDebugAttachmentClient(ctx context.Context) (v1.DebugAttachmentClient, error) {
-	return getDebugAttachmentClient(ctx, false)
+	return getDebugAttachmentClient(ctx, squashkubeutils.KubeConfig{}, false)
 }
 
-func getDebugAttachmentClient(ctx context.Context, withRegistration bool) (v1.DebugAttachmentClient, error) {
-	cfg, err := kubeutils.GetConfig("", "")
+// GetDebugAttachmentClientWithRegistrationFor returns a debug attachment client for the cluster that kc selects,
+// registering the DebugAttachment CRD if needed
+func GetDebugAttachmentClientWithRegistrationFor(ctx context.Context, kc squashkubeutils.KubeConfig) (v1.DebugAttachmentClient, error) {
+	return getDebugAttachmentClient(ctx, kc, true)
+}
+
+// GetBasicDebugAttachmentClientFor returns a debug attachment client for the cluster that kc selects
+func GetBasicDebugAttachmentClientFor(ctx context.Context, kc squashkubeutils.KubeConfig) (v1.DebugAttachmentClient, error) {
+	return getDebugAttachmentClient(ctx, kc, false)
+}
+
+// GetBasicDebugAttachmentClientForConfig returns a debug attachment client that connects with cfg
+func GetBasicDebugAttachmentClientForConfig(ctx context.Context, cfg *rest.Config) (v1.DebugAttachmentClient, error) {
+	return debugAttachmentClientForConfig(ctx, cfg, false)
+}
+
+func getDebugAttachmentClient(ctx context.Context, kc squashkubeutils.KubeConfig, withRegistration bool) (v1.DebugAttachmentClient, error) {
+	cfg, err := kc.RestConfig()
 	if err != nil {
 		return nil, err
 	}
+	return debugAttachmentClientForConfig(ctx, cfg, withRegistration)
+}
+
+func debugAttachmentClientForConfig(ctx context.Context, cfg *rest.Config, withRegistration bool) (v1.DebugAttachmentClient, error) {
 	cache := kube.NewKubeCache(ctx)
 	rcFactory := &factory.KubeResourceClientFactory{
 		Crd:             v1.DebugAttachmentCrd,
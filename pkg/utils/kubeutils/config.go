@@ -0,0 +1,26 @@
+package kubeutils
+
+import (
+	gokubeutils "github.com/solo-io/go-utils/kubeutils"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// KubeConfig selects the cluster to talk to: a kubeconfig file, and a context in it.
+// Empty fields select the defaults: $KUBECONFIG or ~/.kube/config, and its current context.
+// The zero value also falls back to the in-cluster config, for squash and plank.
+type KubeConfig struct {
+	Path    string
+	Context string
+}
+
+// RestConfig returns the client config for the selected cluster
+func (k KubeConfig) RestConfig() (*rest.Config, error) {
+	if k.Path == "" && k.Context == "" {
+		return gokubeutils.GetConfig("", "")
+	}
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = k.Path
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: k.Context}
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides).ClientConfig()
+}
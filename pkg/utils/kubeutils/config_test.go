@@ -0,0 +1,69 @@
+package kubeutils_test
+
+import (
+	"io/ioutil"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/solo-io/squash/pkg/utils/kubeutils"
+)
+
+const twoClusterKubeConfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: staging
+  cluster:
+    server: https://staging.example.com
+- name: prod
+  cluster:
+    server: https://prod.example.com
+users:
+- name: dev
+  user:
+    token: abc
+contexts:
+- name: staging
+  context:
+    cluster: staging
+    user: dev
+- name: prod
+  context:
+    cluster: prod
+    user: dev
+current-context: staging
+`
+
+var _ = Describe("KubeConfig", func() {
+	var path string
+
+	BeforeEach(func() {
+		f, err := ioutil.TempFile("", "kubeconfig")
+		Expect(err).NotTo(HaveOccurred())
+		_, err = f.WriteString(twoClusterKubeConfig)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(f.Close()).To(Succeed())
+		path = f.Name()
+	})
+
+	AfterEach(func() {
+		os.Remove(path)
+	})
+
+	It("uses the current context of the kubeconfig file", func() {
+		cfg, err := kubeutils.KubeConfig{Path: path}.RestConfig()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg.Host).To(Equal("https://staging.example.com"))
+	})
+
+	It("uses the selected context", func() {
+		cfg, err := kubeutils.KubeConfig{Path: path, Context: "prod"}.RestConfig()
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cfg.Host).To(Equal("https://prod.example.com"))
+	})
+
+	It("fails for an unknown context", func() {
+		_, err := kubeutils.KubeConfig{Path: path, Context: "dev"}.RestConfig()
+		Expect(err).To(HaveOccurred())
+	})
+})
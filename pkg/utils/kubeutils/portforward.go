@@ -0,0 +1,106 @@
+package kubeutils
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/solo-io/go-utils/errors"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+)
+
+// PortForwarder forwards a local port to a pod port through the Kubernetes API server
+type PortForwarder struct {
+	// LocalPort is the port bound on localhost
+	LocalPort int
+
+	stopChan chan struct{}
+	stopOnce sync.Once
+	done     chan error
+}
+
+// PortForward opens a port-forward stream to remotePort on the given pod, in the cluster that kc selects.
+// If localPort is 0, a free local port is chosen. It returns once the local port is accepting connections.
+func PortForward(ctx context.Context, kc KubeConfig, namespace, podName string, localPort, remotePort int) (*PortForwarder, error) {
+	restCfg, err := kc.RestConfig()
+	if err != nil {
+		return nil, errors.Wrapf(err, "no Kubernetes context config found; please double check your Kubernetes environment")
+	}
+	kubeClient, err := kubernetes.NewForConfig(restCfg)
+	if err != nil {
+		return nil, err
+	}
+	transport, upgrader, err := spdy.RoundTripperFor(restCfg)
+	if err != nil {
+		return nil, err
+	}
+	req := kubeClient.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(namespace).
+		Name(podName).
+		SubResource("portforward")
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, req.URL())
+
+	pf := &PortForwarder{
+		stopChan: make(chan struct{}),
+		done:     make(chan error, 1),
+	}
+	readyChan := make(chan struct{})
+	ports := []string{fmt.Sprintf("%v:%v", localPort, remotePort)}
+	fw, err := portforward.New(dialer, ports, pf.stopChan, readyChan, ioutil.Discard, os.Stderr)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		pf.done <- fw.ForwardPorts()
+		close(pf.done)
+	}()
+
+	select {
+	case <-ctx.Done():
+		pf.Close()
+		return nil, fmt.Errorf("port-forward to %v/%v was not ready in the allotted time", namespace, podName)
+	case err := <-pf.done:
+		if err == nil {
+			err = fmt.Errorf("stopped before it was ready")
+		}
+		return nil, errors.Wrapf(err, "port-forward to %v/%v", namespace, podName)
+	case <-readyChan:
+	}
+
+	forwarded, err := fw.GetPorts()
+	if err != nil {
+		pf.Close()
+		return nil, err
+	}
+	if len(forwarded) != 1 {
+		pf.Close()
+		return nil, fmt.Errorf("expected to forward one port, forwarded %v", len(forwarded))
+	}
+	pf.LocalPort = int(forwarded[0].Local)
+	return pf, nil
+}
+
+// LocalAddress is the address that local debuggers should connect to
+func (pf *PortForwarder) LocalAddress() string {
+	return fmt.Sprintf("127.0.0.1:%v", pf.LocalPort)
+}
+
+// Done receives the result of the port-forward when it stops
+func (pf *PortForwarder) Done() <-chan error {
+	return pf.done
+}
+
+// Close stops forwarding and releases the local port. A PortForwarder that was never started has nothing to stop
+func (pf *PortForwarder) Close() {
+	pf.stopOnce.Do(func() {
+		if pf.stopChan != nil {
+			close(pf.stopChan)
+		}
+	})
+}
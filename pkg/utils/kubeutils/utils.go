@@ -3,7 +3,6 @@ package kubeutils
 import (
 	"github.com/solo-io/go-utils/errors"
 
-	gokubeutils "github.com/solo-io/go-utils/kubeutils"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 
@@ -43,8 +42,14 @@ func GetNamespaces(clientset *kubernetes.Clientset) ([]string, error) {
 	return namespaces, nil
 }
 
+// GetKubeClient returns a clientset for the default cluster
 func GetKubeClient() (*kubernetes.Clientset, error) {
-	restCfg, err := gokubeutils.GetConfig("", "")
+	return GetKubeClientFor(KubeConfig{})
+}
+
+// GetKubeClientFor returns a clientset for the cluster that kc selects
+func GetKubeClientFor(kc KubeConfig) (*kubernetes.Clientset, error) {
+	restCfg, err := kc.RestConfig()
 	if err != nil {
 		return &kubernetes.Clientset{}, errors.Wrapf(err, "no Kubernetes context config found; please double check your Kubernetes environment")
 	}
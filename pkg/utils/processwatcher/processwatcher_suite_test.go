@@ -0,0 +1,13 @@
+package processwatcher_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestProcesswatcher(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Processwatcher Suite")
+}
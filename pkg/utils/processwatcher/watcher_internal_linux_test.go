@@ -0,0 +1,116 @@
+package processwatcher
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const (
+	containerID = "0f5e4d3c2b1a09f8e7d6c5b4a3928170f5e4d3c2b1a09f8e7d6c5b4a3928170f"
+	otherID     = "9a8b7c6d5e4f30211a2b3c4d5e6f708192a3b4c5d6e7f8091a2b3c4d5e6f7081"
+)
+
+var _ = Describe("cgroupsMention", func() {
+	It("should find the container id in the cgroup paths", func() {
+		for _, c := range []struct {
+			description string
+			cgroups     string
+			mentioned   bool
+		}{
+			{
+				description: "cgroup v1, docker",
+				cgroups: "12:pids:/kubepods/burstable/pod2c6a1f4e-5d3b-11e9-8647-d663bd873d93/" + containerID + "\n" +
+					"4:memory:/kubepods/burstable/pod2c6a1f4e-5d3b-11e9-8647-d663bd873d93/" + containerID + "\n" +
+					"1:name=systemd:/kubepods/burstable/pod2c6a1f4e-5d3b-11e9-8647-d663bd873d93/" + containerID + "\n",
+				mentioned: true,
+			},
+			{
+				description: "cgroup v1, systemd driver, docker",
+				cgroups:     "4:memory:/kubepods.slice/kubepods-besteffort.slice/kubepods-besteffort-pod2c6a1f4e.slice/docker-" + containerID + ".scope\n",
+				mentioned:   true,
+			},
+			{
+				description: "cgroup v2, containerd",
+				cgroups:     "0::/kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod2c6a1f4e.slice/cri-containerd-" + containerID + ".scope\n",
+				mentioned:   true,
+			},
+			{
+				description: "cgroup v2, CRI-O",
+				cgroups:     "0::/kubepods.slice/kubepods-pod2c6a1f4e.slice/crio-" + containerID + ".scope\n",
+				mentioned:   true,
+			},
+			{
+				description: "cgroup v1, cgroupfs driver, containerd",
+				cgroups:     "3:cpu,cpuacct:/kubepods/besteffort/pod2c6a1f4e-5d3b-11e9-8647-d663bd873d93/" + containerID,
+				mentioned:   true,
+			},
+			{
+				description: "another container of the pod",
+				cgroups:     "0::/kubepods.slice/kubepods-pod2c6a1f4e.slice/cri-containerd-" + otherID + ".scope\n",
+			},
+			{
+				description: "a host process",
+				cgroups:     "9:name=systemd:/\n4:memory:/system.slice/containerd.service\n0::/\n",
+			},
+			{
+				description: "the id outside of the path",
+				cgroups:     "0:" + containerID + ":/\n",
+			},
+			{
+				description: "malformed lines",
+				cgroups:     containerID + "\n\n",
+			},
+			{
+				description: "empty",
+			},
+		} {
+			Expect(cgroupsMention(c.cgroups, containerID)).To(Equal(c.mentioned), c.description)
+		}
+	})
+})
+
+var _ = Describe("findPidsInCgroup", func() {
+	var procRoot string
+
+	BeforeEach(func() {
+		var err error
+		procRoot, err = ioutil.TempDir("", "proc")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(procRoot)
+	})
+
+	process := func(name, cgroups string) {
+		dir := filepath.Join(procRoot, name)
+		Expect(os.MkdirAll(dir, 0755)).To(Succeed())
+		if cgroups != "" {
+			Expect(ioutil.WriteFile(filepath.Join(dir, "cgroup"), []byte(cgroups), 0644)).To(Succeed())
+		}
+	}
+
+	It("should find the processes of the container only", func() {
+		process("1", "0::/init.scope\n")
+		process("42", "0::/kubepods.slice/cri-containerd-"+containerID+".scope\n")
+		process("43", "0::/kubepods.slice/cri-containerd-"+otherID+".scope\n")
+		process("57", "4:memory:/kubepods/pod2c6a1f4e/"+containerID+"\n0::/\n")
+		// exited before its cgroups were read
+		process("58", "")
+		// not processes
+		process("self", "0::/kubepods.slice/cri-containerd-"+containerID+".scope\n")
+		Expect(ioutil.WriteFile(filepath.Join(procRoot, "99"), nil, 0644)).To(Succeed())
+
+		Expect(findPidsInCgroup(procRoot, containerID)).To(Equal([]int{42, 57}))
+		Expect(findPidsInCgroup(procRoot, otherID)).To(Equal([]int{43}))
+	})
+
+	It("should not match every process without a container id", func() {
+		process("1", "0::/\n")
+		Expect(findPidsInCgroup(procRoot, "")).To(BeEmpty())
+	})
+})
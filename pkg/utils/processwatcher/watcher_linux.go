@@ -1,9 +1,11 @@
 package processwatcher
 
 import (
+	"context"
 	"io/ioutil"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"sync"
 	"syscall"
 	"time"
@@ -26,24 +28,49 @@ var (
 )
 
 type Watcher struct {
-	inod uint64
-	pids map[int]bool
+	findPids func() ([]int, error)
+	pids     map[int]bool
 }
 
+// NewWatcher watches for processes whose executable has the given inode
 func NewWatcher(inod uint64) *Watcher {
 	return &Watcher{
-		inod: inod,
-		pids: make(map[int]bool),
+		findPids: func() ([]int, error) { return FindPids(inod) },
+		pids:     make(map[int]bool),
 	}
 }
 
-func (w *Watcher) Watch() <-chan int {
+// NewNamespaceWatcher watches for processes in the namespace with the given inode, ns is the namespace type, as in "mnt"
+func NewNamespaceWatcher(inod uint64, ns string) *Watcher {
+	return &Watcher{
+		findPids: func() ([]int, error) { return FindPidsInNS(inod, ns) },
+		pids:     make(map[int]bool),
+	}
+}
+
+// Ignore marks pids as already seen, so that Watch does not report them while they run
+func (w *Watcher) Ignore(pids ...int) {
+	for _, pid := range pids {
+		w.pids[pid] = true
+	}
+}
+
+// Watch reports new pids until the context is done
+func (w *Watcher) Watch(ctx context.Context) <-chan int {
 	c := make(chan int)
 	go func() {
+		defer close(c)
 		for {
-			pids, err := FindPids(w.inod)
+			pids, err := w.findPids()
 			if err != nil {
-				panic("error getting pids")
+				// without a scan, every pid would look stale and be reported again by the next scan
+				log.WithField("err", err).Warn("error getting pids")
+				select {
+				case <-time.After(time.Second):
+					continue
+				case <-ctx.Done():
+					return
+				}
 			}
 
 			// stale old pids
@@ -61,10 +88,18 @@ func (w *Watcher) Watch() <-chan int {
 				if _, ok := w.pids[pid]; !ok {
 					log.WithFields(log.Fields{"pid": pid}).Debug("match found")
 					w.pids[pid] = true
-					c <- pid
+					select {
+					case c <- pid:
+					case <-ctx.Done():
+						return
+					}
 				}
 			}
-			time.Sleep(time.Second)
+			select {
+			case <-time.After(time.Second):
+			case <-ctx.Done():
+				return
+			}
 		}
 	}()
 	return c
@@ -102,3 +137,85 @@ func FindPids(inod uint64) ([]int, error) {
 
 	return res, nil
 }
+
+// FindPidsInNS returns the processes in the namespace with the given inode, ns is the namespace type, as in "mnt"
+func FindPidsInNS(inod uint64, ns string) ([]int, error) {
+	var res []int
+	files, err := ioutil.ReadDir("/proc")
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range files {
+		if !f.IsDir() {
+			continue
+		}
+		pid, err := strconv.Atoi(f.Name())
+		if err != nil {
+			continue
+		}
+
+		p := filepath.Join("/proc", f.Name(), "ns", ns)
+		if inod2, err := PathToInode(p); err != nil {
+			continue
+		} else if inod == inod2 {
+			res = append(res, pid)
+		}
+	}
+
+	return res, nil
+}
+
+// FindPidsInCgroup returns the processes whose cgroup path mentions the container id.
+// Docker, containerd and CRI-O all name a container's cgroup after its id.
+func FindPidsInCgroup(containerID string) ([]int, error) {
+	return findPidsInCgroup("/proc", containerID)
+}
+
+func findPidsInCgroup(procRoot, containerID string) ([]int, error) {
+	if containerID == "" {
+		return nil, nil
+	}
+	var res []int
+	files, err := ioutil.ReadDir(procRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range files {
+		if !f.IsDir() {
+			continue
+		}
+		pid, err := strconv.Atoi(f.Name())
+		if err != nil {
+			continue
+		}
+
+		cgroups, err := ioutil.ReadFile(filepath.Join(procRoot, f.Name(), "cgroup"))
+		if err != nil {
+			// the process may have exited
+			continue
+		}
+		if cgroupsMention(string(cgroups), containerID) {
+			res = append(res, pid)
+		}
+	}
+
+	return res, nil
+}
+
+// cgroupsMention reports whether a /proc/<pid>/cgroup file places the process in a cgroup of the container.
+// Each line looks like hierarchy-id:controllers:path, for example
+// 4:memory:/kubepods/burstable/pod2c6a.../0f5e... or 0::/kubepods.slice/.../cri-containerd-0f5e....scope
+func cgroupsMention(cgroups, containerID string) bool {
+	for _, line := range strings.Split(cgroups, "\n") {
+		fields := strings.SplitN(line, ":", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		if strings.Contains(fields[2], containerID) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,56 @@
+package socket
+
+import (
+	"net"
+	"runtime"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// DialUnixAs connects to a unix socket with the effective uid and gid of another user, for servers that check
+// the credentials of their peers. Only the calling thread changes credentials, the real and saved ids stay root.
+func DialUnixAs(path string, uid, gid int) (net.Conn, error) {
+	if uid == 0 && gid == 0 {
+		return net.Dial("unix", path)
+	}
+	// credentials belong to the thread, so no other goroutine may run on it while they are switched
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	if err := setEffectiveIds(uid, gid); err != nil {
+		setEffectiveIds(0, 0)
+		return nil, errors.Wrapf(err, "switching to uid %v and gid %v", uid, gid)
+	}
+	// the peer credentials are recorded on connect
+	conn, dialErr := net.Dial("unix", path)
+	if err := setEffectiveIds(0, 0); err != nil {
+		// the thread is left with the wrong credentials, keep this goroutine on it so that no other goroutine is scheduled there
+		runtime.LockOSThread()
+		if conn != nil {
+			conn.Close()
+		}
+		return nil, errors.Wrap(err, "restoring root credentials")
+	}
+	return conn, dialErr
+}
+
+// setEffectiveIds sets the effective ids of the calling thread. The raw syscalls are used because the syscall
+// package refuses to change the credentials of a single thread. The uid is restored first, since only root may set the gid.
+func setEffectiveIds(uid, gid int) error {
+	keep := ^uintptr(0)
+	if uid == 0 {
+		if _, _, errno := syscall.RawSyscall(syscall.SYS_SETRESUID, keep, 0, keep); errno != 0 {
+			return errno
+		}
+	}
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_SETRESGID, keep, uintptr(gid), keep); errno != 0 {
+		return errno
+	}
+	if uid != 0 {
+		if _, _, errno := syscall.RawSyscall(syscall.SYS_SETRESUID, keep, uintptr(uid), keep); errno != 0 {
+			return errno
+		}
+	}
+	return nil
+}
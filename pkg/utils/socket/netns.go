@@ -0,0 +1,44 @@
+package socket
+
+import (
+	"net"
+	"runtime"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/vishvananda/netns"
+)
+
+// DialInNetworkOf connects to address from the network namespace of pid, so that addresses that are only
+// reachable from inside its container, such as its loopback, can be reached. This needs CAP_SYS_ADMIN.
+func DialInNetworkOf(pid int, address string, timeout time.Duration) (net.Conn, error) {
+	// the namespace belongs to the thread, so no other goroutine may run on it while it is switched
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+
+	origin, err := netns.Get()
+	if err != nil {
+		return nil, errors.Wrap(err, "reading the current network namespace")
+	}
+	defer origin.Close()
+	target, err := netns.GetFromPid(pid)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading the network namespace of pid %v", pid)
+	}
+	defer target.Close()
+
+	if err := netns.Set(target); err != nil {
+		return nil, errors.Wrapf(err, "entering the network namespace of pid %v", pid)
+	}
+	// the socket keeps its namespace once it is created
+	conn, dialErr := net.DialTimeout("tcp", address, timeout)
+	if err := netns.Set(origin); err != nil {
+		// the thread is left in the wrong namespace, keep this goroutine on it so that no other goroutine is scheduled there
+		runtime.LockOSThread()
+		if conn != nil {
+			conn.Close()
+		}
+		return nil, errors.Wrap(err, "restoring the network namespace")
+	}
+	return conn, dialErr
+}
@@ -0,0 +1,13 @@
+package socket_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestSocket(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Socket Suite")
+}
@@ -3,6 +3,7 @@ package socket
 import (
 	"bufio"
 	"encoding/binary"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -20,13 +21,15 @@ import (
 
 type inodeAndPort struct {
 	inode int64
+	ip    net.IP
 	port  int
 }
 
-func parseProcNetTcp() ([]inodeAndPort, error) {
+// parseProcNetTcpFile reads the listening sockets from a /proc/net/tcp or /proc/net/tcp6 table
+func parseProcNetTcpFile(path string) ([]inodeAndPort, error) {
 	var sockets []inodeAndPort
 
-	f, err := os.Open("/proc/net/tcp")
+	f, err := os.Open(path)
 	defer f.Close()
 
 	if err != nil {
@@ -37,7 +40,7 @@ func parseProcNetTcp() ([]inodeAndPort, error) {
 	//   sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
 	//   0: 0100007F:8A17 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 959999 1 ffff88004e4726c0 100 0 0 10 0
 
-	tcpnetListenRegex := regexp.MustCompile(`\d+:\s+[0-9A-Fa-f]{8}:([0-9A-Fa-f]{4})\s+00000000:0000\s+0[aA]\s+00000000:00000000\s+00:00000000\s+00000000\s+\d+\s+0\s+(\d+)`)
+	tcpnetListenRegex := regexp.MustCompile(`\d+:\s+([0-9A-Fa-f]{8}|[0-9A-Fa-f]{32}):([0-9A-Fa-f]{4})\s+0+:0000\s+0[aA]\s+00000000:00000000\s+00:00000000\s+00000000\s+\d+\s+0\s+(\d+)`)
 
 	reader := bufio.NewReader(f)
 	//read first line
@@ -54,8 +57,9 @@ func parseProcNetTcp() ([]inodeAndPort, error) {
 		// now parse l:
 		match := tcpnetListenRegex.FindStringSubmatch(string(l))
 		if match != nil {
-			portStr := match[1]
-			inodeStr := match[2]
+			ipStr := match[1]
+			portStr := match[2]
+			inodeStr := match[3]
 
 			port, err := strconv.ParseUint(portStr, 16, 16)
 			if err != nil {
@@ -65,33 +69,96 @@ func parseProcNetTcp() ([]inodeAndPort, error) {
 			if err != nil {
 				continue
 			}
-			sockets = append(sockets, inodeAndPort{inode: int64(inode), port: int(port)})
+			ip, err := parseProcNetIP(ipStr)
+			if err != nil {
+				continue
+			}
+			sockets = append(sockets, inodeAndPort{inode: int64(inode), ip: ip, port: int(port)})
 		}
 	}
 
 	return sockets, nil
 }
 
-func GetListeningPortsFor(pid int) ([]int, error) {
-	var inoddedPorts []inodeAndPort
+// parseProcNetIP reads an address of /proc/net/tcp, which is printed as 32 bit words in host byte order
+func parseProcNetIP(s string) (net.IP, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	ip := make(net.IP, len(b))
+	for i := 0; i+4 <= len(b); i += 4 {
+		native.PutUint32(ip[i:i+4], networkOrder.Uint32(b[i:i+4]))
+	}
+	return ip, nil
+}
 
-	if listeningsokcets, err := SocketListen(); err != nil {
-		log.WithFields(log.Fields{"pid": pid, "err": err}).Warn("GetListeningSocketsFor: Can't get listening sockets with netlink")
+// GetListeningPortsInNetworkOf lists the tcp ports that any process listens on in the network namespace of pid
+func GetListeningPortsInNetworkOf(pid int) ([]int, error) {
+	sockets, err := listeningSocketsInNetworkOf(pid)
+	if err != nil {
+		return nil, err
+	}
+	var ports []int
+	for _, socket := range sockets {
+		ports = append(ports, socket.port)
+	}
+	return ports, nil
+}
 
-		inoddedPorts, err = parseProcNetTcp()
+// listeningSocketsInNetworkOf reads the tcp tables of the network namespace of pid, which may not be our own
+func listeningSocketsInNetworkOf(pid int) ([]inodeAndPort, error) {
+	var sockets []inodeAndPort
+	for _, table := range []string{"tcp", "tcp6"} {
+		tableSockets, err := parseProcNetTcpFile(filepath.Join("/proc", strconv.Itoa(pid), "net", table))
 		if err != nil {
-			log.WithFields(log.Fields{"pid": pid, "err": err}).Error("GetListeningSocketsFor: Can't get listening sockets")
+			if os.IsNotExist(err) {
+				continue
+			}
 			return nil, err
 		}
+		sockets = append(sockets, tableSockets...)
+	}
+	return sockets, nil
+}
+
+func GetListeningPortsFor(pid int) ([]int, error) {
+	addresses, err := GetListeningAddressesFor(pid)
+	if err != nil {
+		return nil, err
+	}
+	var ports []int
+	for _, address := range addresses {
+		ports = append(ports, address.Port)
+	}
+	return ports, nil
+}
+
+// GetListeningAddressesFor returns the addresses that pid listens on, in its own network namespace
+func GetListeningAddressesFor(pid int) ([]*net.TCPAddr, error) {
+	var inoddedPorts []inodeAndPort
+
+	// netlink only sees our own network namespace, the tables of pid see the namespace of its container
+	if listeningsokcets, err := SocketListen(); err != nil {
+		log.WithFields(log.Fields{"pid": pid, "err": err}).Warn("GetListeningSocketsFor: Can't get listening sockets with netlink")
 	} else {
 
 		for _, sock := range listeningsokcets {
 			curport := int(sock.ID.SourcePort)
 
-			inoddedPorts = append(inoddedPorts, inodeAndPort{port: curport, inode: int64(sock.INode)})
+			inoddedPorts = append(inoddedPorts, inodeAndPort{port: curport, ip: sock.ID.Source, inode: int64(sock.INode)})
 		}
 
 	}
+	namespaceSockets, err := listeningSocketsInNetworkOf(pid)
+	if err != nil {
+		log.WithFields(log.Fields{"pid": pid, "err": err}).Warn("GetListeningSocketsFor: Can't read the tcp tables of the pid")
+		if len(inoddedPorts) == 0 {
+			log.WithFields(log.Fields{"pid": pid, "err": err}).Error("GetListeningSocketsFor: Can't get listening sockets")
+			return nil, err
+		}
+	}
+	inoddedPorts = append(inoddedPorts, namespaceSockets...)
 	log.WithFields(log.Fields{"pid": pid, "inoddedPorts": inoddedPorts}).Debug("GetSocketInodesFor: got listening sockets")
 
 	sockets, err := GetSocketInodesFor(pid)
@@ -101,24 +168,26 @@ func GetListeningPortsFor(pid int) ([]int, error) {
 	}
 	log.WithFields(log.Fields{"pid": pid, "sockets": sockets}).Debug("GetSocketInodesFor: got sockets for pid")
 
+	// a socket of our own namespace can be found by both netlink and the tables of pid
+	matched := make(map[int64]bool)
 	var pidsocks []inodeAndPort
 	for _, socket := range inoddedPorts {
 		for _, pidsock := range sockets {
 			log.WithFields(log.Fields{"socket": socket, "inodesock": pidsock}).Debug("GetSocketInodesFor: testing socket match")
 
-			if uint64(socket.inode) == pidsock {
+			if uint64(socket.inode) == pidsock && !matched[socket.inode] {
+				matched[socket.inode] = true
 				pidsocks = append(pidsocks, socket)
 			}
 		}
 	}
 
-	var ports []int
+	var addresses []*net.TCPAddr
 	for _, sock := range pidsocks {
-		curport := int(sock.port)
-		ports = append(ports, curport)
+		addresses = append(addresses, &net.TCPAddr{IP: sock.ip, Port: sock.port})
 	}
 
-	return ports, nil
+	return addresses, nil
 }
 
 func GetSocketInodesFor(pid int) ([]uint64, error) {
@@ -0,0 +1,126 @@
+package socket
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("sockets", func() {
+	BeforeEach(func() {
+		// the kernel prints each 32 bit word of an address in host byte order, and these tables come from a little endian host
+		if native.Uint16([]byte{1, 0}) != 1 {
+			Skip("the tables are printed by a little endian host")
+		}
+	})
+
+	Describe("parseProcNetIP", func() {
+		It("should read the words of an address in host byte order", func() {
+			cases := []struct {
+				description string
+				address     string
+				ip          net.IP
+			}{
+				{description: "ipv4 loopback", address: "0100007F", ip: net.ParseIP("127.0.0.1").To4()},
+				{description: "ipv4 any", address: "00000000", ip: net.ParseIP("0.0.0.0").To4()},
+				{description: "ipv4 pod address", address: "0A01A8C0", ip: net.ParseIP("192.168.1.10").To4()},
+				{description: "ipv6 loopback", address: "00000000000000000000000001000000", ip: net.ParseIP("::1")},
+				{description: "ipv6 link local", address: "000080FE00000000FF00500211F4BCFE", ip: net.ParseIP("fe80::250:ff:febc:f411")},
+				{description: "ipv4 mapped ipv6", address: "0000000000000000FFFF00000100007F", ip: net.ParseIP("::ffff:127.0.0.1").To16()},
+			}
+			for _, c := range cases {
+				ip, err := parseProcNetIP(c.address)
+				Expect(err).NotTo(HaveOccurred(), c.description)
+				Expect(ip).To(Equal(c.ip), c.description)
+			}
+		})
+
+		It("should fail on an address that isn't hex", func() {
+			_, err := parseProcNetIP("0100007G")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("parseProcNetTcpFile", func() {
+		var dir string
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir("", "proc-net")
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		table := func(name string, lines ...string) string {
+			path := filepath.Join(dir, name)
+			content := "  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode\n"
+			for _, line := range lines {
+				content += line + "\n"
+			}
+			Expect(ioutil.WriteFile(path, []byte(content), 0644)).To(Succeed())
+			return path
+		}
+
+		It("should read the listening sockets of a table", func() {
+			cases := []struct {
+				description string
+				lines       []string
+				sockets     []inodeAndPort
+			}{
+				{
+					description: "tcp listeners",
+					lines: []string{
+						"   0: 0100007F:8A17 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 959999 1 ffff88004e4726c0 100 0 0 10 0",
+						"   1: 00000000:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 12345 1 ffff88004e472000 100 0 0 10 0",
+					},
+					sockets: []inodeAndPort{
+						{inode: 959999, ip: net.ParseIP("127.0.0.1").To4(), port: 0x8A17},
+						{inode: 12345, ip: net.ParseIP("0.0.0.0").To4(), port: 8080},
+					},
+				},
+				{
+					description: "tcp6 listeners",
+					lines: []string{
+						"   0: 00000000000000000000000001000000:2382 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 4242 1 ffff88004e4726c0 100 0 0 10 0",
+						"   1: 00000000000000000000000000000000:1F90 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000  1000        0 4343 1 ffff88004e472000 100 0 0 10 0",
+					},
+					sockets: []inodeAndPort{
+						{inode: 4242, ip: net.ParseIP("::1"), port: 9090},
+						{inode: 4343, ip: net.ParseIP("::"), port: 8080},
+					},
+				},
+				{
+					description: "connected sockets are skipped",
+					lines: []string{
+						"   0: 0100007F:1F90 0100007F:D3A4 01 00000000:00000000 00:00000000 00000000     0        0 777 1 ffff88004e4726c0 20 4 30 10 -1",
+						"   1: 00000000000000000000000001000000:1F90 00000000000000000000000001000000:D3A4 01 00000000:00000000 00:00000000 00000000     0        0 778 1 ffff88004e4726c0 20 4 30 10 -1",
+						"   2: 0100007F:1F91 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 779 1 ffff88004e4726c0 100 0 0 10 0",
+					},
+					sockets: []inodeAndPort{
+						{inode: 779, ip: net.ParseIP("127.0.0.1").To4(), port: 8081},
+					},
+				},
+				{
+					description: "empty table",
+				},
+			}
+			for _, c := range cases {
+				sockets, err := parseProcNetTcpFile(table("tcp", c.lines...))
+				Expect(err).NotTo(HaveOccurred(), c.description)
+				Expect(sockets).To(Equal(c.sockets), c.description)
+			}
+		})
+
+		It("should fail when the table is missing", func() {
+			_, err := parseProcNetTcpFile(filepath.Join(dir, "tcp6"))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
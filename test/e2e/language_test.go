@@ -0,0 +1,63 @@
+package e2e_test
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	gotestutils "github.com/solo-io/go-utils/testutils"
+	"github.com/solo-io/squash/test/testutils"
+	"k8s.io/client-go/kubernetes"
+)
+
+// languageTest deploys one of the services in contrib/condition/languages and debugs it with the given debugger,
+// it returns the machine output of squashctl
+func languageTest(cs *kubernetes.Clientset, appNamespace, plankNamespace, language, debugger string) string {
+	installFile := fmt.Sprintf("../../contrib/condition/languages/%v.yaml", language)
+	labelSelector := fmt.Sprintf("app=squash-demo-%v", language)
+	applyOut, err := gotestutils.KubectlOut("apply", "-f", installFile, "-n", appNamespace)
+	Expect(err).NotTo(HaveOccurred())
+	_, err = fmt.Fprintf(GinkgoWriter, applyOut)
+	Expect(err).NotTo(HaveOccurred())
+	appName, err := waitForPodByLabel(cs, appNamespace, labelSelector)
+	Expect(err).NotTo(HaveOccurred())
+
+	By(fmt.Sprintf("should attach a %v debugger", debugger))
+	timeLimitSeconds := 100
+	dbgStr, err := testutils.SquashctlMachineSessionOut(testutils.MachineDebugArgs(testConditions,
+		debugger,
+		appNamespace,
+		appName,
+		plankNamespace,
+		"",
+		""), &timeLimitSeconds)
+	Expect(err).NotTo(HaveOccurred())
+	validateMachineDebugOutput(dbgStr)
+
+	By(fmt.Sprintf("should reach the %v debugger", debugger))
+	ensureDebugServerIsListening(dbgStr)
+
+	By("should list expected resources after debug session initiated")
+	attachmentList, err := testutils.SquashctlOut("utils list-attachments")
+	Expect(err).NotTo(HaveOccurred())
+	validateUtilsListDebugAttachments(attachmentList, 1)
+	return dbgStr
+}
+
+// ensureDebugServerIsListening connects to the local address that squashctl forwards to the debugger.
+// Unlike dlv, the node inspector, ptvsd, debugpy and JDWP do not answer curl consistently, so a connection is enough.
+func ensureDebugServerIsListening(dbgJson string) {
+	ed := editorDataOf(dbgJson)
+	conn, err := net.DialTimeout("tcp", ed.LocalAddress, 5*time.Second)
+	ExpectWithOffset(1, err).NotTo(HaveOccurred())
+	conn.Close()
+}
+
+// ensureDebuggerUrlIsForwarded checks that the inspector's websocket url points at the forwarded address
+func ensureDebuggerUrlIsForwarded(dbgJson string) {
+	ed := editorDataOf(dbgJson)
+	ExpectWithOffset(1, ed.Processes).NotTo(BeEmpty())
+	ExpectWithOffset(1, ed.Processes[0].DebuggerUrl).To(HavePrefix(fmt.Sprintf("ws://%v/", ed.Processes[0].LocalAddress)))
+}
@@ -32,7 +32,7 @@ func multiProcessTest(cs *kubernetes.Clientset, appNamespace, plankNamespace, pr
 	// give it enough time to pull the image, but don't be as lenient as squashctl itself since the test environments
 	// should be able to pull all images in less than one minute
 	timeLimitSeconds := 100
-	dbgStr, err := testutils.SquashctlOutWithTimeout(testutils.MachineDebugArgs(testConditions,
+	dbgStr, err := testutils.SquashctlMachineSessionOut(testutils.MachineDebugArgs(testConditions,
 		"dlv",
 		appNamespace,
 		appName,
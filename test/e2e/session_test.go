@@ -7,7 +7,6 @@ import (
 	"log"
 	"math/rand"
 	"os"
-	"os/exec"
 	"regexp"
 	"strings"
 	"time"
@@ -25,7 +24,7 @@ import (
 
 	"github.com/solo-io/squash/pkg/config"
 	sqOpts "github.com/solo-io/squash/pkg/options"
-	"github.com/solo-io/squash/pkg/utils"
+	"github.com/solo-io/squash/pkg/squashctl"
 	"github.com/solo-io/squash/test/testutils"
 	v1 "k8s.io/api/core/v1"
 	apiexts "k8s.io/apiextensions-apiserver/pkg/client/clientset/clientset"
@@ -91,7 +90,7 @@ var _ = Describe("Single debug mode", func() {
 	It("Should create a debug session", func() {
 		goPodName, javaPodName := installSquashBuiltInDemoApps(cs, testNamespace, testPlankNamespace)
 		By("should attach a dlv debugger")
-		dbgStr, err := testutils.SquashctlOut(testutils.MachineDebugArgs(testConditions, "dlv", testNamespace, goPodName, testPlankNamespace, "", ""))
+		dbgStr, err := testutils.SquashctlMachineSessionOut(testutils.MachineDebugArgs(testConditions, "dlv", testNamespace, goPodName, testPlankNamespace, "", ""), nil)
 		Expect(err).NotTo(HaveOccurred())
 
 		By("should have created the required permissions")
@@ -136,7 +135,7 @@ var _ = Describe("Single debug mode", func() {
 		Expect(err).NotTo(HaveOccurred())
 
 		By("should attach a dlv debugger")
-		dbgStr, err := testutils.SquashctlOut(testutils.MachineDebugArgs(testConditions, "dlv", testNamespace, goPodName, testPlankNamespace, configFile, ""))
+		dbgStr, err := testutils.SquashctlMachineSessionOut(testutils.MachineDebugArgs(testConditions, "dlv", testNamespace, goPodName, testPlankNamespace, configFile, ""), nil)
 		Expect(err).NotTo(HaveOccurred())
 		validateMachineDebugOutput(dbgStr)
 
@@ -173,6 +172,25 @@ var _ = Describe("Single debug mode", func() {
 		processName := "sample_app"
 		multiProcessTest(cs, testNamespace, testPlankNamespace, processName, false)
 	})
+
+	It("Should create a debug session - nodejs8", func() {
+		dbgStr := languageTest(cs, testNamespace, testPlankNamespace, "nodejs", "nodejs8")
+
+		By("should report the inspector's websocket url")
+		ensureDebuggerUrlIsForwarded(dbgStr)
+	})
+
+	It("Should create a debug session - python", func() {
+		languageTest(cs, testNamespace, testPlankNamespace, "python", "python")
+	})
+
+	It("Should create a debug session - debugpy", func() {
+		languageTest(cs, testNamespace, testPlankNamespace, "debugpy", "debugpy")
+	})
+
+	It("Should create a debug session - java", func() {
+		languageTest(cs, testNamespace, testPlankNamespace, "java", "java")
+	})
 })
 
 func waitForPod(cs *kubernetes.Clientset, testNamespace, deploymentName string) (string, error) {
@@ -260,43 +278,32 @@ func validateUtilsListDebugAttachmentsLine(line string) {
 }
 
 /* sample of expected output:
-{"PortForwardCmd":"kubectl port-forward plankhxpq4 :33303 -n squash-debugger"}
+{"version":"v1","kind":"EditorData","data":{"LocalAddress":"127.0.0.1:33303","Processes":[{"ContainerName":"example-service1","Pid":4312,"LocalAddress":"127.0.0.1:33303"}]}}
 */
 func validateMachineDebugOutput(output string) {
-	re := regexp.MustCompile(`{"PortForwardCmd":"kubectl port-forward.*}`)
+	re := regexp.MustCompile(`^{"version":"v1","kind":"EditorData","data":{"LocalAddress":"127.0.0.1:\d+","Processes":\[{"ContainerName":"[^"]*","Pid":\d+,"LocalAddress":"127.0.0.1:\d+"[^}]*}.*\]}}$`)
 	By(fmt.Sprintf("Output from validateMachineDebugOutput: %v", output))
 	ExpectWithOffset(1, re.MatchString(output)).To(BeTrue())
 }
 
-// using the kubectl port-forward command spec provided by the Plank pod,
-// port forward, curl, and inspect the curl error message
+// editorDataOf reads the machine output of squashctl, a json document that describes the debug session
+func editorDataOf(dbgJson string) config.EditorData {
+	ed := config.EditorData{}
+	doc := squashctl.JsonDocument{Data: &ed}
+	err := json.Unmarshal([]byte(dbgJson), &doc)
+	ExpectWithOffset(2, err).NotTo(HaveOccurred())
+	ExpectWithOffset(2, doc.Error).To(BeNil())
+	ExpectWithOffset(2, doc.Version).To(Equal(squashctl.JsonVersion))
+	ExpectWithOffset(2, doc.Kind).To(Equal(squashctl.EditorDataKind))
+	return ed
+}
+
+// using the local address that squashctl is forwarding to the Plank pod,
+// curl and inspect the curl error message
 // expect to see the error associated with a rejection, rather than a failure to connect
 func ensureDLVServerIsLive(dbgJson string) {
-	ed := config.EditorData{}
-	err := json.Unmarshal([]byte(dbgJson), &ed)
-	Expect(err).NotTo(HaveOccurred())
-	cmdParts := strings.Split(ed.PortForwardCmd, " ")
-	// 0: kubectl
-	// 1:	port-forward
-	// 2: plankhxpq4
-	// 3: :33303
-	// 4: -n
-	// 5: squash-debugger
-	ports := strings.Split(cmdParts[3], ":")
-	remotePort := ports[1]
-	var localPort int
-	err = utils.FindAnyFreePort(&localPort)
-	Expect(err).NotTo(HaveOccurred())
-	cmdParts[3] = fmt.Sprintf("%v:%v", localPort, remotePort)
-	// the portforward spec includes "kubectl ..." but exec.Command requires the binary be called explicitly
-	pfCmd := exec.Command("kubectl", cmdParts[1:]...)
-	go func() {
-		out, _ := pfCmd.CombinedOutput()
-		fmt.Println(string(out))
-	}()
-	time.Sleep(2 * time.Second)
-	dlvAddr := fmt.Sprintf("localhost:%v", localPort)
-	curlOut, _ := testutils.Curl(dlvAddr)
+	ed := editorDataOf(dbgJson)
+	curlOut, _ := testutils.Curl(ed.LocalAddress)
 	// valid response signature: curl: (52) Empty reply from server
 	// invalid response signature: curl: (7) Failed to connect to localhost port 58239: Connection refused
 	re := regexp.MustCompile(`curl: \(52\) Empty reply from server`)
@@ -12,13 +12,16 @@ func GenerateDebugAttachment(name, namespace, dbgger, image, pod, container, pro
 			Name:      name,
 			Namespace: namespace,
 		},
-		Debugger:  dbgger,
-		Image:     image,
-		Pod:       pod,
-		Container: container,
-	}
-	if processName != "" {
-		da.ProcessName = processName
+		Intent: &v1.Intent{
+			Debugger: dbgger,
+			Pod: &core.ResourceRef{
+				Name:      pod,
+				Namespace: namespace,
+			},
+			ContainerName:  container,
+			ProcessMatcher: processName,
+		},
+		Image: image,
 	}
 	return da
 }
@@ -1,9 +1,11 @@
 package testutils
 
 import (
+	"bufio"
 	"bytes"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"os/exec"
 	"strings"
@@ -128,6 +130,63 @@ func SquashctlOutWithTimeout(args string, timeout *int) (string, error) {
 	return strings.TrimSuffix(out, "\n"), nil
 }
 
+// SquashctlMachineSessionOut runs squashctl with the --machine flag and returns the editor data that it reports.
+// squashctl keeps the debug session open after reporting, so it continues in the background until
+// its debug attachment is deleted.
+func SquashctlMachineSessionOut(args string, timeout *int) (string, error) {
+	timeLimit := 600 // default to 10 minute timeout
+	if timeout != nil {
+		timeLimit = *timeout
+	}
+	stdOut := os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		return "", err
+	}
+	os.Stdout = w
+	restore := func() {
+		_ = w.Close()
+		os.Stdout = stdOut // restoring the real stdout
+	}
+	defer restore()
+
+	app, err := squashctl.App("test")
+	if err != nil {
+		return "", err
+	}
+	app.SetArgs(strings.Split(args, " "))
+
+	errC := make(chan error, 1)
+	go func() {
+		errC <- app.Execute()
+	}()
+	lineC := make(chan string, 1)
+	go func() {
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			if line := scanner.Text(); strings.HasPrefix(line, "{") {
+				lineC <- line
+				break
+			}
+		}
+		// drain anything else written before stdout is restored
+		_, _ = io.Copy(ioutil.Discard, r)
+	}()
+	t := time.NewTimer(time.Duration(timeLimit) * time.Second)
+
+	select {
+	case line := <-lineC:
+		return line, nil
+	case exErr := <-errC:
+		if exErr != nil {
+			return "", exErr
+		}
+		return "", fmt.Errorf("squashctl exited without reporting a debug session")
+	case <-t.C:
+		return "", fmt.Errorf("timeout during squashctl call")
+	}
+}
+
 func Curl(args string) ([]byte, error) {
 	curl := exec.Command("curl", strings.Split(args, " ")...)
 	return curl.CombinedOutput()